@@ -19,6 +19,7 @@ import (
 	"time"
 
 	gocommon "github.com/playground/packages/go-common"
+	"github.com/playground/packages/go-common/lifecycle"
 
 	"github.com/playground/apps/guardrail-server-go/internal/api"
 	"github.com/playground/apps/guardrail-server-go/internal/circuitbreaker"
@@ -27,8 +28,6 @@ import (
 	"github.com/playground/apps/guardrail-server-go/internal/orchestrator"
 )
 
-var shuttingDown = false
-
 func main() {
 	// Load configuration
 	cfg := config.Load()
@@ -39,28 +38,49 @@ func main() {
 	log.Printf("  Model URLs: %v", cfg.ModelURLs())
 	log.Printf("  Model Timeout: %v", cfg.ModelTimeout)
 	log.Printf("  CB Failure Threshold: %d", cfg.CBFailureThreshold)
+	log.Printf("  CB Failure Rate Threshold: %.2f (min %d requests)", cfg.CBFailureRateThreshold, cfg.CBMinRequestVolume)
+	log.Printf("  CB Half-Open Max Concurrent: %d", cfg.CBHalfOpenMaxConcurrent)
 	log.Printf("  Retry Enabled: %v", cfg.RetryEnabled)
 	log.Printf("  Retry Max Attempts: %d", cfg.RetryMaxAttempts)
+	log.Printf("  Cache Enabled: %v", cfg.CacheEnabled)
 
 	// Initialize metrics
 	metrics := gocommon.NewGuardrailMetrics("guardrail-server")
 
-	// Initialize client pool
-	clients := client.NewPool(cfg)
-
 	// Initialize circuit breaker registry
 	breakers := circuitbreaker.NewRegistry(
-		cfg.CBFailureThreshold,
-		cfg.CBSuccessThreshold,
-		cfg.CBRecoveryTimeout,
+		circuitbreaker.Config{
+			FailureThreshold:      cfg.CBFailureThreshold,
+			SuccessThreshold:      cfg.CBSuccessThreshold,
+			RecoveryTimeout:       cfg.CBRecoveryTimeout,
+			FailureRateThreshold:  cfg.CBFailureRateThreshold,
+			MinRequestVolume:      cfg.CBMinRequestVolume,
+			FailureRateWindow:     cfg.CBFailureRateWindow,
+			HalfOpenMaxConcurrent: cfg.CBHalfOpenMaxConcurrent,
+		},
 		metrics.CircuitBreakerState,
+		&circuitbreaker.MetricsStateListener{Transitions: metrics.CircuitBreakerTransitions},
 	)
 
+	// Initialize client pool. It shares the breaker registry above so
+	// Pool.Do's circuit-breaker checks agree with what the admin status
+	// endpoints report.
+	clients := client.NewPool(cfg, breakers)
+
 	// Initialize orchestrator
 	orch := orchestrator.New(cfg, clients, breakers, metrics)
 
+	// Initialize shutdown coordinator and register the client pool's
+	// cleanup so Wait closes it alongside any future closers, in reverse
+	// registration order.
+	lc := lifecycle.NewCoordinator()
+	lc.RegisterCloser("client-pool", 5*time.Second, func(ctx context.Context) error {
+		clients.CloseAll()
+		return nil
+	})
+
 	// Create HTTP handler
-	handler := api.NewHandler(orch, breakers, &shuttingDown)
+	handler := api.NewHandler(orch, breakers, lc)
 
 	// Create ServeMux and register routes
 	mux := http.NewServeMux()
@@ -93,7 +113,12 @@ func main() {
 	<-quit
 
 	log.Println("[guardrail-server-go] Shutdown initiated")
-	shuttingDown = true
+
+	// Flip readiness to draining immediately so upstream load balancers
+	// stop routing here, then give that change time to propagate before
+	// we stop accepting connections at all.
+	lc.MarkDraining()
+	time.Sleep(cfg.PreStopDelay)
 
 	// Wait for in-flight requests to drain (max 5s)
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -104,8 +129,11 @@ func main() {
 		log.Printf("Server shutdown error: %v", err)
 	}
 
-	// Clean up
-	clients.CloseAll()
+	// Close registered resources (currently just the client pool) in
+	// reverse registration order.
+	for _, err := range lc.Wait(ctx) {
+		log.Printf("Shutdown closer error: %v", err)
+	}
 
 	log.Println("[guardrail-server-go] Shutdown complete")
 }
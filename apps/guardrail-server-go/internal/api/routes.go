@@ -3,10 +3,12 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 
 	gocommon "github.com/playground/packages/go-common"
+	"github.com/playground/packages/go-common/lifecycle"
 
 	"github.com/playground/apps/guardrail-server-go/internal/circuitbreaker"
 	"github.com/playground/apps/guardrail-server-go/internal/orchestrator"
@@ -16,15 +18,15 @@ import (
 type Handler struct {
 	orchestrator *orchestrator.Orchestrator
 	breakers     *circuitbreaker.Registry
-	shuttingDown *bool
+	lifecycle    *lifecycle.Coordinator
 }
 
 // NewHandler creates a new API handler.
-func NewHandler(orch *orchestrator.Orchestrator, breakers *circuitbreaker.Registry, shuttingDown *bool) *Handler {
+func NewHandler(orch *orchestrator.Orchestrator, breakers *circuitbreaker.Registry, lc *lifecycle.Coordinator) *Handler {
 	return &Handler{
 		orchestrator: orch,
 		breakers:     breakers,
-		shuttingDown: shuttingDown,
+		lifecycle:    lc,
 	}
 }
 
@@ -32,6 +34,7 @@ func NewHandler(orch *orchestrator.Orchestrator, breakers *circuitbreaker.Regist
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	// Main API routes (Go 1.22+ pattern matching)
 	mux.HandleFunc("POST /v1/validate", h.handleValidate)
+	mux.HandleFunc("POST /v1/validate/stream", h.handleValidateStream)
 	mux.HandleFunc("GET /v1/health", h.handleHealth)
 	mux.HandleFunc("GET /v1/ready", h.handleReady)
 
@@ -84,7 +87,7 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 		r.Context(),
 		req.Text,
 		nil, // All models
-		orchestrator.StrategyAnyFlag,
+		req.Strategy,
 		req.RequestID,
 	)
 	if err != nil {
@@ -101,6 +104,71 @@ func (h *Handler) handleValidate(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, result)
 }
 
+// handleValidateStream handles POST /v1/validate/stream, the SSE
+// counterpart to handleValidate: each model's result is pushed as a
+// "model_result" event as soon as that model replies, followed by one
+// "final" event carrying the aggregated ValidateResponse. Clients can
+// close the connection (or the caller can cancel the request context) as
+// soon as a critical model's event flags, without waiting for the rest.
+func (h *Handler) handleValidateStream(w http.ResponseWriter, r *http.Request) {
+	apiKey := r.Header.Get("X-API-Key")
+	if apiKey == "" {
+		h.writeError(w, http.StatusUnauthorized, "invalid_api_key", "API key required")
+		return
+	}
+
+	var req gocommon.ValidateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "validation_error", "Invalid request body")
+		return
+	}
+	if req.ProjectID == "" {
+		h.writeError(w, http.StatusBadRequest, "validation_error", "project_id is required")
+		return
+	}
+	if req.Text == "" {
+		h.writeError(w, http.StatusBadRequest, "validation_error", "text is required")
+		return
+	}
+	if len(req.Text) > 50000 {
+		h.writeError(w, http.StatusBadRequest, "validation_error", "text exceeds 50000 characters")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		h.writeError(w, http.StatusInternalServerError, "internal_server_error", "streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	resultsCh, finalCh := h.orchestrator.ValidateTextStream(r.Context(), req.Text, nil, req.Strategy, req.RequestID)
+
+	for result := range resultsCh {
+		writeSSEEvent(w, "model_result", result)
+		flusher.Flush()
+	}
+
+	if final := <-finalCh; final != nil {
+		writeSSEEvent(w, "final", final)
+		flusher.Flush()
+	}
+}
+
+// writeSSEEvent writes a single Server-Sent Event with the given event
+// name and a JSON-encoded data payload.
+func writeSSEEvent(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
+
 // handleHealth handles GET /v1/health
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, gocommon.HealthResponse{
@@ -110,9 +178,12 @@ func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 
 // handleReady handles GET /v1/ready
 func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
-	// Check shutdown state
-	if h.shuttingDown != nil && *h.shuttingDown {
-		h.writeError(w, http.StatusServiceUnavailable, "draining", "Server shutting down, not accepting new requests")
+	// Check shutdown state. "draining" is distinct from the "not_ready"
+	// startup state below: it means the server was healthy and is now
+	// intentionally failing readiness so load balancers stop routing here
+	// ahead of the process actually exiting.
+	if h.lifecycle != nil && h.lifecycle.IsDraining() {
+		h.writeJSON(w, http.StatusServiceUnavailable, gocommon.ReadyResponse{Status: "draining"})
 		return
 	}
 
@@ -145,16 +216,12 @@ func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleGetCircuitBreakers handles GET /debug/circuit-breakers
+// handleGetCircuitBreakers handles GET /debug/circuit-breakers. It
+// reports each breaker's sliding-window stats (failure rate, sample
+// count, in-flight half-open probes) alongside its status, which the
+// wire-schema-bound gocommon.CircuitBreakerStatus doesn't carry.
 func (h *Handler) handleGetCircuitBreakers(w http.ResponseWriter, r *http.Request) {
-	breakers := h.breakers.GetAll()
-	result := make(map[string]gocommon.CircuitBreakerStatus)
-
-	for name, cb := range breakers {
-		result[name] = cb.GetStatus()
-	}
-
-	h.writeJSON(w, http.StatusOK, result)
+	h.writeJSON(w, http.StatusOK, h.breakers.Snapshot())
 }
 
 // handleForceCloseCircuitBreaker handles POST /debug/circuit-breakers/{model}/close
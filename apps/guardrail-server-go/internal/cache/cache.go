@@ -0,0 +1,128 @@
+// Package cache provides an optional Redis-backed cache of per-model
+// validation results, keyed by a content hash of the model name and
+// text, so identical input doesn't re-trigger a model call.
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	gocommon "github.com/playground/packages/go-common"
+
+	"github.com/playground/apps/guardrail-server-go/internal/config"
+)
+
+// keyPrefix namespaces result cache entries in the shared Redis instance.
+const keyPrefix = "guardrail:result:"
+
+// Result is a cached model call outcome, success or failure.
+type Result struct {
+	Success  bool
+	Response *gocommon.ModelPredictResponse
+	Error    string
+}
+
+// entry is the JSON encoding of Result stored in Redis.
+type entry struct {
+	Success  bool                           `json:"success"`
+	Response *gocommon.ModelPredictResponse `json:"response,omitempty"`
+	Error    string                         `json:"error,omitempty"`
+}
+
+// ResultCache caches per-model validation results in Redis.
+type ResultCache struct {
+	client     *redis.Client
+	cfg        *config.Config
+	normalized map[string]bool
+}
+
+// New connects to Redis and returns a ResultCache. It returns an error if
+// Redis isn't reachable, so the caller can decide whether to run without
+// a cache rather than fail startup.
+func New(cfg *config.Config) (*ResultCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr: cfg.RedisAddr(),
+		DB:   cfg.RedisDB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+
+	normalized := make(map[string]bool, len(cfg.CacheNormalizedModels))
+	for _, name := range cfg.CacheNormalizedModels {
+		normalized[name] = true
+	}
+
+	return &ResultCache{client: client, cfg: cfg, normalized: normalized}, nil
+}
+
+// Close closes the underlying Redis connection.
+func (c *ResultCache) Close() error {
+	return c.client.Close()
+}
+
+// Get looks up the cached result for modelName and text. The second
+// return value is false on a cache miss.
+func (c *ResultCache) Get(ctx context.Context, modelName, text string) (Result, bool) {
+	val, err := c.client.Get(ctx, c.key(modelName, text)).Result()
+	if err != nil {
+		return Result{}, false
+	}
+
+	var e entry
+	if err := json.Unmarshal([]byte(val), &e); err != nil {
+		return Result{}, false
+	}
+	return Result{Success: e.Success, Response: e.Response, Error: e.Error}, true
+}
+
+// Set caches a successful model result for CacheTTL.
+func (c *ResultCache) Set(ctx context.Context, modelName, text string, response *gocommon.ModelPredictResponse) {
+	c.store(ctx, modelName, text, entry{Success: true, Response: response}, c.cfg.CacheTTL)
+}
+
+// SetNegative caches a failed model call for the shorter
+// CacheNegativeTTL, so a model that's currently failing doesn't get
+// hammered with the exact same input while it recovers.
+func (c *ResultCache) SetNegative(ctx context.Context, modelName, text, errMsg string) {
+	c.store(ctx, modelName, text, entry{Success: false, Error: errMsg}, c.cfg.CacheNegativeTTL)
+}
+
+func (c *ResultCache) store(ctx context.Context, modelName, text string, e entry, ttl time.Duration) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	c.client.Set(ctx, c.key(modelName, text), payload, ttl)
+}
+
+// key builds the content-addressed cache key for modelName and text:
+// sha256(modelName + ":" + normalizedOrRawText). Whether text is
+// normalized first is configured per-model via CacheNormalizedModels.
+func (c *ResultCache) key(modelName, text string) string {
+	if c.normalized[modelName] {
+		text = normalize(text)
+	}
+	sum := sha256.Sum256([]byte(modelName + ":" + text))
+	return keyPrefix + hex.EncodeToString(sum[:])
+}
+
+var whitespaceRe = regexp.MustCompile(`\s+`)
+
+// normalize collapses runs of whitespace and lower-cases text, so
+// semantically-identical input to keyword-style detectors (e.g.
+// inference.DetectPII) shares a cache entry regardless of spacing or case.
+func normalize(text string) string {
+	return strings.ToLower(whitespaceRe.ReplaceAllString(strings.TrimSpace(text), " "))
+}
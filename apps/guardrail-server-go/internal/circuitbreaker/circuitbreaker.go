@@ -28,6 +28,103 @@ const (
 	StateHalfOpen State = 2
 )
 
+// outcomeWindow is a time-bucketed ring buffer of call outcomes, used to
+// compute the failure rate over a trailing window (e.g. the last 60
+// seconds) rather than over the last N calls, so a breaker with bursty
+// traffic and one with a slow trickle are held to the same standard. It
+// mirrors the ring-buffer approach the adaptive limiter uses for its
+// latency window, but buckets by time instead of by sample count since
+// the request volume isn't constant.
+type outcomeWindow struct {
+	mu         sync.Mutex
+	buckets    []bucket
+	bucketSpan time.Duration
+	current    int
+	updatedAt  time.Time
+}
+
+// windowBuckets is the number of slices the sliding window is divided
+// into. A bucket is zeroed out (rather than the whole window resetting
+// at once) once it falls out of range, so the failure rate decays
+// smoothly rather than in one big jump every windowSpan.
+const windowBuckets = 10
+
+type bucket struct {
+	total    int
+	failures int
+}
+
+func newOutcomeWindow(windowSpan time.Duration) *outcomeWindow {
+	if windowSpan <= 0 {
+		windowSpan = 60 * time.Second
+	}
+	return &outcomeWindow{
+		buckets:    make([]bucket, windowBuckets),
+		bucketSpan: windowSpan / windowBuckets,
+		updatedAt:  time.Now(),
+	}
+}
+
+// advance zeroes out any buckets that have aged out of the window since
+// it was last touched. Must be called with the lock held.
+func (w *outcomeWindow) advance(now time.Time) {
+	elapsed := now.Sub(w.updatedAt)
+	if elapsed < w.bucketSpan {
+		return
+	}
+
+	steps := int(elapsed / w.bucketSpan)
+	if steps > len(w.buckets) {
+		steps = len(w.buckets)
+	}
+	for i := 0; i < steps; i++ {
+		w.current = (w.current + 1) % len(w.buckets)
+		w.buckets[w.current] = bucket{}
+	}
+	w.updatedAt = now
+}
+
+func (w *outcomeWindow) record(success bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(time.Now())
+	w.buckets[w.current].total++
+	if !success {
+		w.buckets[w.current].failures++
+	}
+}
+
+// failureRate returns the fraction of failures among the samples
+// currently in the window, along with the sample count.
+func (w *outcomeWindow) failureRate() (rate float64, count int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.advance(time.Now())
+
+	var total, failures int
+	for _, b := range w.buckets {
+		total += b.total
+		failures += b.failures
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return float64(failures) / float64(total), total
+}
+
+func (w *outcomeWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		w.buckets[i] = bucket{}
+	}
+	w.current = 0
+	w.updatedAt = time.Now()
+}
+
 // String returns the state name.
 func (s State) String() string {
 	switch s {
@@ -44,30 +141,88 @@ func (s State) String() string {
 
 // CircuitBreaker provides circuit breaker functionality for a single model service.
 type CircuitBreaker struct {
-	name             string
-	failureThreshold int
-	recoveryTimeout  time.Duration
-	successThreshold int
-
-	mu              sync.Mutex
-	state           State
-	failureCount    int
-	successCount    int
-	lastFailureTime time.Time
+	name                  string
+	failureThreshold      int
+	recoveryTimeout       time.Duration
+	successThreshold      int
+	failureRateThreshold  float64
+	minRequestVolume      int
+	halfOpenMaxConcurrent int
+
+	mu               sync.Mutex
+	state            State
+	failureCount     int
+	successCount     int
+	lastFailureTime  time.Time
+	halfOpenInFlight int
+	window           *outcomeWindow
 
 	// Prometheus gauge for state
 	stateGauge *prometheus.GaugeVec
+	listener   StateListener
 }
 
-// New creates a new circuit breaker.
-func New(name string, failureThreshold, successThreshold int, recoveryTimeout time.Duration, stateGauge *prometheus.GaugeVec) *CircuitBreaker {
+// StateListener is notified whenever a circuit breaker changes state. It
+// exists so state transitions can be exported as discrete events (e.g. a
+// Prometheus counter) in addition to the instantaneous stateGauge, which
+// only ever shows the current state and says nothing about how often a
+// breaker is flapping.
+type StateListener interface {
+	OnStateChange(name string, from, to State)
+}
+
+// MetricsStateListener is a StateListener that records every transition
+// as a Prometheus counter increment.
+type MetricsStateListener struct {
+	Transitions *prometheus.CounterVec
+}
+
+// OnStateChange implements StateListener.
+func (l *MetricsStateListener) OnStateChange(name string, from, to State) {
+	if l == nil || l.Transitions == nil {
+		return
+	}
+	l.Transitions.WithLabelValues(name, from.String(), to.String()).Inc()
+}
+
+// Config groups a circuit breaker's tuning parameters, so New and
+// Registry can share a single struct instead of a long positional
+// parameter list.
+type Config struct {
+	FailureThreshold      int
+	SuccessThreshold      int
+	RecoveryTimeout       time.Duration
+	FailureRateThreshold  float64
+	MinRequestVolume      int
+	FailureRateWindow     time.Duration
+	HalfOpenMaxConcurrent int
+}
+
+// New creates a new circuit breaker. In addition to the original
+// consecutive-failure threshold, it trips open if the failure rate over
+// the trailing cfg.FailureRateWindow exceeds cfg.FailureRateThreshold
+// once at least cfg.MinRequestVolume calls have been observed in that
+// window, which reacts to a model that's failing intermittently over a
+// long stretch rather than only to a tight failure streak. While
+// HALF_OPEN, at most cfg.HalfOpenMaxConcurrent probe requests are
+// allowed through at once. listener may be nil.
+func New(name string, cfg Config, stateGauge *prometheus.GaugeVec, listener StateListener) *CircuitBreaker {
+	if cfg.HalfOpenMaxConcurrent <= 0 {
+		cfg.HalfOpenMaxConcurrent = 1
+	}
+
 	cb := &CircuitBreaker{
-		name:             name,
-		failureThreshold: failureThreshold,
-		successThreshold: successThreshold,
-		recoveryTimeout:  recoveryTimeout,
-		state:            StateClosed,
-		stateGauge:       stateGauge,
+		name:                  name,
+		failureThreshold:      cfg.FailureThreshold,
+		successThreshold:      cfg.SuccessThreshold,
+		recoveryTimeout:       cfg.RecoveryTimeout,
+		failureRateThreshold:  cfg.FailureRateThreshold,
+		minRequestVolume:      cfg.MinRequestVolume,
+		halfOpenMaxConcurrent: cfg.HalfOpenMaxConcurrent,
+		state:                 StateClosed,
+		window:                newOutcomeWindow(cfg.FailureRateWindow),
+		stateGauge:            stateGauge,
+		listener:              listener,
 	}
 
 	// Initialize metric
@@ -78,7 +233,10 @@ func New(name string, failureThreshold, successThreshold int, recoveryTimeout ti
 	return cb
 }
 
-// AllowRequest checks if a request should be allowed.
+// AllowRequest checks if a request should be allowed. While HALF_OPEN,
+// only up to halfOpenMaxConcurrent probes are let through at a time; the
+// caller that's granted one must eventually call RecordSuccess or
+// RecordFailure to release its slot.
 func (cb *CircuitBreaker) AllowRequest() bool {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
@@ -91,7 +249,13 @@ func (cb *CircuitBreaker) AllowRequest() bool {
 	}
 
 	switch cb.state {
-	case StateClosed, StateHalfOpen:
+	case StateClosed:
+		return true
+	case StateHalfOpen:
+		if cb.halfOpenInFlight >= cb.halfOpenMaxConcurrent {
+			return false
+		}
+		cb.halfOpenInFlight++
 		return true
 	default:
 		return false
@@ -103,8 +267,11 @@ func (cb *CircuitBreaker) RecordSuccess() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	cb.window.record(true)
+
 	switch cb.state {
 	case StateHalfOpen:
+		cb.halfOpenInFlight--
 		cb.successCount++
 		if cb.successCount >= cb.successThreshold {
 			cb.transitionTo(StateClosed)
@@ -119,14 +286,18 @@ func (cb *CircuitBreaker) RecordFailure() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
+	cb.window.record(false)
 	cb.failureCount++
 	cb.lastFailureTime = time.Now()
 
 	switch cb.state {
 	case StateHalfOpen:
+		cb.halfOpenInFlight--
 		cb.transitionTo(StateOpen)
 	case StateClosed:
-		if cb.failureCount >= cb.failureThreshold {
+		rate, n := cb.window.failureRate()
+		rateTripped := n >= cb.minRequestVolume && cb.minRequestVolume > 0 && rate > cb.failureRateThreshold
+		if cb.failureCount >= cb.failureThreshold || rateTripped {
 			cb.transitionTo(StateOpen)
 		}
 	}
@@ -134,18 +305,24 @@ func (cb *CircuitBreaker) RecordFailure() {
 
 // transitionTo transitions to a new state. Must be called with lock held.
 func (cb *CircuitBreaker) transitionTo(newState State) {
+	oldState := cb.state
 	cb.state = newState
 
 	if cb.stateGauge != nil {
 		cb.stateGauge.WithLabelValues(cb.name).Set(float64(newState))
 	}
+	if cb.listener != nil && oldState != newState {
+		cb.listener.OnStateChange(cb.name, oldState, newState)
+	}
 
 	// Reset counters on state change
 	switch newState {
 	case StateClosed:
 		cb.failureCount = 0
+		cb.window.reset()
 	case StateHalfOpen:
 		cb.successCount = 0
+		cb.halfOpenInFlight = 0
 	}
 }
 
@@ -197,29 +374,73 @@ func (cb *CircuitBreaker) State() State {
 	return cb.state
 }
 
+// Snapshot is a point-in-time view of a breaker's sliding window, for
+// debugging and the admin endpoint. Unlike GetStatus, which matches the
+// Python service's wire schema, Snapshot is Go-only and free to grow.
+type Snapshot struct {
+	Name              string  `json:"name"`
+	State             string  `json:"state"`
+	FailureCount      int     `json:"failure_count"`
+	SuccessCount      int     `json:"success_count"`
+	WindowFailureRate float64 `json:"window_failure_rate"`
+	WindowRequests    int     `json:"window_requests"`
+	HalfOpenInFlight  int     `json:"half_open_in_flight"`
+}
+
+// Snapshot returns the breaker's current window stats alongside its
+// status.
+func (cb *CircuitBreaker) Snapshot() Snapshot {
+	cb.mu.Lock()
+	state := cb.state
+	if cb.state == StateOpen && time.Since(cb.lastFailureTime) >= cb.recoveryTimeout {
+		state = StateHalfOpen
+	}
+	snap := Snapshot{
+		Name:             cb.name,
+		State:            state.String(),
+		FailureCount:     cb.failureCount,
+		SuccessCount:     cb.successCount,
+		HalfOpenInFlight: cb.halfOpenInFlight,
+	}
+	cb.mu.Unlock()
+
+	snap.WindowFailureRate, snap.WindowRequests = cb.window.failureRate()
+	return snap
+}
+
 // Registry holds all circuit breakers.
 type Registry struct {
 	breakers map[string]*CircuitBreaker
 	mu       sync.RWMutex
 
 	// Config for new breakers
-	failureThreshold int
-	successThreshold int
-	recoveryTimeout  time.Duration
-	stateGauge       *prometheus.GaugeVec
+	cfg        Config
+	stateGauge *prometheus.GaugeVec
+	listener   StateListener
 }
 
-// NewRegistry creates a new circuit breaker registry.
-func NewRegistry(failureThreshold, successThreshold int, recoveryTimeout time.Duration, stateGauge *prometheus.GaugeVec) *Registry {
+// NewRegistry creates a new circuit breaker registry. listener, if
+// non-nil, is attached to every breaker the registry creates.
+func NewRegistry(cfg Config, stateGauge *prometheus.GaugeVec, listener StateListener) *Registry {
 	return &Registry{
-		breakers:         make(map[string]*CircuitBreaker),
-		failureThreshold: failureThreshold,
-		successThreshold: successThreshold,
-		recoveryTimeout:  recoveryTimeout,
-		stateGauge:       stateGauge,
+		breakers:   make(map[string]*CircuitBreaker),
+		cfg:        cfg,
+		stateGauge: stateGauge,
+		listener:   listener,
 	}
 }
 
+// Snapshot returns a Snapshot for every registered breaker, keyed by
+// model name.
+func (r *Registry) Snapshot() map[string]Snapshot {
+	breakers := r.GetAll()
+	result := make(map[string]Snapshot, len(breakers))
+	for name, cb := range breakers {
+		result[name] = cb.Snapshot()
+	}
+	return result
+}
+
 // Get returns the circuit breaker for a model, creating it if necessary.
 func (r *Registry) Get(name string) *CircuitBreaker {
 	r.mu.RLock()
@@ -238,7 +459,7 @@ func (r *Registry) Get(name string) *CircuitBreaker {
 		return cb
 	}
 
-	cb = New(name, r.failureThreshold, r.successThreshold, r.recoveryTimeout, r.stateGauge)
+	cb = New(name, r.cfg, r.stateGauge, r.listener)
 	r.breakers[name] = cb
 	return cb
 }
@@ -2,30 +2,150 @@
 package client
 
 import (
+	"context"
+	"errors"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/playground/apps/guardrail-server-go/internal/circuitbreaker"
 	"github.com/playground/apps/guardrail-server-go/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
-// Pool manages HTTP clients for model services.
+// ErrCircuitOpen is returned by Do when modelName's circuit breaker has
+// tripped open, before any attempt is made to reach the model.
+var ErrCircuitOpen = errors.New("client: circuit breaker open")
+
+// ErrOverloaded is returned by Do when modelName's in-flight semaphore
+// couldn't be acquired within cfg.ModelQueueTimeout, i.e. the model
+// already has ModelMaxInFlight calls outstanding.
+var ErrOverloaded = errors.New("client: model overloaded")
+
+// Pool manages HTTP clients for model services, plus the per-model
+// circuit breaker and in-flight semaphore Do enforces around them.
 type Pool struct {
-	clients map[string]*http.Client
-	mu      sync.RWMutex
-	cfg     *config.Config
+	clients  map[string]*http.Client
+	mu       sync.RWMutex
+	cfg      *config.Config
+	replicas map[string][]string
+	breakers *circuitbreaker.Registry
+
+	rrMu    sync.Mutex
+	rrIndex map[string]int
+
+	semMu      sync.Mutex
+	semaphores map[string]chan struct{}
+
+	breakerState *prometheus.GaugeVec
+	inflight     *prometheus.GaugeVec
+	shedTotal    *prometheus.CounterVec
+}
+
+// NewPool creates a new client pool. breakers is shared with whatever
+// else in the process needs to inspect or report on circuit breaker
+// state (e.g. the admin status endpoints), so Pool.Do's breaker checks
+// and the rest of the server's view of a model's health stay in sync.
+func NewPool(cfg *config.Config, breakers *circuitbreaker.Registry) *Pool {
+	p := &Pool{
+		clients:    make(map[string]*http.Client),
+		cfg:        cfg,
+		replicas:   cfg.ModelReplicaURLs(),
+		breakers:   breakers,
+		rrIndex:    make(map[string]int),
+		semaphores: make(map[string]chan struct{}),
+
+		breakerState: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "model_breaker_state",
+				Help: "Circuit breaker state as seen by client.Pool.Do (0=closed, 1=open, 2=half_open)",
+			},
+			[]string{"model_name"},
+		),
+		inflight: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "model_inflight",
+				Help: "Calls to a model currently holding a Pool.Do in-flight slot",
+			},
+			[]string{"model_name"},
+		),
+		shedTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "model_shed_total",
+				Help: "Total requests Pool.Do shed without reaching the model",
+			},
+			[]string{"model_name", "reason"},
+		),
+	}
+
+	prometheus.MustRegister(p.breakerState, p.inflight, p.shedTotal)
+
+	return p
 }
 
-// NewPool creates a new client pool.
-func NewPool(cfg *config.Config) *Pool {
-	return &Pool{
-		clients: make(map[string]*http.Client),
-		cfg:     cfg,
+// semaphore returns modelName's in-flight semaphore, creating one sized
+// from cfg.ModelMaxInFlight(modelName) on first use.
+func (p *Pool) semaphore(modelName string) chan struct{} {
+	p.semMu.Lock()
+	defer p.semMu.Unlock()
+
+	if sem, ok := p.semaphores[modelName]; ok {
+		return sem
 	}
+
+	sem := make(chan struct{}, p.cfg.ModelMaxInFlight(modelName))
+	p.semaphores[modelName] = sem
+	return sem
+}
+
+// Do issues req against modelName's HTTP client, guarded by its circuit
+// breaker and in-flight semaphore: it rejects immediately with
+// ErrCircuitOpen if the breaker is open, then blocks up to
+// cfg.ModelQueueTimeout for a free in-flight slot before giving up with
+// ErrOverloaded. A transport error or 5xx response counts as a failure
+// against the breaker; anything else counts as a success.
+func (p *Pool) Do(ctx context.Context, modelName string, req *http.Request) (*http.Response, error) {
+	cb := p.breakers.Get(modelName)
+	p.breakerState.WithLabelValues(modelName).Set(float64(cb.State()))
+
+	if !cb.AllowRequest() {
+		p.shedTotal.WithLabelValues(modelName, "circuit_open").Inc()
+		return nil, ErrCircuitOpen
+	}
+
+	sem := p.semaphore(modelName)
+	queueCtx, cancel := context.WithTimeout(ctx, p.cfg.ModelQueueTimeout)
+	defer cancel()
+
+	select {
+	case sem <- struct{}{}:
+	case <-queueCtx.Done():
+		p.shedTotal.WithLabelValues(modelName, "queue_timeout").Inc()
+		// AllowRequest already counted this as an admitted attempt (and,
+		// while HALF_OPEN, claimed one of its limited probe slots), so it
+		// must be resolved one way or the other rather than left hanging.
+		cb.RecordFailure()
+		return nil, ErrOverloaded
+	}
+	defer func() { <-sem }()
+
+	p.inflight.WithLabelValues(modelName).Inc()
+	defer p.inflight.WithLabelValues(modelName).Dec()
+
+	resp, err := p.Get(modelName).Do(req)
+	if err != nil || (resp != nil && resp.StatusCode >= 500) {
+		cb.RecordFailure()
+	} else {
+		cb.RecordSuccess()
+	}
+	return resp, err
 }
 
 // Get returns an HTTP client for the specified model.
+//
+// Deprecated: prefer Do, which additionally enforces the model's circuit
+// breaker and in-flight concurrency ceiling around the call.
 func (p *Pool) Get(modelName string) *http.Client {
 	p.mu.RLock()
 	client, exists := p.clients[modelName]
@@ -67,10 +187,37 @@ func (p *Pool) createClient() *http.Client {
 	}
 }
 
-// GetBaseURL returns the base URL for a model.
+// GetBaseURL returns the primary base URL configured for a model.
 func (p *Pool) GetBaseURL(modelName string) string {
-	urls := p.cfg.ModelURLs()
-	return urls[modelName]
+	urls := p.replicas[modelName]
+	if len(urls) == 0 {
+		return ""
+	}
+	return urls[0]
+}
+
+// PickBaseURL returns a replica base URL for modelName, preferring one
+// that isn't exclude so a hedged request's second attempt lands on a
+// different replica than the one already in flight. Replicas are chosen
+// round-robin; if only one replica is configured, it's returned as-is.
+func (p *Pool) PickBaseURL(modelName, exclude string) string {
+	urls := p.replicas[modelName]
+	if len(urls) == 0 {
+		return ""
+	}
+	if len(urls) == 1 {
+		return urls[0]
+	}
+
+	p.rrMu.Lock()
+	idx := p.rrIndex[modelName]
+	p.rrIndex[modelName] = (idx + 1) % len(urls)
+	p.rrMu.Unlock()
+
+	if candidate := urls[idx]; candidate != exclude {
+		return candidate
+	}
+	return urls[(idx+1)%len(urls)]
 }
 
 // CloseAll closes all clients in the pool.
@@ -4,6 +4,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,11 @@ type Config struct {
 	Host  string
 	Port  int
 	Debug bool
+	// PreStopDelay is how long the server waits, after readiness flips to
+	// "draining" on shutdown, before it stops accepting connections. It
+	// gives the upstream load balancer time to notice and stop routing
+	// here before in-flight requests are cut off.
+	PreStopDelay time.Duration
 
 	// Model service URLs
 	ModelPromptGuardURL  string
@@ -28,14 +34,87 @@ type Config struct {
 	CBFailureThreshold int
 	CBRecoveryTimeout  time.Duration
 	CBSuccessThreshold int
+	// CBFailureRateThreshold and CBMinRequestVolume drive an additional
+	// sliding-window trip condition: the breaker also opens if the
+	// failure rate over the trailing CBFailureRateWindow exceeds
+	// CBFailureRateThreshold, once at least CBMinRequestVolume calls have
+	// been observed in that window. This catches a model that's failing,
+	// say, half the time without ever stringing together
+	// CBFailureThreshold failures in a row.
+	CBFailureRateThreshold float64
+	CBMinRequestVolume     int
+	CBFailureRateWindow    time.Duration
+	// CBHalfOpenMaxConcurrent caps how many probe requests are allowed
+	// through at once while a breaker is HALF_OPEN.
+	CBHalfOpenMaxConcurrent int
 
 	// Retry configuration
 	RetryEnabled     bool
 	RetryMaxAttempts int
 	RetryWaitMs      int
+
+	// Aggregation configuration
+	// ModelWeights is parsed from MODEL_WEIGHTS, e.g.
+	// "prompt-guard:2.0,pii-detect:1.0", for the weighted_threshold and
+	// log_odds aggregation strategies.
+	ModelWeights map[string]float64
+	// AggregationThreshold is the score above which weighted_threshold and
+	// log_odds flag the request.
+	AggregationThreshold float64
+	// VetoModels unilaterally flag the request if they flag, regardless of
+	// what the rest of the ensemble says (the "veto" strategy).
+	VetoModels []string
+
+	// Adaptive concurrency configuration. The orchestrator's per-model
+	// limiter starts at AdaptiveLimitInitial in-flight calls and grows or
+	// shrinks within [AdaptiveLimitMin, AdaptiveLimitMax] based on observed
+	// latency and errors.
+	AdaptiveLimitInitial int
+	AdaptiveLimitMin     int
+	AdaptiveLimitMax     int
+
+	// Per-model hard concurrency ceiling enforced by client.Pool.Do, on
+	// top of (not instead of) the adaptive limiter above: where that
+	// limit grows and shrinks with observed latency, this one is a fixed
+	// cap from MODEL_MAX_INFLIGHT_<NAME> (see ModelMaxInFlight) that
+	// never grows past what the caller has deemed safe, no matter how
+	// fast responses currently look.
+	ModelMaxInFlightDefault int
+	// ModelQueueTimeout bounds how long Pool.Do blocks waiting for an
+	// in-flight slot before shedding the request with ErrOverloaded.
+	ModelQueueTimeout time.Duration
+
+	// Hedged request configuration. When HedgeEnabled, a model call that
+	// hasn't returned within its moving p95 latency times HedgeFactor
+	// fires a second attempt against a different replica; HedgeMinDelay is
+	// used instead while there isn't yet enough latency history to make
+	// that estimate.
+	HedgeEnabled  bool
+	HedgeFactor   float64
+	HedgeMinDelay time.Duration
+
+	// Redis connection, shared by the result cache.
+	RedisHost string
+	RedisPort string
+	RedisDB   int
+
+	// Result cache configuration. When CacheEnabled, the orchestrator
+	// looks up a SHA-256 hash of (model name, text) in Redis before
+	// calling a model, and stores the response on a miss.
+	CacheEnabled     bool
+	CacheTTL         time.Duration
+	CacheNegativeTTL time.Duration
+	// CacheNormalizedModels lists models whose text is trimmed, has
+	// whitespace collapsed, and is lower-cased before hashing, so
+	// semantically-identical input shares a cache entry for
+	// keyword-style detectors (e.g. inference.DetectPII). Models not
+	// listed are hashed on the raw text, for pattern-sensitive detectors
+	// where casing and spacing matter.
+	CacheNormalizedModels []string
 }
 
-// ModelURLs returns a map of model names to their URLs.
+// ModelURLs returns a map of model names to their (possibly
+// comma-separated) configured URL(s).
 func (c *Config) ModelURLs() map[string]string {
 	return map[string]string{
 		"prompt-guard":  c.ModelPromptGuardURL,
@@ -45,13 +124,51 @@ func (c *Config) ModelURLs() map[string]string {
 	}
 }
 
+// ModelMaxInFlight returns modelName's hard in-flight concurrency ceiling:
+// MODEL_MAX_INFLIGHT_<NAME> (dashes in modelName become underscores,
+// upper-cased), falling back to ModelMaxInFlightDefault if unset.
+func (c *Config) ModelMaxInFlight(modelName string) int {
+	key := "MODEL_MAX_INFLIGHT_" + strings.ToUpper(strings.ReplaceAll(modelName, "-", "_"))
+	return getEnvInt(key, c.ModelMaxInFlightDefault)
+}
+
+// RedisAddr returns the Redis address in host:port form.
+func (c *Config) RedisAddr() string {
+	return c.RedisHost + ":" + c.RedisPort
+}
+
+// ModelReplicaURLs returns each model's configured base URLs split on
+// commas, so a single MODEL_*_URL env var can name multiple replicas
+// (e.g. "http://a:8000,http://b:8000") for the client pool to pick
+// between when issuing hedged requests.
+func (c *Config) ModelReplicaURLs() map[string][]string {
+	replicas := make(map[string][]string)
+	for name, urls := range c.ModelURLs() {
+		replicas[name] = splitList(urls)
+	}
+	return replicas
+}
+
+// splitList splits a comma-separated string into a trimmed, non-empty list.
+func splitList(value string) []string {
+	parts := strings.Split(value, ",")
+	list := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
 // Load loads configuration from environment variables.
 func Load() *Config {
 	return &Config{
 		// Server configuration
-		Host:  getEnv("HOST", "0.0.0.0"),
-		Port:  getEnvInt("PORT", 8000),
-		Debug: getEnvBool("DEBUG", false),
+		Host:         getEnv("HOST", "0.0.0.0"),
+		Port:         getEnvInt("PORT", 8000),
+		Debug:        getEnvBool("DEBUG", false),
+		PreStopDelay: getEnvDuration("PRE_STOP_DELAY_SECONDS", 2*time.Second),
 
 		// Model service URLs
 		ModelPromptGuardURL:  getEnv("MODEL_PROMPT_GUARD_URL", "http://model-prompt-guard:8000"),
@@ -64,14 +181,48 @@ func Load() *Config {
 		ModelConnectTimeout: getEnvDuration("MODEL_CONNECT_TIMEOUT", 20*time.Millisecond),
 
 		// Circuit breaker configuration
-		CBFailureThreshold: getEnvInt("CB_FAILURE_THRESHOLD", 5),
-		CBRecoveryTimeout:  getEnvDuration("CB_RECOVERY_TIMEOUT", 30*time.Second),
-		CBSuccessThreshold: getEnvInt("CB_SUCCESS_THRESHOLD", 3),
+		CBFailureThreshold:      getEnvInt("CB_FAILURE_THRESHOLD", 5),
+		CBRecoveryTimeout:       getEnvDuration("CB_RECOVERY_TIMEOUT", 30*time.Second),
+		CBSuccessThreshold:      getEnvInt("CB_SUCCESS_THRESHOLD", 3),
+		CBFailureRateThreshold:  getEnvFloat("CB_FAILURE_RATE_THRESHOLD", 0.5),
+		CBMinRequestVolume:      getEnvInt("CB_MIN_REQUEST_VOLUME", 10),
+		CBFailureRateWindow:     getEnvDuration("CB_FAILURE_RATE_WINDOW", 60*time.Second),
+		CBHalfOpenMaxConcurrent: getEnvInt("CB_HALF_OPEN_MAX_CONCURRENT", 1),
 
 		// Retry configuration
 		RetryEnabled:     getEnvBool("RETRY_ENABLED", true),
 		RetryMaxAttempts: getEnvInt("RETRY_MAX_ATTEMPTS", 2),
 		RetryWaitMs:      getEnvInt("RETRY_WAIT_MS", 5),
+
+		// Aggregation configuration
+		ModelWeights:         getEnvWeights("MODEL_WEIGHTS"),
+		AggregationThreshold: getEnvFloat("AGGREGATION_THRESHOLD", 0.5),
+		VetoModels:           getEnvList("VETO_MODELS", []string{"prompt-guard"}),
+
+		// Adaptive concurrency configuration
+		AdaptiveLimitInitial: getEnvInt("ADAPTIVE_LIMIT_INITIAL", 10),
+		AdaptiveLimitMin:     getEnvInt("ADAPTIVE_LIMIT_MIN", 1),
+		AdaptiveLimitMax:     getEnvInt("ADAPTIVE_LIMIT_MAX", 50),
+
+		// Per-model hard concurrency ceiling (client.Pool.Do)
+		ModelMaxInFlightDefault: getEnvInt("MODEL_MAX_INFLIGHT_DEFAULT", 50),
+		ModelQueueTimeout:       getEnvDuration("MODEL_QUEUE_TIMEOUT_SECONDS", 20*time.Millisecond),
+
+		// Hedged request configuration
+		HedgeEnabled:  getEnvBool("HEDGE_ENABLED", true),
+		HedgeFactor:   getEnvFloat("HEDGE_FACTOR", 1.0),
+		HedgeMinDelay: getEnvDuration("HEDGE_MIN_DELAY_SECONDS", 50*time.Millisecond),
+
+		// Redis configuration
+		RedisHost: getEnv("REDIS_HOST", "localhost"),
+		RedisPort: getEnv("REDIS_PORT", "6379"),
+		RedisDB:   getEnvInt("REDIS_DB", 0),
+
+		// Result cache configuration
+		CacheEnabled:          getEnvBool("CACHE_ENABLED", false),
+		CacheTTL:              getEnvDuration("CACHE_TTL_SECONDS", 300*time.Second),
+		CacheNegativeTTL:      getEnvDuration("CACHE_NEGATIVE_TTL_SECONDS", 30*time.Second),
+		CacheNormalizedModels: getEnvList("CACHE_NORMALIZED_MODELS", []string{"pii-detect"}),
 	}
 }
 
@@ -103,6 +254,58 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvFloat gets a float environment variable with a default value.
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList gets a comma-separated list environment variable with a
+// default value.
+func getEnvList(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	return splitList(value)
+}
+
+// getEnvWeights parses a "model:weight,model:weight" environment variable
+// (e.g. MODEL_WEIGHTS=prompt-guard:2.0,pii-detect:1.0) into a model name to
+// weight map. Malformed entries are skipped.
+func getEnvWeights(key string) map[string]float64 {
+	weights := make(map[string]float64)
+
+	value := os.Getenv(key)
+	if value == "" {
+		return weights
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			continue
+		}
+		weights[strings.TrimSpace(parts[0])] = weight
+	}
+
+	return weights
+}
+
 // getEnvDuration gets a duration environment variable.
 // For MODEL_TIMEOUT_SECONDS, RECOVERY_TIMEOUT etc., expects seconds as float.
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
@@ -0,0 +1,268 @@
+package orchestrator
+
+import (
+	"math"
+	"sync"
+
+	gocommon "github.com/playground/packages/go-common"
+
+	"github.com/playground/apps/guardrail-server-go/internal/config"
+)
+
+// Aggregator combines per-model results into a single flagged/score
+// decision. Implementations are registered in a Registry by name so
+// ValidateText can select one at request time.
+type Aggregator interface {
+	Aggregate(results map[string]gocommon.ModelResultResponse) (flagged bool, score float64, reasons []string)
+}
+
+// Registry holds the named aggregation strategies available to
+// ValidateText, analogous to circuitbreaker.Registry.
+type Registry struct {
+	mu     sync.RWMutex
+	byName map[string]Aggregator
+}
+
+// NewAggregatorRegistry builds a Registry pre-loaded with the built-in
+// strategies, configured from cfg: per-model weights and threshold for
+// WeightedThresholdAggregator and LogOddsAggregator, and the critical
+// model list for VetoAggregator (which falls back to weighted threshold).
+func NewAggregatorRegistry(cfg *config.Config) *Registry {
+	weighted := &WeightedThresholdAggregator{
+		Weights:       cfg.ModelWeights,
+		DefaultWeight: 1.0,
+		Threshold:     cfg.AggregationThreshold,
+	}
+	logOdds := &LogOddsAggregator{
+		Weights:       cfg.ModelWeights,
+		DefaultWeight: 1.0,
+		Threshold:     cfg.AggregationThreshold,
+	}
+	veto := &VetoAggregator{
+		VetoModels: toSet(cfg.VetoModels),
+		Fallback:   weighted,
+	}
+
+	r := &Registry{byName: make(map[string]Aggregator)}
+	r.Register("any_flag", AnyFlagAggregator{})
+	r.Register("all_flag", AllFlagAggregator{})
+	r.Register("majority", MajorityAggregator{})
+	r.Register("weighted_threshold", weighted)
+	r.Register("log_odds", logOdds)
+	r.Register("veto", veto)
+	return r
+}
+
+// Register adds or replaces the aggregator registered under name.
+func (r *Registry) Register(name string, agg Aggregator) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byName[name] = agg
+}
+
+// Get looks up the aggregator registered under name.
+func (r *Registry) Get(name string) (Aggregator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	agg, ok := r.byName[name]
+	return agg, ok
+}
+
+// AnyFlagAggregator flags if any model flags. Its score is the highest
+// individual model score, so a flagged result is never reported at 0.
+type AnyFlagAggregator struct{}
+
+func (AnyFlagAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	var flagged bool
+	var maxScore float64
+	var reasons []string
+
+	for name, r := range results {
+		if r.Score > maxScore {
+			maxScore = r.Score
+		}
+		if r.Flagged {
+			flagged = true
+			reasons = append(reasons, name+"_flagged")
+		}
+	}
+
+	return flagged, maxScore, reasons
+}
+
+// AllFlagAggregator flags only if every model flags. Its score is the
+// average of the per-model scores.
+type AllFlagAggregator struct{}
+
+func (AllFlagAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	if len(results) == 0 {
+		return false, 0, nil
+	}
+
+	all := true
+	var total float64
+	var reasons []string
+
+	for name, r := range results {
+		total += r.Score
+		if r.Flagged {
+			reasons = append(reasons, name+"_flagged")
+		} else {
+			all = false
+		}
+	}
+
+	return all, total / float64(len(results)), reasons
+}
+
+// MajorityAggregator flags if more than half the models flag. Its score
+// is the average of the per-model scores.
+type MajorityAggregator struct{}
+
+func (MajorityAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	if len(results) == 0 {
+		return false, 0, nil
+	}
+
+	count := 0
+	var total float64
+	var reasons []string
+
+	for name, r := range results {
+		total += r.Score
+		if r.Flagged {
+			count++
+			reasons = append(reasons, name+"_flagged")
+		}
+	}
+
+	flagged := float64(count) > float64(len(results))/2
+	return flagged, total / float64(len(results)), reasons
+}
+
+// WeightedThresholdAggregator flags if the weighted average of per-model
+// scores exceeds Threshold. Weights come from config.Config.ModelWeights
+// (MODEL_WEIGHTS env var), falling back to DefaultWeight for any model not
+// listed there.
+type WeightedThresholdAggregator struct {
+	Weights       map[string]float64
+	DefaultWeight float64
+	Threshold     float64
+}
+
+func (a *WeightedThresholdAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	if len(results) == 0 {
+		return false, 0, nil
+	}
+
+	var weightedSum, weightTotal float64
+	var reasons []string
+
+	for name, r := range results {
+		w := a.weightFor(name)
+		weightedSum += w * r.Score
+		weightTotal += w
+		if r.Flagged {
+			reasons = append(reasons, name+"_flagged")
+		}
+	}
+
+	if weightTotal == 0 {
+		return false, 0, reasons
+	}
+
+	score := weightedSum / weightTotal
+	return score > a.Threshold, score, reasons
+}
+
+func (a *WeightedThresholdAggregator) weightFor(model string) float64 {
+	if w, ok := a.Weights[model]; ok {
+		return w
+	}
+	return a.DefaultWeight
+}
+
+// logitEpsilon keeps logit() finite for scores at or near 0 or 1.
+const logitEpsilon = 1e-6
+
+// LogOddsAggregator treats each model's score as a probability and
+// combines them in log-odds space: logit(p_final) = Σ w_i * logit(p_i).
+// This weighs strong evidence (scores near 0 or 1) more heavily than a
+// plain weighted average would, which is the point of a Bayesian-style
+// combiner over a simple mean.
+type LogOddsAggregator struct {
+	Weights       map[string]float64
+	DefaultWeight float64
+	Threshold     float64
+}
+
+func (a *LogOddsAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	if len(results) == 0 {
+		return false, 0, nil
+	}
+
+	var combinedLogit float64
+	var reasons []string
+
+	for name, r := range results {
+		w := a.weightFor(name)
+		combinedLogit += w * logit(clampProbability(r.Score))
+		if r.Flagged {
+			reasons = append(reasons, name+"_flagged")
+		}
+	}
+
+	score := sigmoid(combinedLogit)
+	return score > a.Threshold, score, reasons
+}
+
+func (a *LogOddsAggregator) weightFor(model string) float64 {
+	if w, ok := a.Weights[model]; ok {
+		return w
+	}
+	return a.DefaultWeight
+}
+
+func clampProbability(p float64) float64 {
+	if p < logitEpsilon {
+		return logitEpsilon
+	}
+	if p > 1-logitEpsilon {
+		return 1 - logitEpsilon
+	}
+	return p
+}
+
+func logit(p float64) float64 {
+	return math.Log(p / (1 - p))
+}
+
+func sigmoid(x float64) float64 {
+	return 1 / (1 + math.Exp(-x))
+}
+
+// VetoAggregator flags unconditionally if any model in VetoModels flags,
+// regardless of what the rest of the ensemble says, and otherwise defers
+// entirely to Fallback. Use this for critical models (e.g. prompt-guard)
+// whose positive signal shouldn't be diluted by averaging.
+type VetoAggregator struct {
+	VetoModels map[string]bool
+	Fallback   Aggregator
+}
+
+func (a *VetoAggregator) Aggregate(results map[string]gocommon.ModelResultResponse) (bool, float64, []string) {
+	for name, r := range results {
+		if a.VetoModels[name] && r.Flagged {
+			return true, r.Score, []string{name + "_veto_flagged"}
+		}
+	}
+	return a.Fallback.Aggregate(results)
+}
+
+func toSet(names []string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
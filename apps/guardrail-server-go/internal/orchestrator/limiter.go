@@ -0,0 +1,217 @@
+package orchestrator
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gocommon "github.com/playground/packages/go-common"
+
+	"github.com/playground/apps/guardrail-server-go/internal/config"
+)
+
+// latencyWindowSize is the number of recent per-model latency samples
+// used to estimate moving p50/p95, large enough to smooth out noise
+// without requiring a real histogram sketch.
+const latencyWindowSize = 128
+
+// latencyWindow is a fixed-size ring buffer of recent latencies, used to
+// estimate moving percentiles cheaply in-process.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size)}
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < cap(w.samples) {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % len(w.samples)
+}
+
+// percentile returns the p-th percentile (0-1) of the current samples, or
+// 0 if there aren't any yet.
+func (w *latencyWindow) percentile(p float64) time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration(nil), w.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	idx := int(p * float64(len(sorted)-1))
+	return sorted[idx]
+}
+
+// AdaptiveLimiter bounds the number of in-flight calls to a single model
+// using a Little's-law-style limit: it shrinks multiplicatively when
+// latency spikes or a call errors, and grows additively when calls
+// complete quickly, and it tracks how often hedged requests actually pay
+// off for that model.
+type AdaptiveLimiter struct {
+	modelName string
+	metrics   *gocommon.Metrics
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	inFlight int
+	limit    float64
+	minLimit float64
+	maxLimit float64
+
+	window *latencyWindow
+
+	hedgesFired int64
+	hedgesWon   int64
+}
+
+func newAdaptiveLimiter(modelName string, initial, min, max int, metrics *gocommon.Metrics) *AdaptiveLimiter {
+	l := &AdaptiveLimiter{
+		modelName: modelName,
+		metrics:   metrics,
+		limit:     float64(initial),
+		minLimit:  float64(min),
+		maxLimit:  float64(max),
+		window:    newLatencyWindow(latencyWindowSize),
+	}
+	l.cond = sync.NewCond(&l.mu)
+
+	if metrics != nil && metrics.AdaptiveConcurrencyLimit != nil {
+		metrics.AdaptiveConcurrencyLimit.WithLabelValues(modelName).Set(l.limit)
+	}
+	return l
+}
+
+// Acquire blocks until a slot is available under the current limit, or
+// ctx is cancelled. Every successful Acquire must be paired with Release.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if done := ctx.Done(); done != nil {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			select {
+			case <-done:
+				l.cond.Broadcast()
+			case <-stop:
+			}
+		}()
+	}
+
+	for l.inFlight >= int(l.limit) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.cond.Wait()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	l.inFlight++
+	return nil
+}
+
+// Release frees the slot acquired by a matching Acquire.
+func (l *AdaptiveLimiter) Release() {
+	l.mu.Lock()
+	l.inFlight--
+	l.cond.Broadcast()
+	l.mu.Unlock()
+}
+
+// Percentile returns the limiter's current moving p-th percentile (0-1)
+// latency estimate for this model, or 0 if there isn't enough history yet.
+func (l *AdaptiveLimiter) Percentile(p float64) time.Duration {
+	return l.window.percentile(p)
+}
+
+// Observe records a completed call's latency and adjusts limit: it
+// shrinks multiplicatively (limit *= 0.9) on an error or a latency spike
+// more than 2x the moving p95, and grows additively (limit++) on a
+// success faster than the moving p50, each clamped to [minLimit, maxLimit].
+func (l *AdaptiveLimiter) Observe(latency time.Duration, errored bool) {
+	p50 := l.window.percentile(0.5)
+	p95 := l.window.percentile(0.95)
+	l.window.add(latency)
+
+	l.mu.Lock()
+	switch {
+	case errored || (p95 > 0 && latency > 2*p95):
+		l.limit = math.Max(l.minLimit, l.limit*0.9)
+	case p50 > 0 && latency < p50:
+		l.limit = math.Min(l.maxLimit, l.limit+1)
+	}
+	current := l.limit
+	l.mu.Unlock()
+
+	if l.metrics != nil && l.metrics.AdaptiveConcurrencyLimit != nil {
+		l.metrics.AdaptiveConcurrencyLimit.WithLabelValues(l.modelName).Set(current)
+	}
+}
+
+// RecordHedgeOutcome records that a hedged call fired its delayed second
+// attempt, and whether that attempt won the race against the primary,
+// updating the model's hedge-win-rate gauge.
+func (l *AdaptiveLimiter) RecordHedgeOutcome(hedgeWon bool) {
+	fired := atomic.AddInt64(&l.hedgesFired, 1)
+	won := atomic.LoadInt64(&l.hedgesWon)
+	if hedgeWon {
+		won = atomic.AddInt64(&l.hedgesWon, 1)
+	}
+
+	if l.metrics != nil && l.metrics.HedgeWinRate != nil {
+		l.metrics.HedgeWinRate.WithLabelValues(l.modelName).Set(float64(won) / float64(fired))
+	}
+}
+
+// LimiterRegistry holds the per-model AdaptiveLimiters, analogous to
+// circuitbreaker.Registry and orchestrator.Registry.
+type LimiterRegistry struct {
+	mu       sync.Mutex
+	limiters map[string]*AdaptiveLimiter
+	cfg      *config.Config
+	metrics  *gocommon.Metrics
+}
+
+// NewLimiterRegistry creates an empty registry that lazily builds a
+// limiter per model name on first use, configured from cfg.
+func NewLimiterRegistry(cfg *config.Config, metrics *gocommon.Metrics) *LimiterRegistry {
+	return &LimiterRegistry{
+		limiters: make(map[string]*AdaptiveLimiter),
+		cfg:      cfg,
+		metrics:  metrics,
+	}
+}
+
+// Get returns the limiter for modelName, creating one if necessary.
+func (r *LimiterRegistry) Get(modelName string) *AdaptiveLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if l, ok := r.limiters[modelName]; ok {
+		return l
+	}
+
+	l := newAdaptiveLimiter(modelName, r.cfg.AdaptiveLimitInitial, r.cfg.AdaptiveLimitMin, r.cfg.AdaptiveLimitMax, r.metrics)
+	r.limiters[modelName] = l
+	return l
+}
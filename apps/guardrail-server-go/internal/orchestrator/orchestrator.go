@@ -11,6 +11,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -22,24 +23,15 @@ import (
 	"github.com/google/uuid"
 	gocommon "github.com/playground/packages/go-common"
 
+	"github.com/playground/apps/guardrail-server-go/internal/cache"
 	"github.com/playground/apps/guardrail-server-go/internal/circuitbreaker"
 	"github.com/playground/apps/guardrail-server-go/internal/client"
 	"github.com/playground/apps/guardrail-server-go/internal/config"
 )
 
-// AggregationStrategy defines how to aggregate model results.
-type AggregationStrategy int
-
-const (
-	// StrategyAnyFlag flags if ANY model flags
-	StrategyAnyFlag AggregationStrategy = iota
-	// StrategyAllFlag flags only if ALL models flag
-	StrategyAllFlag
-	// StrategyMajority flags if majority (>50%) flag
-	StrategyMajority
-	// StrategyThreshold flags if weighted score exceeds threshold
-	StrategyThreshold
-)
+// DefaultStrategy is the aggregation strategy used when ValidateText is
+// called with an empty or unrecognized strategy name.
+const DefaultStrategy = "any_flag"
 
 // ModelCallResult holds the result from a single model call.
 type ModelCallResult struct {
@@ -51,26 +43,47 @@ type ModelCallResult struct {
 
 // Orchestrator coordinates model calls and aggregates results.
 type Orchestrator struct {
-	cfg        *config.Config
-	clients    *client.Pool
-	breakers   *circuitbreaker.Registry
-	metrics    *gocommon.Metrics
-	inFlight   int64
-	inFlightMu sync.Mutex
+	cfg         *config.Config
+	clients     *client.Pool
+	breakers    *circuitbreaker.Registry
+	metrics     *gocommon.Metrics
+	aggregators *Registry
+	limiters    *LimiterRegistry
+	resultCache *cache.ResultCache
+	inFlight    int64
+	inFlightMu  sync.Mutex
 }
 
-// New creates a new orchestrator.
+// New creates a new orchestrator. If cfg.CacheEnabled but Redis isn't
+// reachable, the orchestrator logs the error and runs without a result
+// cache rather than failing startup.
 func New(cfg *config.Config, clients *client.Pool, breakers *circuitbreaker.Registry, metrics *gocommon.Metrics) *Orchestrator {
+	var resultCache *cache.ResultCache
+	if cfg.CacheEnabled {
+		rc, err := cache.New(cfg)
+		if err != nil {
+			log.Printf("result cache disabled: %v", err)
+		} else {
+			resultCache = rc
+		}
+	}
+
 	return &Orchestrator{
-		cfg:      cfg,
-		clients:  clients,
-		breakers: breakers,
-		metrics:  metrics,
+		cfg:         cfg,
+		clients:     clients,
+		breakers:    breakers,
+		metrics:     metrics,
+		aggregators: NewAggregatorRegistry(cfg),
+		limiters:    NewLimiterRegistry(cfg, metrics),
+		resultCache: resultCache,
 	}
 }
 
-// ValidateText validates text against all enabled models.
-func (o *Orchestrator) ValidateText(ctx context.Context, text string, enabledModels []string, strategy AggregationStrategy, requestID string) (*gocommon.ValidateResponse, error) {
+// ValidateText validates text against all enabled models. strategy names
+// one of the Aggregator implementations registered in the orchestrator's
+// Registry (e.g. "any_flag", "weighted_threshold", "log_odds", "veto");
+// an empty or unrecognized name falls back to DefaultStrategy.
+func (o *Orchestrator) ValidateText(ctx context.Context, text string, enabledModels []string, strategy string, requestID string) (*gocommon.ValidateResponse, error) {
 	startTime := time.Now()
 
 	// Generate request ID if not provided
@@ -100,10 +113,74 @@ func (o *Orchestrator) ValidateText(ctx context.Context, text string, enabledMod
 	// Call all models in parallel
 	results := o.callModelsParallel(ctx, text, requestID, enabledModels)
 
-	// Process results
+	return o.finalize(startTime, requestID, strategy, results), nil
+}
+
+// ValidateTextStream is the streaming counterpart to ValidateText: it
+// fans out to the same enabled models, but emits each ModelCallResult on
+// the first returned channel as soon as that model replies, rather than
+// waiting for every model before returning anything. The aggregated
+// ValidateResponse is sent on the second channel once every model has
+// responded (or ctx is cancelled), and both channels are then closed.
+//
+// This lets an SSE handler render per-model progress in real time, and
+// lets callers cancel ctx the moment a critical model flags (e.g. under
+// the "any_flag" or "veto" strategies) without waiting on slower models.
+func (o *Orchestrator) ValidateTextStream(ctx context.Context, text string, enabledModels []string, strategy string, requestID string) (<-chan ModelCallResult, <-chan *gocommon.ValidateResponse) {
+	startTime := time.Now()
+
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	if len(enabledModels) == 0 {
+		enabledModels = []string{"prompt-guard", "pii-detect", "hate-detect", "content-class"}
+	}
+
+	o.inFlightMu.Lock()
+	o.inFlight++
+	o.inFlightMu.Unlock()
+	hostname, _ := os.Hostname()
+	o.metrics.InFlightRequests.WithLabelValues(hostname).Inc()
+
+	resultsCh := make(chan ModelCallResult, len(enabledModels))
+	finalCh := make(chan *gocommon.ValidateResponse, 1)
+
+	go func() {
+		defer close(resultsCh)
+		defer close(finalCh)
+		defer func() {
+			o.inFlightMu.Lock()
+			o.inFlight--
+			o.inFlightMu.Unlock()
+			o.metrics.InFlightRequests.WithLabelValues(hostname).Dec()
+		}()
+
+		results := make([]ModelCallResult, len(enabledModels))
+		var wg sync.WaitGroup
+
+		for i, model := range enabledModels {
+			wg.Add(1)
+			go func(idx int, modelName string) {
+				defer wg.Done()
+				result := o.callModelCached(ctx, modelName, text, requestID)
+				results[idx] = result
+				resultsCh <- result
+			}(i, model)
+		}
+		wg.Wait()
+
+		finalCh <- o.finalize(startTime, requestID, strategy, results)
+	}()
+
+	return resultsCh, finalCh
+}
+
+// finalize aggregates a batch of ModelCallResults into a ValidateResponse
+// and records the request-level metrics, shared by ValidateText and
+// ValidateTextStream.
+func (o *Orchestrator) finalize(startTime time.Time, requestID, strategy string, results []ModelCallResult) *gocommon.ValidateResponse {
 	modelResults := make(map[string]gocommon.ModelResultResponse)
 	var failedModels []string
-	var flagReasons []string
 
 	for _, result := range results {
 		if result.Success && result.Response != nil {
@@ -111,19 +188,20 @@ func (o *Orchestrator) ValidateText(ctx context.Context, text string, enabledMod
 				Flagged:   result.Response.Flagged,
 				Score:     result.Response.Score,
 				Details:   result.Response.Details,
+				Entities:  result.Response.Entities,
 				LatencyMs: result.Response.LatencyMs,
 			}
-
-			if result.Response.Flagged {
-				flagReasons = append(flagReasons, result.ModelName+"_flagged")
-			}
 		} else {
 			failedModels = append(failedModels, result.ModelName)
 		}
 	}
 
 	// Aggregate results
-	flagged := o.aggregateResults(modelResults, strategy)
+	agg, ok := o.aggregators.Get(strategy)
+	if !ok {
+		agg, _ = o.aggregators.Get(DefaultStrategy)
+	}
+	flagged, score, flagReasons := agg.Aggregate(modelResults)
 
 	// Calculate latency
 	latencyMs := int(time.Since(startTime).Milliseconds())
@@ -139,12 +217,13 @@ func (o *Orchestrator) ValidateText(ctx context.Context, text string, enabledMod
 	return &gocommon.ValidateResponse{
 		RequestID:      requestID,
 		Flagged:        flagged,
+		Score:          score,
 		FlagReasons:    flagReasons,
 		ModelResults:   modelResults,
 		PartialFailure: len(failedModels) > 0,
 		FailedModels:   failedModels,
 		LatencyMs:      latencyMs,
-	}, nil
+	}
 }
 
 // callModelsParallel calls all models in parallel using goroutines.
@@ -156,7 +235,7 @@ func (o *Orchestrator) callModelsParallel(ctx context.Context, text, requestID s
 		wg.Add(1)
 		go func(idx int, modelName string) {
 			defer wg.Done()
-			results[idx] = o.callModel(ctx, modelName, text, requestID)
+			results[idx] = o.callModelCached(ctx, modelName, text, requestID)
 		}(i, model)
 	}
 
@@ -164,12 +243,44 @@ func (o *Orchestrator) callModelsParallel(ctx context.Context, text, requestID s
 	return results
 }
 
-// callModel calls a single model with circuit breaker and retry protection.
-func (o *Orchestrator) callModel(ctx context.Context, modelName, text, requestID string) ModelCallResult {
-	cb := o.breakers.Get(modelName)
+// callModelCached wraps callModel with the optional result cache: a hit
+// is returned directly without calling the model, and a miss is stored
+// (positively on success, negatively on failure) after calling through.
+// With caching disabled it's just callModel.
+func (o *Orchestrator) callModelCached(ctx context.Context, modelName, text, requestID string) ModelCallResult {
+	if o.resultCache == nil {
+		return o.callModel(ctx, modelName, text, requestID)
+	}
+
+	if cached, ok := o.resultCache.Get(ctx, modelName, text); ok {
+		o.metrics.CacheHits.WithLabelValues(modelName).Inc()
+		return ModelCallResult{
+			ModelName: modelName,
+			Success:   cached.Success,
+			Response:  cached.Response,
+			Error:     cached.Error,
+		}
+	}
+	o.metrics.CacheMisses.WithLabelValues(modelName).Inc()
+
+	result := o.callModel(ctx, modelName, text, requestID)
+	if result.Success {
+		o.resultCache.Set(ctx, modelName, text, result.Response)
+	} else {
+		o.resultCache.SetNegative(ctx, modelName, text, result.Error)
+	}
+	return result
+}
 
-	// Check circuit breaker
-	if !cb.AllowRequest() {
+// callModel calls a single model with circuit breaker, adaptive
+// concurrency, hedging, and retry protection.
+func (o *Orchestrator) callModel(ctx context.Context, modelName, text, requestID string) ModelCallResult {
+	// Cheap precheck: skip even acquiring a limiter slot when the breaker
+	// is already open. This only peeks at the state - o.clients.Do makes
+	// the authoritative AllowRequest check (and records the outcome) for
+	// every attempt below, including the moment a breaker flips open
+	// mid-retry.
+	if o.breakers.Get(modelName).State() == circuitbreaker.StateOpen {
 		return ModelCallResult{
 			ModelName: modelName,
 			Success:   false,
@@ -177,6 +288,16 @@ func (o *Orchestrator) callModel(ctx context.Context, modelName, text, requestID
 		}
 	}
 
+	limiter := o.limiters.Get(modelName)
+	if err := limiter.Acquire(ctx); err != nil {
+		return ModelCallResult{
+			ModelName: modelName,
+			Success:   false,
+			Error:     fmt.Sprintf("concurrency limiter: %v", err),
+		}
+	}
+	defer limiter.Release()
+
 	// Retry loop
 	var lastErr error
 	for attempt := 1; attempt <= o.cfg.RetryMaxAttempts; attempt++ {
@@ -189,22 +310,23 @@ func (o *Orchestrator) callModel(ctx context.Context, modelName, text, requestID
 			time.Sleep(time.Duration(o.cfg.RetryWaitMs) * time.Millisecond)
 		}
 
-		result, err := o.doModelCall(ctx, modelName, text, requestID)
+		start := time.Now()
+		result, err := o.doModelCallHedged(ctx, modelName, text, requestID, limiter)
+		limiter.Observe(time.Since(start), err != nil)
 		if err == nil {
-			cb.RecordSuccess()
 			return result
 		}
 
 		lastErr = err
 
-		// Don't retry for certain errors
-		if !o.cfg.RetryEnabled {
+		// Don't retry once the breaker has rejected an attempt outright,
+		// or for certain errors.
+		if errors.Is(err, client.ErrCircuitOpen) || !o.cfg.RetryEnabled {
 			break
 		}
 	}
 
 	// All retries failed
-	cb.RecordFailure()
 	return ModelCallResult{
 		ModelName: modelName,
 		Success:   false,
@@ -212,11 +334,68 @@ func (o *Orchestrator) callModel(ctx context.Context, modelName, text, requestID
 	}
 }
 
-// doModelCall performs the actual HTTP call to a model.
-func (o *Orchestrator) doModelCall(ctx context.Context, modelName, text, requestID string) (ModelCallResult, error) {
+// doModelCallHedged performs a single (retry-loop) attempt at calling
+// modelName, racing a primary call against a delayed hedge to a
+// different replica when hedging is enabled. Whichever attempt completes
+// first wins and the other is cancelled via its context.
+func (o *Orchestrator) doModelCallHedged(ctx context.Context, modelName, text, requestID string, limiter *AdaptiveLimiter) (ModelCallResult, error) {
+	if !o.cfg.HedgeEnabled {
+		return o.doModelCall(ctx, modelName, text, requestID, o.clients.GetBaseURL(modelName))
+	}
+
+	hedgeDelay := limiter.Percentile(0.95)
+	if hedgeDelay <= 0 {
+		hedgeDelay = o.cfg.HedgeMinDelay
+	} else {
+		hedgeDelay = time.Duration(float64(hedgeDelay) * o.cfg.HedgeFactor)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		result ModelCallResult
+		err    error
+		hedge  bool
+	}
+	attempts := make(chan attempt, 2)
+
+	primaryURL := o.clients.GetBaseURL(modelName)
+	go func() {
+		result, err := o.doModelCall(ctx, modelName, text, requestID, primaryURL)
+		attempts <- attempt{result, err, false}
+	}()
+
+	timer := time.NewTimer(hedgeDelay)
+	defer timer.Stop()
+
+	select {
+	case first := <-attempts:
+		return first.result, first.err
+	case <-ctx.Done():
+		return ModelCallResult{}, ctx.Err()
+	case <-timer.C:
+	}
+
+	hedgeURL := o.clients.PickBaseURL(modelName, primaryURL)
+	go func() {
+		result, err := o.doModelCall(ctx, modelName, text, requestID, hedgeURL)
+		attempts <- attempt{result, err, true}
+	}()
+
+	first := <-attempts
+	limiter.RecordHedgeOutcome(first.hedge)
+	// The loser's goroutine is cancelled via ctx but may still be writing
+	// to attempts; drain it in the background so it doesn't leak.
+	go func() { <-attempts }()
+
+	return first.result, first.err
+}
+
+// doModelCall performs the actual HTTP call to a model at baseURL.
+func (o *Orchestrator) doModelCall(ctx context.Context, modelName, text, requestID, baseURL string) (ModelCallResult, error) {
 	startTime := time.Now()
 
-	baseURL := o.clients.GetBaseURL(modelName)
 	if baseURL == "" {
 		return ModelCallResult{}, fmt.Errorf("unknown model: %s", modelName)
 	}
@@ -238,9 +417,9 @@ func (o *Orchestrator) doModelCall(ctx context.Context, modelName, text, request
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// Make request
-	client := o.clients.Get(modelName)
-	resp, err := client.Do(req)
+	// Make request, guarded by modelName's circuit breaker and in-flight
+	// semaphore.
+	resp, err := o.clients.Do(ctx, modelName, req)
 	if err != nil {
 		return ModelCallResult{}, fmt.Errorf("request error: %w", err)
 	}
@@ -273,55 +452,6 @@ func (o *Orchestrator) doModelCall(ctx context.Context, modelName, text, request
 	}, nil
 }
 
-// aggregateResults aggregates model results based on strategy.
-func (o *Orchestrator) aggregateResults(results map[string]gocommon.ModelResultResponse, strategy AggregationStrategy) bool {
-	if len(results) == 0 {
-		return false
-	}
-
-	flags := make([]bool, 0, len(results))
-	var totalScore float64
-
-	for _, r := range results {
-		flags = append(flags, r.Flagged)
-		totalScore += r.Score
-	}
-
-	switch strategy {
-	case StrategyAnyFlag:
-		for _, f := range flags {
-			if f {
-				return true
-			}
-		}
-		return false
-
-	case StrategyAllFlag:
-		for _, f := range flags {
-			if !f {
-				return false
-			}
-		}
-		return true
-
-	case StrategyMajority:
-		count := 0
-		for _, f := range flags {
-			if f {
-				count++
-			}
-		}
-		return float64(count) > float64(len(flags))/2
-
-	case StrategyThreshold:
-		avgScore := totalScore / float64(len(results))
-		return avgScore > 0.5
-
-	default:
-		return false
-	}
-}
-
 // GetInFlight returns the current number of in-flight requests.
 func (o *Orchestrator) GetInFlight() int64 {
 	o.inFlightMu.Lock()
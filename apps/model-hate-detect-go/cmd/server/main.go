@@ -12,6 +12,7 @@ import (
 
 	"github.com/playground/apps/model-hate-detect-go/internal/api"
 	"github.com/playground/apps/model-hate-detect-go/internal/config"
+	"github.com/playground/apps/model-hate-detect-go/internal/inference"
 	gocommon "github.com/playground/packages/go-common"
 )
 
@@ -23,15 +24,17 @@ func main() {
 	cfg := config.Load()
 	log.Printf("[%s] Starting on %s:%d", modelName, cfg.Host, cfg.Port)
 
+	classifier := loadClassifier(cfg)
+
 	metrics := gocommon.NewModelMetrics(modelName)
-	handler := api.NewHandler(cfg, metrics, &shuttingDown)
+	handler := api.NewHandler(cfg, metrics, &shuttingDown, classifier)
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
 	mux.Handle("GET /metrics", gocommon.MetricsHandler())
 
 	addr := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
-	server := &http.Server{Addr: addr, Handler: metrics.MetricsMiddleware(mux), ReadTimeout: 10 * time.Second, WriteTimeout: 10 * time.Second}
+	server := &http.Server{Addr: addr, Handler: metrics.MetricsMiddleware(gocommon.RequestIDMiddleware(mux)), ReadTimeout: 10 * time.Second, WriteTimeout: 10 * time.Second}
 
 	go func() {
 		log.Printf("[%s] Listening on %s", modelName, addr)
@@ -50,3 +53,33 @@ func main() {
 	server.Shutdown(ctx)
 	log.Printf("[%s] Shutdown complete", modelName)
 }
+
+// loadClassifier picks the configured backend, falling back to the keyword
+// matcher if the ONNX model/tokenizer can't be loaded.
+func loadClassifier(cfg *config.Config) inference.Classifier {
+	if cfg.InferenceBackend != "onnx" {
+		return inference.NewKeywordClassifier()
+	}
+
+	onnx, err := inference.NewONNXClassifier(inference.ONNXConfig{
+		ModelPath:     cfg.ONNXModelPath,
+		TokenizerPath: cfg.ONNXTokenizerPath,
+		ModelVersion:  cfg.ONNXModelVersion,
+		Workers:       cfg.InferenceWorkers,
+	})
+	if err != nil {
+		log.Printf("[%s] failed to load ONNX classifier, falling back to keyword matcher: %v", modelName, err)
+		return inference.NewKeywordClassifier()
+	}
+
+	warmupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := onnx.Warmup(warmupCtx); err != nil {
+		log.Printf("[%s] ONNX warmup failed, falling back to keyword matcher: %v", modelName, err)
+		onnx.Close()
+		return inference.NewKeywordClassifier()
+	}
+
+	log.Printf("[%s] Loaded ONNX classifier model=%s workers=%d", modelName, cfg.ONNXModelVersion, cfg.InferenceWorkers)
+	return onnx
+}
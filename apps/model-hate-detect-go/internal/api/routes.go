@@ -2,7 +2,7 @@ package api
 
 import (
 	"encoding/json"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	"time"
@@ -18,11 +18,11 @@ type Handler struct {
 	cfg          *config.Config
 	metrics      *gocommon.Metrics
 	shuttingDown *bool
-	semaphore    chan struct{}
+	classifier   inference.Classifier
 }
 
-func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, shuttingDown *bool) *Handler {
-	return &Handler{cfg: cfg, metrics: metrics, shuttingDown: shuttingDown, semaphore: make(chan struct{}, 1)}
+func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, shuttingDown *bool, classifier inference.Classifier) *Handler {
+	return &Handler{cfg: cfg, metrics: metrics, shuttingDown: shuttingDown, classifier: classifier}
 }
 
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
@@ -33,28 +33,46 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 
 func (h *Handler) handlePredict(w http.ResponseWriter, r *http.Request) {
 	startTime := time.Now()
+	requestID := gocommon.RequestIDFromContext(r.Context())
+
 	var req gocommon.ModelPredictRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request"})
 		return
 	}
-
-	h.semaphore <- struct{}{}
-	defer func() { <-h.semaphore }()
+	if req.RequestID == "" {
+		req.RequestID = requestID
+	}
 
 	if h.cfg.InferenceDelayEnabled {
 		delayMs := h.cfg.InferenceDelayMinMs + rand.Intn(h.cfg.InferenceDelayMaxMs-h.cfg.InferenceDelayMinMs+1)
 		time.Sleep(time.Duration(delayMs) * time.Millisecond)
-		log.Printf("[%s] Simulated delay: %dms for request %s", modelName, delayMs, req.RequestID)
+		slog.Info("simulated inference delay", "request_id", req.RequestID, "model", modelName, "delay_ms", delayMs)
 	}
 
-	flagged, score, details := inference.DetectHateSpeech(req.Text)
+	backend := h.classifier.Backend()
+	version := h.classifier.Version()
+
+	flagged, score, details, err := h.classifier.Predict(r.Context(), req.Text)
 	latencyMs := int(time.Since(startTime).Milliseconds())
 
-	h.metrics.InferenceLatency.WithLabelValues(modelName).Observe(float64(latencyMs) / 1000.0)
-	h.metrics.InferenceTotal.WithLabelValues(modelName, "success").Inc()
+	h.metrics.InferenceLatency.WithLabelValues(modelName, backend, version).Observe(float64(latencyMs) / 1000.0)
+	if err != nil {
+		h.metrics.InferenceTotal.WithLabelValues(modelName, backend, version, "error").Inc()
+		slog.Error("predict failed", "request_id", req.RequestID, "model", modelName, "backend", backend, "model_version", version, "latency_ms", latencyMs, "error", err)
+		h.writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "inference failed"})
+		return
+	}
+	h.metrics.InferenceTotal.WithLabelValues(modelName, backend, version, "success").Inc()
+	slog.Info("predict completed", "request_id", req.RequestID, "model", modelName, "latency_ms", latencyMs, "flagged", flagged, "score", score)
 
-	h.writeJSON(w, http.StatusOK, gocommon.ModelPredictResponse{Flagged: flagged, Score: score, Details: details, LatencyMs: latencyMs})
+	h.writeJSON(w, http.StatusOK, gocommon.ModelPredictResponse{
+		RequestID: req.RequestID,
+		Flagged:   flagged,
+		Score:     score,
+		Details:   details,
+		LatencyMs: latencyMs,
+	})
 }
 
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
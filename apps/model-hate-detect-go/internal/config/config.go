@@ -0,0 +1,70 @@
+// Package config handles configuration for the model service.
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// Config holds all configuration for the model service.
+type Config struct {
+	Host string
+	Port int
+
+	InferenceDelayEnabled bool
+	InferenceDelayMinMs   int
+	InferenceDelayMaxMs   int
+
+	// InferenceBackend selects the classifier implementation: "keyword" or "onnx".
+	InferenceBackend string
+	// ONNXModelPath points at the exported DistilBERT/ToxicBERT-class model.
+	ONNXModelPath string
+	// ONNXTokenizerPath points at the wordpiece tokenizer.json for the model.
+	ONNXTokenizerPath string
+	// ONNXModelVersion is reported on metrics and in logs.
+	ONNXModelVersion string
+	// InferenceWorkers bounds the number of concurrent ONNX inference sessions.
+	InferenceWorkers int
+}
+
+func Load() *Config {
+	return &Config{
+		Host: getEnv("HOST", "0.0.0.0"),
+		Port: getEnvInt("PORT", 8000),
+
+		InferenceDelayEnabled: getEnvBool("INFERENCE_DELAY_ENABLED", true),
+		InferenceDelayMinMs:   getEnvInt("INFERENCE_DELAY_MIN_MS", 10),
+		InferenceDelayMaxMs:   getEnvInt("INFERENCE_DELAY_MAX_MS", 30),
+
+		InferenceBackend:  getEnv("INFERENCE_BACKEND", "keyword"),
+		ONNXModelPath:     getEnv("ONNX_MODEL_PATH", "/models/toxic-bert.onnx"),
+		ONNXTokenizerPath: getEnv("ONNX_TOKENIZER_PATH", "/models/tokenizer.json"),
+		ONNXModelVersion:  getEnv("ONNX_MODEL_VERSION", "toxic-bert-v1"),
+		InferenceWorkers:  getEnvInt("INFERENCE_WORKERS", 4),
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,118 @@
+package inference
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// wordpieceTokenizer is a minimal HuggingFace tokenizer.json-compatible
+// wordpiece tokenizer: enough to turn raw text into input_ids for a
+// DistilBERT/ToxicBERT-class model.
+type wordpieceTokenizer struct {
+	vocab    map[string]int64
+	unkToken string
+	clsToken string
+	sepToken string
+	maxLen   int
+}
+
+type tokenizerFile struct {
+	Model struct {
+		Vocab       map[string]int64 `json:"vocab"`
+		UnkToken    string            `json:"unk_token"`
+		ContinuePfx string            `json:"continuing_subword_prefix"`
+	} `json:"model"`
+}
+
+// loadWordpieceTokenizer parses a HuggingFace-style tokenizer.json.
+func loadWordpieceTokenizer(path string) (*wordpieceTokenizer, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tf tokenizerFile
+	if err := json.Unmarshal(raw, &tf); err != nil {
+		return nil, err
+	}
+
+	unk := tf.Model.UnkToken
+	if unk == "" {
+		unk = "[UNK]"
+	}
+
+	return &wordpieceTokenizer{
+		vocab:    tf.Model.Vocab,
+		unkToken: unk,
+		clsToken: "[CLS]",
+		sepToken: "[SEP]",
+		maxLen:   128,
+	}, nil
+}
+
+// Encode performs basic whitespace + greedy-longest-match wordpiece
+// tokenization and returns input_ids, padded/truncated to maxLen.
+func (t *wordpieceTokenizer) Encode(text string) []int64 {
+	ids := make([]int64, 0, t.maxLen)
+	ids = append(ids, t.idOf(t.clsToken))
+
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		for _, piece := range t.wordpieces(word) {
+			if len(ids) >= t.maxLen-1 {
+				break
+			}
+			ids = append(ids, t.idOf(piece))
+		}
+	}
+
+	ids = append(ids, t.idOf(t.sepToken))
+
+	for len(ids) < t.maxLen {
+		ids = append(ids, 0) // [PAD]
+	}
+	if len(ids) > t.maxLen {
+		ids = ids[:t.maxLen]
+	}
+
+	return ids
+}
+
+// wordpieces greedily splits a single word into known subword units,
+// falling back to the unknown token when no prefix matches.
+func (t *wordpieceTokenizer) wordpieces(word string) []string {
+	if _, ok := t.vocab[word]; ok {
+		return []string{word}
+	}
+
+	var pieces []string
+	start := 0
+	for start < len(word) {
+		end := len(word)
+		found := ""
+		for end > start {
+			candidate := word[start:end]
+			if start > 0 {
+				candidate = "##" + candidate
+			}
+			if _, ok := t.vocab[candidate]; ok {
+				found = candidate
+				break
+			}
+			end--
+		}
+		if found == "" {
+			return []string{t.unkToken}
+		}
+		pieces = append(pieces, found)
+		start = end
+	}
+	return pieces
+}
+
+func (t *wordpieceTokenizer) idOf(token string) int64 {
+	if id, ok := t.vocab[token]; ok {
+		return id
+	}
+	return t.vocab[t.unkToken]
+}
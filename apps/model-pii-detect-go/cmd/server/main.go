@@ -12,6 +12,7 @@ import (
 	"time"
 
 	gocommon "github.com/playground/packages/go-common"
+	"github.com/playground/packages/go-common/lifecycle"
 
 	"github.com/playground/apps/model-pii-detect-go/internal/api"
 	"github.com/playground/apps/model-pii-detect-go/internal/config"
@@ -19,8 +20,6 @@ import (
 
 const modelName = "pii-detect"
 
-var shuttingDown = false
-
 func main() {
 	cfg := config.Load()
 
@@ -30,7 +29,8 @@ func main() {
 	}
 
 	metrics := gocommon.NewModelMetrics(modelName)
-	handler := api.NewHandler(cfg, metrics, &shuttingDown)
+	lc := lifecycle.NewCoordinator()
+	handler := api.NewHandler(cfg, metrics, lc)
 
 	mux := http.NewServeMux()
 	handler.RegisterRoutes(mux)
@@ -58,11 +58,16 @@ func main() {
 	<-quit
 
 	log.Printf("[%s] Shutdown initiated", modelName)
-	shuttingDown = true
+
+	lc.MarkDraining()
+	time.Sleep(cfg.PreStopDelay)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	server.Shutdown(ctx)
+	for _, err := range lc.Wait(ctx) {
+		log.Printf("[%s] Shutdown closer error: %v", modelName, err)
+	}
 	log.Printf("[%s] Shutdown complete", modelName)
 }
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	gocommon "github.com/playground/packages/go-common"
+	"github.com/playground/packages/go-common/lifecycle"
 
 	"github.com/playground/apps/model-pii-detect-go/internal/config"
 	"github.com/playground/apps/model-pii-detect-go/internal/inference"
@@ -17,18 +18,18 @@ import (
 const modelName = "pii-detect"
 
 type Handler struct {
-	cfg          *config.Config
-	metrics      *gocommon.Metrics
-	shuttingDown *bool
-	semaphore    chan struct{}
+	cfg       *config.Config
+	metrics   *gocommon.Metrics
+	lifecycle *lifecycle.Coordinator
+	semaphore chan struct{}
 }
 
-func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, shuttingDown *bool) *Handler {
+func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, lc *lifecycle.Coordinator) *Handler {
 	return &Handler{
-		cfg:          cfg,
-		metrics:      metrics,
-		shuttingDown: shuttingDown,
-		semaphore:    make(chan struct{}, 1),
+		cfg:       cfg,
+		metrics:   metrics,
+		lifecycle: lc,
+		semaphore: make(chan struct{}, 1),
 	}
 }
 
@@ -59,27 +60,42 @@ func (h *Handler) handlePredict(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[%s] Simulated delay: %dms for request %s", modelName, delayMs, req.RequestID)
 	}
 
-	flagged, score, details := inference.DetectPII(req.Text)
+	flagged, score, details, entities := inference.DetectPII(req.Text)
 	latencyMs := int(time.Since(startTime).Milliseconds())
 
-	h.metrics.InferenceLatency.WithLabelValues(modelName).Observe(float64(latencyMs) / 1000.0)
-	h.metrics.InferenceTotal.WithLabelValues(modelName, "success").Inc()
+	h.metrics.InferenceLatency.WithLabelValues(modelName, "keyword", "keyword-v1").Observe(float64(latencyMs) / 1000.0)
+	h.metrics.InferenceTotal.WithLabelValues(modelName, "keyword", "keyword-v1", "success").Inc()
 
 	h.writeJSON(w, http.StatusOK, gocommon.ModelPredictResponse{
 		Flagged:   flagged,
 		Score:     score,
 		Details:   details,
+		Entities:  toGocommonEntities(entities),
 		LatencyMs: latencyMs,
 	})
 }
 
+// toGocommonEntities converts inference.Entity values to the shared
+// gocommon.Entity wire type.
+func toGocommonEntities(entities []inference.Entity) []gocommon.Entity {
+	if len(entities) == 0 {
+		return nil
+	}
+
+	out := make([]gocommon.Entity, len(entities))
+	for i, e := range entities {
+		out[i] = gocommon.Entity{Type: e.Type, Value: e.Value, Start: e.Start, End: e.End}
+	}
+	return out
+}
+
 func (h *Handler) handleHealth(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, gocommon.HealthResponse{Status: "healthy", Model: modelName})
 }
 
 func (h *Handler) handleReady(w http.ResponseWriter, r *http.Request) {
-	if h.shuttingDown != nil && *h.shuttingDown {
-		h.writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "draining", "model": modelName})
+	if h.lifecycle != nil && h.lifecycle.IsDraining() {
+		h.writeJSON(w, http.StatusServiceUnavailable, gocommon.ReadyResponse{Status: "draining", Model: modelName})
 		return
 	}
 	h.writeJSON(w, http.StatusOK, gocommon.ReadyResponse{Status: "ready", Model: modelName})
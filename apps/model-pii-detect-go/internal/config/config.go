@@ -4,6 +4,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds all configuration for the model service.
@@ -14,6 +15,10 @@ type Config struct {
 	InferenceDelayEnabled bool
 	InferenceDelayMinMs   int
 	InferenceDelayMaxMs   int
+
+	// PreStopDelay is how long the server waits, after readiness flips to
+	// "draining" on shutdown, before it stops accepting connections.
+	PreStopDelay time.Duration
 }
 
 func Load() *Config {
@@ -24,6 +29,8 @@ func Load() *Config {
 		InferenceDelayEnabled: getEnvBool("INFERENCE_DELAY_ENABLED", true),
 		InferenceDelayMinMs:   getEnvInt("INFERENCE_DELAY_MIN_MS", 10),
 		InferenceDelayMaxMs:   getEnvInt("INFERENCE_DELAY_MAX_MS", 30),
+
+		PreStopDelay: getEnvDuration("PRE_STOP_DELAY_SECONDS", 2*time.Second),
 	}
 }
 
@@ -51,3 +58,12 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(floatVal * float64(time.Second))
+		}
+	}
+	return defaultValue
+}
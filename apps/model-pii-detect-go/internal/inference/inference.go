@@ -25,37 +25,59 @@ var piiKeywords = []string{
 	"dob",
 }
 
-// DetectPII performs keyword-based PII detection.
-func DetectPII(text string) (bool, float64, []string) {
+// Entity is a structured span of detected PII within the input text, for
+// callers that want more than the flat detail strings (e.g. to redact or
+// highlight the exact match).
+type Entity struct {
+	Type  string
+	Value string
+	Start int
+	End   int
+}
+
+// DetectPII performs keyword- and pattern-based PII detection, returning
+// both flat detail strings (for backward-compatible logging/metrics) and
+// structured entities for each pattern match. Credit card matches are
+// additionally validated against the Luhn checksum so that e.g. a
+// 16-digit order or tracking number isn't flagged as a card number.
+func DetectPII(text string) (bool, float64, []string, []Entity) {
 	lowerText := strings.ToLower(text)
-	var detections []string
+	var details []string
+	var entities []Entity
 
-	// Check patterns
-	if emailPattern.MatchString(text) {
-		detections = append(detections, "email_detected")
+	for _, m := range emailPattern.FindAllStringIndex(text, -1) {
+		details = append(details, "email_detected")
+		entities = append(entities, Entity{Type: "email", Value: text[m[0]:m[1]], Start: m[0], End: m[1]})
 	}
-	if phonePattern.MatchString(text) {
-		detections = append(detections, "phone_detected")
+	for _, m := range phonePattern.FindAllStringIndex(text, -1) {
+		details = append(details, "phone_detected")
+		entities = append(entities, Entity{Type: "phone", Value: text[m[0]:m[1]], Start: m[0], End: m[1]})
 	}
-	if ssnPattern.MatchString(text) {
-		detections = append(detections, "ssn_pattern_detected")
+	for _, m := range ssnPattern.FindAllStringIndex(text, -1) {
+		details = append(details, "ssn_pattern_detected")
+		entities = append(entities, Entity{Type: "ssn", Value: text[m[0]:m[1]], Start: m[0], End: m[1]})
 	}
-	if ccPattern.MatchString(text) {
-		detections = append(detections, "credit_card_detected")
+	for _, m := range ccPattern.FindAllStringIndex(text, -1) {
+		value := text[m[0]:m[1]]
+		if !isValidLuhn(value) {
+			continue
+		}
+		details = append(details, "credit_card_detected")
+		entities = append(entities, Entity{Type: "credit_card", Value: value, Start: m[0], End: m[1]})
 	}
 
 	// Check keywords
 	for _, keyword := range piiKeywords {
 		if strings.Contains(lowerText, keyword) {
-			detections = append(detections, "keyword:"+keyword)
+			details = append(details, "keyword:"+keyword)
 		}
 	}
 
-	if len(detections) == 0 {
-		return false, 0.0, nil
+	if len(details) == 0 {
+		return false, 0.0, nil, nil
 	}
 
-	score := float64(len(detections)) * 0.2
+	score := float64(len(details)) * 0.2
 	if score > 1.0 {
 		score = 1.0
 	}
@@ -63,5 +85,40 @@ func DetectPII(text string) (bool, float64, []string) {
 		score = 0.5
 	}
 
-	return true, score, detections
+	return true, score, details, entities
+}
+
+// isValidLuhn reports whether value, a run of digits optionally separated
+// by spaces or hyphens, passes the Luhn checksum used to validate credit
+// card numbers.
+func isValidLuhn(value string) bool {
+	var digits []int
+	for _, r := range value {
+		switch {
+		case r == '-' || r == ' ':
+			continue
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		default:
+			return false
+		}
+	}
+	if len(digits) < 12 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := digits[i]
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
 }
@@ -1,10 +1,9 @@
 // Model Prompt Guard - Main Application Entry Point
 //
 // This is the Go HTTP server that provides prompt injection detection.
-// Uses keyword-based detection as a dummy implementation.
-//
-// IMPORTANT: This service processes ONE REQUEST AT A TIME per pod
-// using a semaphore, simulating real ML which is CPU/GPU-bound.
+// Backed by a pluggable inference.Classifier: keyword-based detection by
+// default, or an ONNX Runtime model when INFERENCE_BACKEND=onnx, falling
+// back to keyword-based detection if the model can't load.
 //
 // To run:
 //
@@ -25,6 +24,7 @@ import (
 
 	"github.com/playground/apps/model-prompt-guard-go/internal/api"
 	"github.com/playground/apps/model-prompt-guard-go/internal/config"
+	"github.com/playground/apps/model-prompt-guard-go/internal/inference"
 )
 
 const modelName = "prompt-guard"
@@ -38,16 +38,19 @@ func main() {
 	log.Printf("[%s] Starting with configuration:", modelName)
 	log.Printf("  Host: %s", cfg.Host)
 	log.Printf("  Port: %d", cfg.Port)
+	log.Printf("  Inference Backend: %s", cfg.InferenceBackend)
 	log.Printf("  Inference Delay Enabled: %v", cfg.InferenceDelayEnabled)
 	if cfg.InferenceDelayEnabled {
 		log.Printf("  Inference Delay: %d-%dms", cfg.InferenceDelayMinMs, cfg.InferenceDelayMaxMs)
 	}
 
+	classifier := loadClassifier(cfg)
+
 	// Initialize metrics
 	metrics := gocommon.NewModelMetrics(modelName)
 
 	// Create HTTP handler
-	handler := api.NewHandler(cfg, metrics, &shuttingDown)
+	handler := api.NewHandler(cfg, metrics, &shuttingDown, classifier)
 
 	// Create ServeMux and register routes
 	mux := http.NewServeMux()
@@ -98,3 +101,33 @@ func main() {
 
 	log.Printf("[%s] Shutdown complete", modelName)
 }
+
+// loadClassifier picks the configured backend, falling back to the keyword
+// matcher if the ONNX model/tokenizer can't be loaded.
+func loadClassifier(cfg *config.Config) inference.Classifier {
+	if cfg.InferenceBackend != "onnx" {
+		return inference.NewKeywordClassifier()
+	}
+
+	onnx, err := inference.NewONNXClassifier(inference.ONNXConfig{
+		ModelPath:     cfg.ONNXModelPath,
+		TokenizerPath: cfg.ONNXTokenizerPath,
+		ModelVersion:  cfg.ONNXModelVersion,
+		Workers:       cfg.InferenceWorkers,
+	})
+	if err != nil {
+		log.Printf("[%s] failed to load ONNX classifier, falling back to keyword matcher: %v", modelName, err)
+		return inference.NewKeywordClassifier()
+	}
+
+	warmupCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := onnx.Warmup(warmupCtx); err != nil {
+		log.Printf("[%s] ONNX warmup failed, falling back to keyword matcher: %v", modelName, err)
+		onnx.Close()
+		return inference.NewKeywordClassifier()
+	}
+
+	log.Printf("[%s] Loaded ONNX classifier model=%s workers=%d", modelName, cfg.ONNXModelVersion, cfg.InferenceWorkers)
+	return onnx
+}
@@ -21,19 +21,16 @@ type Handler struct {
 	cfg          *config.Config
 	metrics      *gocommon.Metrics
 	shuttingDown *bool
-
-	// Semaphore for single-request-at-a-time processing
-	// This simulates real ML which can only process one request at a time per pod
-	semaphore chan struct{}
+	classifier   inference.Classifier
 }
 
 // NewHandler creates a new API handler.
-func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, shuttingDown *bool) *Handler {
+func NewHandler(cfg *config.Config, metrics *gocommon.Metrics, shuttingDown *bool, classifier inference.Classifier) *Handler {
 	return &Handler{
 		cfg:          cfg,
 		metrics:      metrics,
 		shuttingDown: shuttingDown,
-		semaphore:    make(chan struct{}, 1), // Capacity 1 = single request at a time
+		classifier:   classifier,
 	}
 }
 
@@ -55,11 +52,6 @@ func (h *Handler) handlePredict(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Acquire semaphore (blocks if another request is processing)
-	// This ensures only one inference runs at a time per pod
-	h.semaphore <- struct{}{}
-	defer func() { <-h.semaphore }()
-
 	// Simulate ML inference delay (blocking)
 	if h.cfg.InferenceDelayEnabled {
 		delayMs := h.cfg.InferenceDelayMinMs
@@ -70,14 +62,24 @@ func (h *Handler) handlePredict(w http.ResponseWriter, r *http.Request) {
 		log.Printf("[%s] Simulated delay: %dms for request %s", modelName, delayMs, req.RequestID)
 	}
 
-	// Run inference
-	flagged, score, details := inference.DetectPromptInjection(req.Text)
+	backend := h.classifier.Backend()
+	version := h.classifier.Version()
+
+	// Run inference, bounded by the classifier's own worker pool rather than
+	// a single-slot semaphore, so concurrent requests use all CPU cores.
+	flagged, score, details, err := h.classifier.Predict(r.Context(), req.Text)
 
 	latencyMs := int(time.Since(startTime).Milliseconds())
 
 	// Record metrics
-	h.metrics.InferenceLatency.WithLabelValues(modelName).Observe(float64(latencyMs) / 1000.0)
-	h.metrics.InferenceTotal.WithLabelValues(modelName, "success").Inc()
+	h.metrics.InferenceLatency.WithLabelValues(modelName, backend, version).Observe(float64(latencyMs) / 1000.0)
+	if err != nil {
+		h.metrics.InferenceTotal.WithLabelValues(modelName, backend, version, "error").Inc()
+		log.Printf("[%s] backend=%s version=%s predict error: %v", modelName, backend, version, err)
+		h.writeError(w, http.StatusInternalServerError, "inference failed")
+		return
+	}
+	h.metrics.InferenceTotal.WithLabelValues(modelName, backend, version, "success").Inc()
 
 	// Send response
 	h.writeJSON(w, http.StatusOK, gocommon.ModelPredictResponse{
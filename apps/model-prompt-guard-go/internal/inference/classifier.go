@@ -0,0 +1,211 @@
+package inference
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// Classifier is implemented by every prompt-injection detection backend.
+type Classifier interface {
+	// Predict scores text and returns whether it was flagged, a confidence
+	// score in [0, 1], and human-readable details explaining the decision.
+	Predict(ctx context.Context, text string) (flagged bool, score float64, details []string, err error)
+	// Backend identifies the implementation for metrics labels (e.g. "keyword", "onnx").
+	Backend() string
+	// Version identifies the model/ruleset version for metrics labels.
+	Version() string
+}
+
+// KeywordClassifier wraps the original substring-matching heuristic so it
+// keeps working as a fallback when the ONNX backend can't be loaded.
+type KeywordClassifier struct{}
+
+// NewKeywordClassifier creates the fallback keyword-based classifier.
+func NewKeywordClassifier() *KeywordClassifier {
+	return &KeywordClassifier{}
+}
+
+func (c *KeywordClassifier) Predict(_ context.Context, text string) (bool, float64, []string, error) {
+	flagged, score, details := DetectPromptInjection(text)
+	return flagged, score, details, nil
+}
+
+func (c *KeywordClassifier) Backend() string { return "keyword" }
+func (c *KeywordClassifier) Version() string { return "keyword-v1" }
+
+// onnxWorker is one inference slot: a session with its own bound
+// input/output tensors. A session isn't safe for concurrent Run calls
+// against shared tensors, but distinct sessions each with their own
+// tensors run fine in parallel, so ONNXClassifier gives every worker its
+// own rather than serializing them behind one.
+type onnxWorker struct {
+	session      *ort.AdvancedSession
+	inputTensor  *ort.Tensor[int64]
+	outputTensor *ort.Tensor[float32]
+}
+
+// ONNXClassifier runs a DistilBERT-class prompt-injection model via
+// onnxruntime_go. It holds a fixed-size pool of onnxWorkers so concurrent
+// requests actually run their inference in parallel on multi-core hosts,
+// rather than just queueing behind a single session.
+type ONNXClassifier struct {
+	tokenizer *wordpieceTokenizer
+	version   string
+
+	workers chan *onnxWorker
+	all     []*onnxWorker
+}
+
+// ONNXConfig configures the ONNX-backed classifier.
+type ONNXConfig struct {
+	ModelPath     string
+	TokenizerPath string
+	ModelVersion  string
+	MaxSeqLen     int
+	Workers       int
+}
+
+// NewONNXClassifier loads the model and tokenizer and prepares a fixed-size
+// pool of cfg.Workers independent sessions (each with its own bound
+// tensors) so concurrent requests actually run their inference in
+// parallel on multi-core hosts.
+func NewONNXClassifier(cfg ONNXConfig) (*ONNXClassifier, error) {
+	if cfg.MaxSeqLen == 0 {
+		cfg.MaxSeqLen = 128
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 1
+	}
+
+	if err := ort.InitializeEnvironment(); err != nil {
+		return nil, fmt.Errorf("initialize onnxruntime: %w", err)
+	}
+
+	tokenizer, err := loadWordpieceTokenizer(cfg.TokenizerPath)
+	if err != nil {
+		return nil, fmt.Errorf("load tokenizer: %w", err)
+	}
+
+	c := &ONNXClassifier{
+		tokenizer: tokenizer,
+		version:   cfg.ModelVersion,
+		workers:   make(chan *onnxWorker, cfg.Workers),
+	}
+	for i := 0; i < cfg.Workers; i++ {
+		w, err := newONNXWorker(cfg)
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		c.all = append(c.all, w)
+		c.workers <- w
+	}
+	return c, nil
+}
+
+// newONNXWorker creates one inference slot: its own bound input/output
+// tensors and the session that runs against them.
+func newONNXWorker(cfg ONNXConfig) (*onnxWorker, error) {
+	inputShape := ort.NewShape(1, int64(cfg.MaxSeqLen))
+	inputTensor, err := ort.NewEmptyTensor[int64](inputShape)
+	if err != nil {
+		return nil, fmt.Errorf("create input tensor: %w", err)
+	}
+
+	outputShape := ort.NewShape(1, 2)
+	outputTensor, err := ort.NewEmptyTensor[float32](outputShape)
+	if err != nil {
+		return nil, fmt.Errorf("create output tensor: %w", err)
+	}
+
+	session, err := ort.NewAdvancedSession(cfg.ModelPath,
+		[]string{"input_ids"}, []string{"logits"},
+		[]ort.ArbitraryTensor{inputTensor}, []ort.ArbitraryTensor{outputTensor}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create onnx session: %w", err)
+	}
+
+	return &onnxWorker{session: session, inputTensor: inputTensor, outputTensor: outputTensor}, nil
+}
+
+// Predict tokenizes text, runs the model, and applies softmax over the logits.
+func (c *ONNXClassifier) Predict(ctx context.Context, text string) (bool, float64, []string, error) {
+	var w *onnxWorker
+	select {
+	case w = <-c.workers:
+	case <-ctx.Done():
+		return false, 0, nil, ctx.Err()
+	}
+	defer func() { c.workers <- w }()
+
+	ids := c.tokenizer.Encode(text)
+
+	inputData := w.inputTensor.GetData()
+	for i := range inputData {
+		if i < len(ids) {
+			inputData[i] = ids[i]
+		} else {
+			inputData[i] = 0
+		}
+	}
+
+	if err := w.session.Run(); err != nil {
+		return false, 0, nil, fmt.Errorf("onnx inference: %w", err)
+	}
+
+	logits := w.outputTensor.GetData()
+	probs := softmax(logits)
+
+	score := float64(probs[1])
+	flagged := score > 0.5
+	details := []string{fmt.Sprintf("onnx model=%s score=%.4f", c.version, score)}
+
+	return flagged, score, details, nil
+}
+
+func (c *ONNXClassifier) Backend() string { return "onnx" }
+func (c *ONNXClassifier) Version() string { return c.version }
+
+// Warmup runs a throwaway inference so the first real request doesn't pay
+// for lazy CUDA/CPU kernel initialization.
+func (c *ONNXClassifier) Warmup(ctx context.Context) error {
+	_, _, _, err := c.Predict(ctx, "warmup")
+	return err
+}
+
+// Close releases every worker's underlying ONNX session.
+func (c *ONNXClassifier) Close() error {
+	var firstErr error
+	for _, w := range c.all {
+		if w.session == nil {
+			continue
+		}
+		if err := w.session.Destroy(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func softmax(logits []float32) []float32 {
+	max := logits[0]
+	for _, v := range logits {
+		if v > max {
+			max = v
+		}
+	}
+
+	sum := float32(0)
+	out := make([]float32, len(logits))
+	for i, v := range logits {
+		out[i] = float32(math.Exp(float64(v - max)))
+		sum += out[i]
+	}
+	for i := range out {
+		out[i] /= sum
+	}
+	return out
+}
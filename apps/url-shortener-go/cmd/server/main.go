@@ -2,19 +2,31 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/acmecache"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/adaptive"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/api"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/limits"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/promquery"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/proxyproto"
 	"github.com/gofiber/fiber/v2"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
 )
 
 func main() {
@@ -40,8 +52,49 @@ func main() {
 	defer redisCache.Close()
 	log.Println("Connected to Redis")
 
+	// Audit every CreateURL/UpdateURL/DeactivateURL call to url_audit.
+	database.Auditor = audit.NewTimescaleAuditor(database.Pool)
+	if cfg.AuditRetentionDays > 0 {
+		retention := time.Duration(cfg.AuditRetentionDays) * 24 * time.Hour
+		if err := audit.InstallRetentionPolicy(context.Background(), database.Pool, retention); err != nil {
+			log.Printf("Failed to install audit retention policy: %v", err)
+		}
+	}
+
+	// Real-time click stream is opt-in since it adds a pg_notify to every
+	// click insert.
+	if cfg.ClickStreamEnabled {
+		if err := db.InstallClickStreamTrigger(context.Background(), database.Pool); err != nil {
+			log.Printf("Failed to install click stream trigger: %v", err)
+		}
+	}
+
+	// Initialize the self-adaptive redirect limiter, backed by this
+	// service's own Prometheus metrics if PROMETHEUS_URL is configured.
+	var promClient *promquery.Client
+	if cfg.PrometheusURL != "" {
+		promClient, err = promquery.New(cfg.PrometheusURL)
+		if err != nil {
+			log.Fatalf("Failed to create Prometheus client: %v", err)
+		}
+		log.Printf("Self-adaptive redirect limiter enabled against %s", cfg.PrometheusURL)
+	}
+	limiter := adaptive.New(promClient, cfg)
+
+	limiterCtx, cancelLimiter := context.WithCancel(context.Background())
+	defer cancelLimiter()
+	go limiter.Start(limiterCtx, cfg.AdaptiveLimiterPollInterval)
+
+	// Initialize per-tenant limit overrides, hot-reloaded on file change
+	// and on SIGHUP.
+	tenantLimits, err := limits.NewOverrides(cfg, cfg.LimitsFilePath)
+	if err != nil {
+		log.Fatalf("Failed to load limits overrides: %v", err)
+	}
+	defer tenantLimits.Close()
+
 	// Initialize handlers and register routes
-	handlers := api.NewHandlers(redisCache, database, cfg)
+	handlers := api.NewHandlers(redisCache, database, cfg, promClient, tenantLimits)
 
 	// Create Fiber app
 	app := fiber.New(fiber.Config{
@@ -65,6 +118,11 @@ func main() {
 		})
 	})
 
+	// Metrics (and, if enabled, pprof) are served on their own listener so
+	// scraping never competes with redirect traffic for a request-handling
+	// goroutine.
+	go metrics.ServeAdmin(cfg.AdminMetricsAddr, cfg.PprofEnabled)
+
 	app.Get("/ready", func(c *fiber.Ctx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
@@ -106,6 +164,17 @@ func main() {
 			}
 		}
 
+		// Approximate analytics is opt-in, so its health is informational
+		// only and never flips the overall readiness verdict.
+		if err := database.CheckApproxAnalyticsHealth(ctx); err != nil {
+			checks["approx_analytics"] = fiber.Map{
+				"status": "disabled",
+				"error":  err.Error(),
+			}
+		} else {
+			checks["approx_analytics"] = fiber.Map{"status": "healthy"}
+		}
+
 		status := "ready"
 		if !ready {
 			status = "not_ready"
@@ -118,17 +187,88 @@ func main() {
 	})
 
 	// Register all API routes (includes Auth0 middleware for /v1/* routes)
-	api.RegisterRoutes(app, handlers, cfg)
+	api.RegisterRoutes(app, handlers, redisCache, cfg, limiter)
+
+	// Custom-domain TLS is opt-in. When enabled, HostPolicy only ever
+	// issues a cert for a hostname that's a verified CustomDomain - an
+	// unrecognized Host is rejected the same way resolveCustomDomain
+	// rejects it in the redirect handler, so this can't be used to make
+	// the server fetch arbitrary certs on an attacker's behalf.
+	var acmeManager *autocert.Manager
+	if cfg.ACMEEnabled {
+		acmeManager = &autocert.Manager{
+			Prompt: autocert.AcceptTOS,
+			Email:  cfg.ACMEEmail,
+			Cache:  acmecache.New(database),
+			HostPolicy: func(ctx context.Context, host string) error {
+				domain, err := database.GetCustomDomainByName(ctx, host)
+				if err != nil || !domain.Verified {
+					return fmt.Errorf("acme: %s is not a verified custom domain", host)
+				}
+				return nil
+			},
+		}
+		if cfg.ACMEDirectoryURL != "" {
+			acmeManager.Client = &acme.Client{DirectoryURL: cfg.ACMEDirectoryURL}
+		}
+
+		if cfg.ACMEHTTPFallbackEnabled {
+			go func() {
+				log.Printf("ACME HTTP-01 fallback listening on %s", cfg.ACMEHTTPFallbackAddr)
+				if err := http.ListenAndServe(cfg.ACMEHTTPFallbackAddr, acmeManager.HTTPHandler(nil)); err != nil {
+					log.Printf("ACME HTTP-01 fallback error: %v", err)
+				}
+			}()
+		}
+	}
 
 	// Start server in goroutine
 	go func() {
 		addr := cfg.Host + ":" + cfg.Port
+
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("Failed to listen on %s: %v", addr, err)
+		}
+
+		// If this service sits behind an L4 load balancer that terminates
+		// TCP (ELB, HAProxy, envoy), it otherwise sees the LB's address as
+		// every client's RemoteAddr, which ruins GeoIP and per-visitor
+		// analytics. PROXY protocol lets the LB hand the real client
+		// address through, but only a trusted LB can be allowed to supply
+		// one - an untrusted client could send a fake header to spoof its
+		// own IP.
+		if cfg.ProxyProtocolEnabled {
+			ln, err = proxyproto.NewListener(ln, cfg.ProxyProtocolTrustedCIDRs)
+			if err != nil {
+				log.Fatalf("Failed to configure PROXY protocol listener: %v", err)
+			}
+			log.Printf("PROXY protocol enabled, trusted CIDRs: %s", cfg.ProxyProtocolTrustedCIDRs)
+		}
+
+		// Wrap in TLS last, once PROXY protocol (if any) has already
+		// unwrapped the raw connection, so autocert sees the real client
+		// handshake rather than a PROXY protocol header.
+		if acmeManager != nil {
+			ln = tls.NewListener(ln, acmeManager.TLSConfig())
+			log.Println("Custom-domain ACME TLS enabled")
+		}
+
 		log.Printf("Starting URL Shortener API on %s", addr)
-		if err := app.Listen(addr); err != nil {
+		if err := app.Listener(ln); err != nil {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
 
+	// SIGHUP reloads the per-tenant limits file without a restart.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			tenantLimits.Reload()
+		}
+	}()
+
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -143,5 +283,10 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Fiber has stopped accepting new requests by this point, so no new
+	// cache warm/invalidate tasks can be submitted - cancel whatever's
+	// still pending rather than let it run past process exit.
+	handlers.URL.Shutdown()
+
 	fmt.Println("Server exiting")
 }
@@ -0,0 +1,44 @@
+// Package acmecache adapts db.DB to autocert.Cache, so every pod behind
+// the same Postgres shares issued certificates and the ACME account key
+// instead of each pod provisioning (and rate-limiting against the CA)
+// independently.
+package acmecache
+
+import (
+	"context"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Cache implements autocert.Cache on top of a db.DB.
+type Cache struct {
+	db *db.DB
+}
+
+// New creates an autocert.Cache backed by database.
+func New(database *db.DB) *Cache {
+	return &Cache{db: database}
+}
+
+// Get implements autocert.Cache.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.db.GetACMECacheValue(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, nil
+}
+
+// Put implements autocert.Cache.
+func (c *Cache) Put(ctx context.Context, key string, data []byte) error {
+	return c.db.PutACMECacheValue(ctx, key, data)
+}
+
+// Delete implements autocert.Cache.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	return c.db.DeleteACMECacheValue(ctx, key)
+}
@@ -0,0 +1,112 @@
+// Package adaptive implements a self-adaptive redirect rate limit: a
+// background loop periodically reads this service's own P95 latency back
+// from Prometheus and tightens or relaxes the redirect rate limit to keep
+// latency under the configured SLO.
+package adaptive
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/promquery"
+)
+
+// latencyQuery is the P95 redirect-handler latency, in seconds, over the
+// trailing 5 minutes.
+const latencyQuery = `histogram_quantile(0.95, sum(rate(http_request_duration_seconds_bucket{route="/:code"}[5m])) by (le))`
+
+// Limiter periodically adjusts a redirect-per-second budget based on the
+// live P95 latency reported by Prometheus. It exposes its current value
+// via Limit, so it can be plugged straight into ratelimit.Config.LimitFunc.
+type Limiter struct {
+	prom *promquery.Client
+
+	min     int
+	max     int
+	sloSecs float64
+
+	current atomic.Int64
+}
+
+// New creates a Limiter that starts at max and adjusts between min and
+// max. prom may be nil, in which case Start is a no-op and Limit always
+// returns max.
+func New(prom *promquery.Client, cfg *config.Config) *Limiter {
+	l := &Limiter{
+		prom:    prom,
+		min:     cfg.RateLimitRedirectMinPerSecond,
+		max:     cfg.RateLimitRedirectPerSecond,
+		sloSecs: cfg.LatencySLOSeconds,
+	}
+	l.current.Store(int64(l.max))
+	return l
+}
+
+// Limit returns the current redirect rate limit.
+func (l *Limiter) Limit() int {
+	return int(l.current.Load())
+}
+
+// Start runs the poll loop until ctx is cancelled. It is a no-op if the
+// Limiter was built without a Prometheus client.
+func (l *Limiter) Start(ctx context.Context, interval time.Duration) {
+	if l.prom == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("Adaptive redirect limiter started - slo=%.3fs range=[%d,%d] interval=%s", l.sloSecs, l.min, l.max, interval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			l.tick(ctx)
+		}
+	}
+}
+
+func (l *Limiter) tick(ctx context.Context) {
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	value, err := l.prom.Instant(queryCtx, latencyQuery)
+	if err != nil {
+		log.Printf("Adaptive limiter: query failed, leaving limit unchanged: %v", err)
+		return
+	}
+
+	p95, ok := promquery.Scalar(value)
+	if !ok {
+		// No data yet (e.g. cold start before any redirects have fired).
+		return
+	}
+
+	current := l.Limit()
+	next := current
+
+	if p95 > l.sloSecs {
+		// Latency is over budget - tighten by half, floor at min.
+		next = current / 2
+		if next < l.min {
+			next = l.min
+		}
+	} else if current < l.max {
+		// Latency has recovered - relax back toward max by 10%.
+		next = current + (l.max-current+9)/10
+		if next > l.max {
+			next = l.max
+		}
+	}
+
+	if next != current {
+		log.Printf("Adaptive limiter: p95=%.3fs slo=%.3fs, redirect limit %d -> %d", p95, l.sloSecs, current, next)
+		l.current.Store(int64(next))
+	}
+}
@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/promquery"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// userPurgePageSize bounds how many of a user's URLs are fetched per page
+// while cascading a "user" scope purge across their cached entries.
+const userPurgePageSize = 100
+
+// AdminHandler handles operator-only maintenance endpoints. Routes backed
+// by this handler must be guarded by auth.RequireAdminScope.
+type AdminHandler struct {
+	cache   *cache.RedisCache
+	db      *db.DB
+	cfg     *config.Config
+	prom    *promquery.Client
+	auditor audit.Auditor
+}
+
+// NewAdminHandler creates a new admin handler. prom may be nil if
+// cfg.PrometheusURL is unset; auditor may be nil if no audit backend is
+// wired up, in which case GetAuditLog reports itself as disabled.
+func NewAdminHandler(redisCache *cache.RedisCache, database *db.DB, cfg *config.Config, prom *promquery.Client, auditor audit.Auditor) *AdminHandler {
+	return &AdminHandler{cache: redisCache, db: database, cfg: cfg, prom: prom, auditor: auditor}
+}
+
+// alertView is the JSON shape for a single entry in GetAlerts.
+type alertView struct {
+	Name     string            `json:"name"`
+	State    string            `json:"state"`
+	Severity string            `json:"severity"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// GetAlerts handles GET /v1/admin/alerts, surfacing every alert currently
+// known to Prometheus so operators can see firing/pending alerts without
+// leaving the app.
+func (h *AdminHandler) GetAlerts(c *fiber.Ctx) error {
+	if h.prom == nil {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "PROMETHEUS_URL is not configured",
+		})
+	}
+
+	alerts, err := h.prom.Alerts(c.Context())
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{
+			"error": "failed to query Prometheus alerts",
+		})
+	}
+
+	views := make([]alertView, 0, len(alerts))
+	for _, a := range alerts {
+		labels := make(map[string]string, len(a.Labels))
+		severity := "unknown"
+		for name, value := range a.Labels {
+			labels[string(name)] = string(value)
+			if name == "severity" {
+				severity = string(value)
+			}
+		}
+
+		views = append(views, alertView{
+			Name:     string(a.Labels["alertname"]),
+			State:    string(a.State),
+			Severity: severity,
+			Labels:   labels,
+		})
+	}
+
+	return c.JSON(fiber.Map{"alerts": views})
+}
+
+// GetAuditLog handles GET /v1/admin/audit?url_id=&actor_user_id=&action=&since=&until=
+// surfacing the create/update/deactivate history recorded for URLs.
+func (h *AdminHandler) GetAuditLog(c *fiber.Ctx) error {
+	if h.auditor == nil {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "audit log is not configured",
+		})
+	}
+
+	filter := audit.AuditFilter{
+		Action: c.Query("action"),
+	}
+
+	if v := c.Query("url_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid url_id"})
+		}
+		filter.URLID = &id
+	}
+	if v := c.Query("actor_user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid actor_user_id"})
+		}
+		filter.ActorUserID = &id
+	}
+	if v := c.Query("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid since, expected RFC3339"})
+		}
+		filter.Since = &since
+	}
+	if v := c.Query("until"); v != "" {
+		until, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid until, expected RFC3339"})
+		}
+		filter.Until = &until
+	}
+
+	events, err := h.auditor.Search(c.Context(), filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to search audit log",
+		})
+	}
+
+	return c.JSON(fiber.Map{"events": events})
+}
+
+// GetSafetyChecks handles GET /v1/admin/urls/:id/safety, listing every
+// content-classification check recorded against a URL so an operator can
+// review why it was flagged before deciding whether to override it.
+func (h *AdminHandler) GetSafetyChecks(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid URL ID"})
+	}
+
+	checks, err := h.db.ListSafetyChecks(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to list safety checks",
+		})
+	}
+
+	return c.JSON(fiber.Map{"checks": checks})
+}
+
+// overrideSafetyRequest is the body for OverrideSafety.
+type overrideSafetyRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// OverrideSafety handles POST /v1/admin/urls/:id/safety/override,
+// reactivating a URL that the content-classification gate flagged under
+// safety.ModeWarn once an operator has reviewed it and judged it safe.
+func (h *AdminHandler) OverrideSafety(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid URL ID"})
+	}
+
+	var req overrideSafetyRequest
+	_ = c.BodyParser(&req)
+
+	actor := audit.Actor{
+		RequestID: GetRequestID(c),
+		RemoteIP:  c.IP(),
+		Reason:    req.Reason,
+	}
+
+	if err := h.db.OverrideSafetyCheck(c.Context(), actor, id); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to override safety check",
+		})
+	}
+
+	return c.SendStatus(204)
+}
+
+// purgeRevokedRequest is the body for scope=revoked.
+type purgeRevokedRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// purgeUserRequest is the body for scope=user.
+type purgeUserRequest struct {
+	Auth0Sub string `json:"auth0_sub"`
+}
+
+// PurgeTokens handles POST /v1/admin/tokens/purge?scope={lapsed|revoked|user}
+func (h *AdminHandler) PurgeTokens(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	switch c.Query("scope") {
+	case "revoked":
+		return h.purgeRevoked(c, ctx)
+	case "lapsed":
+		return h.purgeLapsed(c, ctx)
+	case "user":
+		return h.purgeUser(c, ctx)
+	default:
+		return c.Status(400).JSON(fiber.Map{
+			"error": "scope must be one of: lapsed, revoked, user",
+		})
+	}
+}
+
+// purgeRevoked adds the sub/jti values in the request body to the
+// auth:revoked set, so auth.Middleware rejects those tokens from here on.
+func (h *AdminHandler) purgeRevoked(c *fiber.Ctx, ctx context.Context) error {
+	var req purgeRevokedRequest
+	if err := c.BodyParser(&req); err != nil || len(req.IDs) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "request body must include a non-empty \"ids\" array of sub or jti values",
+		})
+	}
+
+	if err := h.cache.RevokeTokens(ctx, req.IDs, h.cfg.MaxTokenLifetime); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to revoke tokens",
+		})
+	}
+
+	return c.JSON(fiber.Map{"revoked": len(req.IDs)})
+}
+
+// purgeLapsed sweeps recorded sessions for ones whose expiry has passed.
+func (h *AdminHandler) purgeLapsed(c *fiber.Ctx, ctx context.Context) error {
+	purged, err := h.cache.PurgeLapsed(ctx, time.Now())
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to purge lapsed sessions",
+		})
+	}
+
+	return c.JSON(fiber.Map{"purged": purged})
+}
+
+// purgeUser invalidates every session and cached URL/QR entry owned by a
+// specific auth0_sub, e.g. as part of an account deactivation.
+func (h *AdminHandler) purgeUser(c *fiber.Ctx, ctx context.Context) error {
+	var req purgeUserRequest
+	if err := c.BodyParser(&req); err != nil || req.Auth0Sub == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "request body must include \"auth0_sub\"",
+		})
+	}
+
+	user, err := h.db.GetUserByAuth0Sub(ctx, req.Auth0Sub)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	sessionsPurged, err := h.cache.PurgeUserSessions(ctx, req.Auth0Sub)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to purge user sessions",
+		})
+	}
+
+	urlsPurged := 0
+	for offset := 0; ; offset += userPurgePageSize {
+		urls, err := h.db.ListUserURLs(ctx, user.ID, userPurgePageSize, offset, nil)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to list user URLs",
+			})
+		}
+		if len(urls) == 0 {
+			break
+		}
+
+		for _, url := range urls {
+			if err := h.cache.DeleteURL(ctx, url.ShortCode); err != nil {
+				return c.Status(500).JSON(fiber.Map{
+					"error": "failed to purge cached URL",
+				})
+			}
+			if err := h.cache.DeleteQRCodes(ctx, url.ID.String()); err != nil {
+				return c.Status(500).JSON(fiber.Map{
+					"error": "failed to purge cached QR codes",
+				})
+			}
+			urlsPurged++
+		}
+
+		if len(urls) < userPurgePageSize {
+			break
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"sessions_purged": sessionsPurged,
+		"urls_purged":     urlsPurged,
+	})
+}
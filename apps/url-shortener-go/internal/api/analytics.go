@@ -2,40 +2,50 @@ package api
 
 import (
 	"context"
-	"strconv"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/auth"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/realtime"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/worker"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 )
 
 // AnalyticsHandler handles analytics query endpoints
 type AnalyticsHandler struct {
-	db  *db.DB
-	cfg *config.Config
+	cache *cache.RedisCache
+	db    *db.DB
+	cfg   *config.Config
+	hub   *realtime.Hub
 }
 
-// NewAnalyticsHandler creates a new analytics handler
-func NewAnalyticsHandler(database *db.DB, cfg *config.Config) *AnalyticsHandler {
+// NewAnalyticsHandler creates a new analytics handler. hub backs
+// GetLiveAnalytics; it may be nil, in which case that endpoint reports
+// itself as disabled regardless of cfg.LiveAnalyticsEnabled.
+func NewAnalyticsHandler(redisCache *cache.RedisCache, database *db.DB, cfg *config.Config, hub *realtime.Hub) *AnalyticsHandler {
 	return &AnalyticsHandler{
-		db:  database,
-		cfg: cfg,
+		cache: redisCache,
+		db:    database,
+		cfg:   cfg,
+		hub:   hub,
 	}
 }
 
-// getUser retrieves or creates the user from Auth0 claims
+// getUser retrieves or creates the user from the authenticated identity
 func (h *AnalyticsHandler) getUser(c *fiber.Ctx) (*db.User, error) {
-	auth0Sub := auth.GetAuth0Sub(c)
+	subject := auth.GetSubject(c)
 	email := auth.GetEmail(c)
 	name := auth.GetName(c)
-	
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
-	return h.db.GetOrCreateUser(ctx, auth0Sub, email, name)
+
+	return h.db.GetOrCreateUser(ctx, subject, email, name)
 }
 
 // GetAnalytics handles GET /v1/urls/:id/analytics - gets comprehensive analytics
@@ -66,8 +76,14 @@ func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get stats
-	stats, err := h.db.GetURLStats(ctx, id)
+	// Get stats. ?accuracy=approx trades exact COUNT(DISTINCT ip_hash)
+	// for a HyperLogLog estimate, which stays cheap for high-volume URLs.
+	var stats *db.ClickStats
+	if c.Query("accuracy") == "approx" {
+		stats, err = h.db.GetURLStatsApprox(ctx, id)
+	} else {
+		stats, err = h.db.GetURLStats(ctx, id)
+	}
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to get analytics",
@@ -99,7 +115,29 @@ func (h *AnalyticsHandler) GetAnalytics(c *fiber.Ctx) error {
 	})
 }
 
-// GetClicksOverTime handles GET /v1/urls/:id/analytics/clicks
+// parseFill maps a ?clicks_fill/?visitors_fill query value to a db.Fill,
+// defaulting to def when the value is unset.
+func parseFill(v string, def db.Fill) (db.Fill, error) {
+	switch v {
+	case "":
+		return def, nil
+	case "zero":
+		return db.FillZero, nil
+	case "locf":
+		return db.FillLOCF, nil
+	case "interpolate":
+		return db.FillInterpolate, nil
+	case "null":
+		return db.FillNull, nil
+	default:
+		return 0, fmt.Errorf("invalid fill %q, expected zero, locf, interpolate, or null", v)
+	}
+}
+
+// GetClicksOverTime handles
+// GET /v1/urls/:id/analytics/clicks?start=&end=&resolution=&clicks_fill=&visitors_fill=&accuracy=
+// start/end are RFC3339 timestamps (default: the last 7 days); resolution
+// is one of 1m/5m/1h/1d (default 1h).
 func (h *AnalyticsHandler) GetClicksOverTime(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := uuid.Parse(idParam)
@@ -109,13 +147,31 @@ func (h *AnalyticsHandler) GetClicksOverTime(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get days parameter (default 7)
-	days, _ := strconv.Atoi(c.Query("days", "7"))
-	if days < 1 {
-		days = 1
+	end := time.Now()
+	if v := c.Query("end"); v != "" {
+		end, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid end, expected RFC3339"})
+		}
+	}
+
+	start := end.Add(-7 * 24 * time.Hour)
+	if v := c.Query("start"); v != "" {
+		start, err = time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid start, expected RFC3339"})
+		}
+	}
+
+	resolution := db.Resolution(c.Query("resolution", string(db.Resolution1Hour)))
+
+	clicksFill, err := parseFill(c.Query("clicks_fill"), db.FillZero)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
-	if days > 90 {
-		days = 90
+	visitorsFill, err := parseFill(c.Query("visitors_fill"), db.FillLOCF)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
 	}
 
 	// Get user
@@ -136,18 +192,41 @@ func (h *AnalyticsHandler) GetClicksOverTime(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get time series data
-	points, err := h.db.GetClicksOverTime(ctx, id, days)
+	// ?accuracy=approx serves buckets older than the realtime window from
+	// the hourly_stats_hll continuous aggregate instead of gap-filled
+	// exact counts; it keeps its own days-based windowing.
+	if c.Query("accuracy") == "approx" {
+		days := int(end.Sub(start).Hours()/24) + 1
+		points, err := h.db.GetClicksOverTimeApprox(ctx, id, days)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to get click data",
+			})
+		}
+		return c.JSON(fiber.Map{
+			"url_id": id.String(),
+			"days":   days,
+			"data":   points,
+		})
+	}
+
+	result, err := h.db.GetClicksOverTime(ctx, id, start, end, resolution, clicksFill, visitorsFill)
 	if err != nil {
+		if errors.Is(err, db.ErrTooManyBuckets) || errors.Is(err, db.ErrInvalidResolution) {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to get click data",
 		})
 	}
 
 	return c.JSON(fiber.Map{
-		"url_id": id.String(),
-		"days":   days,
-		"data":   points,
+		"url_id":               id.String(),
+		"start":                start,
+		"end":                  end,
+		"resolution":           resolution,
+		"bucket_width_seconds": result.BucketWidth.Seconds(),
+		"data":                 result.Points,
 	})
 }
 
@@ -244,6 +323,110 @@ func (h *AnalyticsHandler) GetDeviceBreakdown(c *fiber.Ctx) error {
 	})
 }
 
+// CreateExport handles POST /v1/urls/:id/analytics/export. It enqueues an
+// async export job onto analytics:exports and returns immediately with a
+// job ID; GetExport polls for the result. Body: {"start":..., "end":...,
+// "format":"csv"|"parquet", "columns":[...]} - start/end are RFC3339 and
+// default to the last 30 days, format defaults to csv, and columns (CSV
+// only) defaults to every column.
+func (h *AnalyticsHandler) CreateExport(c *fiber.Ctx) error {
+	if h.cfg.S3Endpoint == "" {
+		return c.Status(503).JSON(fiber.Map{"error": "analytics export is not configured"})
+	}
+
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid URL ID",
+		})
+	}
+
+	var body struct {
+		Start   time.Time `json:"start"`
+		End     time.Time `json:"end"`
+		Format  string    `json:"format"`
+		Columns []string  `json:"columns"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	if body.Format == "" {
+		body.Format = "csv"
+	}
+	if body.Format != "csv" && body.Format != "parquet" {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid format, expected csv or parquet"})
+	}
+
+	end := body.End
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := body.Start
+	if start.IsZero() {
+		start = end.Add(-30 * 24 * time.Hour)
+	}
+	if !start.Before(end) {
+		return c.Status(400).JSON(fiber.Map{"error": "start must be before end"})
+	}
+
+	// Get user
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	ctx := c.Context()
+
+	// Verify URL ownership
+	url, err := h.db.GetURLByID(ctx, id)
+	if err != nil || url.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	job := worker.ExportJob{
+		ID:        uuid.NewString(),
+		URLID:     id,
+		Start:     start,
+		End:       end,
+		Format:    body.Format,
+		Columns:   body.Columns,
+		Status:    worker.ExportQueued,
+		CreatedAt: time.Now(),
+	}
+
+	if err := cache.SetJSON(ctx, h.cache, worker.ExportJobKey(job.ID), job, h.cfg.ExportJobTTL); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to create export job"})
+	}
+	if err := h.cache.EnqueueJSON(ctx, "analytics:exports", job); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": "failed to enqueue export job"})
+	}
+
+	return c.Status(202).JSON(fiber.Map{
+		"job_id": job.ID,
+		"status": job.Status,
+	})
+}
+
+// GetExport handles GET /v1/exports/:job_id - polls the status of an
+// export job queued by CreateExport, returning a download_url once the
+// job is done.
+func (h *AnalyticsHandler) GetExport(c *fiber.Ctx) error {
+	jobID := c.Params("job_id")
+
+	job, err := cache.GetJSON[worker.ExportJob](c.Context(), h.cache, worker.ExportJobKey(jobID))
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "export job not found"})
+	}
+
+	return c.JSON(job)
+}
+
 // GetDashboard handles GET /v1/dashboard - user dashboard stats
 func (h *AnalyticsHandler) GetDashboard(c *fiber.Ctx) error {
 	// Get user
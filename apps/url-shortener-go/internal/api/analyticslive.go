@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/realtime"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// GetLiveAnalytics handles GET /v1/urls/:id/analytics/live, upgrading to
+// Server-Sent Events and streaming one JSON frame per click as the
+// flusher worker processes it, via the realtime hub, plus a periodic
+// heartbeat comment to keep idle connections (and the proxies in front of
+// them) alive.
+//
+// A client reconnecting with a Last-Event-ID header (an RFC3339Nano event
+// time) is first replayed every event the hub still has buffered since
+// that time - a small in-memory ring buffer, not a durable store, so a
+// long disconnect can miss events that clicks/stream's DB-backed replay
+// wouldn't. See internal/realtime for why.
+func (h *AnalyticsHandler) GetLiveAnalytics(c *fiber.Ctx) error {
+	if !h.cfg.LiveAnalyticsEnabled || h.hub == nil {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "live analytics is not enabled",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid URL ID",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	url, err := h.db.GetURLByID(c.Context(), id)
+	if err != nil || url.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	var replaySince time.Time
+	if lastEventID := c.Get("Last-Event-ID"); lastEventID != "" {
+		if t, err := time.Parse(time.RFC3339Nano, lastEventID); err == nil {
+			replaySince = t
+		}
+	}
+
+	// Subscribe before replaying, so an event published in the gap
+	// between the replay read and the subscription landing can't be missed.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	live := h.hub.Subscribe(streamCtx, id)
+	replay := h.hub.Replay(id, replaySince)
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		for _, event := range replay {
+			if !writeLiveEvent(w, event) {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(h.cfg.ClickStreamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event, ok := <-live:
+				if !ok {
+					return
+				}
+				if !writeLiveEvent(w, event) {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}))
+
+	return nil
+}
+
+// writeLiveEvent writes event as one SSE frame, using its ID (an
+// RFC3339Nano timestamp, unique enough to double as the frame's id) so a
+// client's next Last-Event-ID resumes exactly after it.
+func writeLiveEvent(w *bufio.Writer, event realtime.Event) bool {
+	if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", event.ID, event.Data); err != nil {
+		return false
+	}
+	return w.Flush() == nil
+}
@@ -0,0 +1,415 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/limits"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/safety"
+	"github.com/gofiber/fiber/v2"
+)
+
+// importRow is one row of a bulk import upload, in either its CSV or
+// JSON shape.
+type importRow struct {
+	DestinationURL string `json:"destination_url"`
+	CustomCode     string `json:"custom_code,omitempty"`
+	Notes          string `json:"notes,omitempty"`
+	ExpiresIn      *int   `json:"expires_in,omitempty"`
+}
+
+// importRowResult reports one row's outcome, keyed by its 1-based
+// position in the upload so a caller can correlate failures back to
+// their source file.
+type importRowResult struct {
+	Row            int    `json:"row"`
+	DestinationURL string `json:"destination_url"`
+	ShortCode      string `json:"short_code,omitempty"`
+	ShortURL       string `json:"short_url,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// parseImportRows decodes body as either JSON (a top-level array of
+// objects matching importRow) or CSV (with a destination_url,
+// custom_code, notes, expires_in header row, in any order - unrecognized
+// columns are ignored), chosen by format ("json" or "csv").
+func parseImportRows(body []byte, format string) ([]importRow, error) {
+	switch format {
+	case "json":
+		var rows []importRow
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return rows, nil
+	case "csv":
+		reader := csv.NewReader(strings.NewReader(string(body)))
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV header: %w", err)
+		}
+		colIndex := make(map[string]int, len(header))
+		for i, name := range header {
+			colIndex[strings.TrimSpace(strings.ToLower(name))] = i
+		}
+		if _, ok := colIndex["destination_url"]; !ok {
+			return nil, fmt.Errorf("CSV must have a destination_url column")
+		}
+
+		var rows []importRow
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CSV row: %w", err)
+			}
+
+			row := importRow{}
+			if i, ok := colIndex["destination_url"]; ok && i < len(record) {
+				row.DestinationURL = record[i]
+			}
+			if i, ok := colIndex["custom_code"]; ok && i < len(record) {
+				row.CustomCode = record[i]
+			}
+			if i, ok := colIndex["notes"]; ok && i < len(record) {
+				row.Notes = record[i]
+			}
+			if i, ok := colIndex["expires_in"]; ok && i < len(record) && record[i] != "" {
+				seconds, err := strconv.Atoi(record[i])
+				if err != nil {
+					return nil, fmt.Errorf("invalid expires_in %q", record[i])
+				}
+				row.ExpiresIn = &seconds
+			}
+			rows = append(rows, row)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q, must be \"json\" or \"csv\"", format)
+	}
+}
+
+// importFormat resolves the format ImportURLs/ExportURLs should use from
+// an explicit ?format= query param, falling back to sniffing the
+// request's Content-Type (import) or defaulting to json (export).
+func importFormat(c *fiber.Ctx) string {
+	if format := c.Query("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	if strings.Contains(strings.ToLower(c.Get("Content-Type")), "csv") {
+		return "csv"
+	}
+	return "json"
+}
+
+// ImportURLs handles POST /v1/urls/import - bulk-creates short URLs from
+// a CSV or JSON upload. Each row is validated and created independently:
+// one row failing (an unsafe destination, a taken custom code, ...)
+// doesn't abort the rest. Rows run on a bounded worker pool
+// (cfg.BulkImportConcurrency) rather than all at once, so a 10k-row
+// upload can't exhaust the database or Redis connection pools the way an
+// unbounded fan-out would.
+func (h *URLHandler) ImportURLs(c *fiber.Ctx) error {
+	format := importFormat(c)
+
+	rows, err := parseImportRows(c.Body(), format)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if len(rows) == 0 {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "no rows to import",
+		})
+	}
+	if h.cfg.BulkImportMaxRows > 0 && len(rows) > h.cfg.BulkImportMaxRows {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("import has %d rows, exceeding the %d row limit", len(rows), h.cfg.BulkImportMaxRows),
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	// Idempotency-Key support: a retried upload with the same key and body
+	// gets the original per-row results back without re-running the
+	// import; the same key with a different body is rejected outright.
+	idempotencyKey := c.Get(idempotencyHeader)
+	var requestHash string
+	if idempotencyKey != "" {
+		requestHash = hashRequestBody(c.Body())
+		rec, err := checkIdempotency(c.Context(), h.cache, user.Auth0Sub, idempotencyKey, requestHash)
+		if err != nil {
+			return idempotencyErrorResponse(c, err)
+		}
+		if rec != nil {
+			return replayIdempotentResponse(c, rec)
+		}
+	}
+
+	tenantLimits := h.limits.For(user.Auth0Sub)
+	if tenantLimits.MaxURLsPerUser > 0 {
+		existing, err := h.db.CountUserURLs(c.Context(), user.ID, nil)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to check URL quota",
+			})
+		}
+		if existing+len(rows) > tenantLimits.MaxURLsPerUser {
+			metrics.LimitRejections.WithLabelValues("max_urls", user.Auth0Sub).Inc()
+			return c.Status(429).JSON(fiber.Map{
+				"error": "import would exceed the maximum number of URLs allowed for this account",
+			})
+		}
+	}
+
+	requestID := GetRequestID(c)
+	remoteIP := c.IP()
+
+	workers := h.cfg.BulkImportConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	results := make([]importRowResult, len(rows))
+	var wg sync.WaitGroup
+	for i, row := range rows {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, row importRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			actor := audit.Actor{
+				UserID:    user.ID,
+				RequestID: requestID,
+				RemoteIP:  remoteIP,
+				Reason:    "bulk import",
+			}
+			results[i] = h.importOneRow(ctx, i+1, user, tenantLimits, actor, row)
+		}(i, row)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Error == "" {
+			succeeded++
+		}
+	}
+
+	if err := c.JSON(fiber.Map{
+		"total":     len(results),
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	}); err != nil {
+		return err
+	}
+	if idempotencyKey != "" {
+		storeIdempotencyResponse(h.cache, user.Auth0Sub, idempotencyKey, requestHash, c)
+	}
+	return nil
+}
+
+// importOneRow validates and creates a single import row, never
+// returning an error itself - any failure is reported in the returned
+// importRowResult.Error so one bad row can't abort the rest of the
+// upload.
+func (h *URLHandler) importOneRow(ctx context.Context, rowNum int, user *db.User, tenantLimits limits.Limits, actor audit.Actor, row importRow) importRowResult {
+	result := importRowResult{Row: rowNum, DestinationURL: row.DestinationURL}
+
+	sanitizedURL, err := h.validator.ValidateAndSanitizeURL(ctx, row.DestinationURL)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if parsed, err := neturl.Parse(sanitizedURL); err == nil && !tenantLimits.AllowsHost(parsed.Hostname()) {
+		result.Error = "destination host is not allowed for this account"
+		return result
+	}
+
+	if row.ExpiresIn != nil && tenantLimits.MaxTTLDays > 0 {
+		maxSeconds := tenantLimits.MaxTTLDays * 86400
+		if *row.ExpiresIn > maxSeconds {
+			result.Error = "expires_in exceeds the maximum TTL allowed for this account"
+			return result
+		}
+	}
+
+	var shortCode string
+	if row.CustomCode != "" {
+		if tenantLimits.MaxShortCodeLength > 0 && len(row.CustomCode) > tenantLimits.MaxShortCodeLength {
+			result.Error = "custom_code exceeds the maximum length allowed for this account"
+			return result
+		}
+		if err := h.shortener.ValidateCustomCode(ctx, row.CustomCode, nil); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		shortCode = row.CustomCode
+	} else {
+		shortCode, err = h.shortener.GenerateCode(ctx, nil)
+		if err != nil {
+			result.Error = "failed to generate short code"
+			return result
+		}
+	}
+
+	var notes *string
+	if row.Notes != "" {
+		notes = &row.Notes
+	}
+
+	safetyMode := safety.ParseMode(user.SafetyMode)
+	var safetyResult *safety.Result
+	if safetyMode != safety.ModeOff {
+		safetyResult, err = h.safetyChecker.Check(ctx, sanitizedURL)
+		if err != nil {
+			result.Error = "failed to classify destination content"
+			return result
+		}
+	}
+
+	url, err := h.db.CreateURL(ctx, actor, shortCode, sanitizedURL, row.ExpiresIn, notes, safetyResult, safetyMode, nil, nil)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	h.cache.SetURL(ctx, shortCode, sanitizedURL)
+	h.cache.SetURLOwner(ctx, shortCode, user.Auth0Sub)
+	h.cache.SetUserURLsLastEdit(ctx, user.Auth0Sub, time.Now())
+
+	result.ShortCode = url.ShortCode
+	result.ShortURL = h.cfg.BaseURL + "/" + url.ShortCode
+	return result
+}
+
+// ExportURLs handles GET /v1/urls/export - streams every URL owned by
+// the caller as CSV or JSON (?format=, default json), paginating through
+// Postgres in cfg.BulkExportBatchSize pages instead of loading the whole
+// account into memory at once.
+func (h *URLHandler) ExportURLs(c *fiber.Ctx) error {
+	format := importFormat(c)
+	if format != "json" && format != "csv" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": fmt.Sprintf("unsupported format %q, must be \"json\" or \"csv\"", format),
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	batchSize := h.cfg.BulkExportBatchSize
+	if batchSize < 1 {
+		batchSize = 500
+	}
+
+	if format == "csv" {
+		c.Set("Content-Type", "text/csv")
+		c.Set("Content-Disposition", `attachment; filename="urls.csv"`)
+	} else {
+		c.Set("Content-Type", "application/json")
+		c.Set("Content-Disposition", `attachment; filename="urls.json"`)
+	}
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ctx := context.Background()
+
+		var csvWriter *csv.Writer
+		if format == "csv" {
+			csvWriter = csv.NewWriter(w)
+			csvWriter.Write([]string{"short_code", "short_url", "destination_url", "notes", "is_active", "expires_at", "created_at"})
+		} else {
+			w.WriteString("[")
+		}
+
+		offset := 0
+		first := true
+		for {
+			urls, err := h.db.ListUserURLs(ctx, user.ID, batchSize, offset, nil)
+			if err != nil || len(urls) == 0 {
+				break
+			}
+
+			for _, url := range urls {
+				if format == "csv" {
+					notes := ""
+					if url.Notes != nil {
+						notes = *url.Notes
+					}
+					expiresAt := ""
+					if url.ExpiresAt != nil {
+						expiresAt = url.ExpiresAt.Format(time.RFC3339)
+					}
+					csvWriter.Write([]string{
+						url.ShortCode,
+						h.cfg.BaseURL + "/" + url.ShortCode,
+						url.DestinationURL,
+						notes,
+						strconv.FormatBool(url.IsActive),
+						expiresAt,
+						url.CreatedAt.Format(time.RFC3339),
+					})
+				} else {
+					if !first {
+						w.WriteString(",")
+					}
+					first = false
+					data, err := json.Marshal(h.toResponse(ctx, url))
+					if err != nil {
+						continue
+					}
+					w.Write(data)
+				}
+			}
+			if format == "csv" {
+				csvWriter.Flush()
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			if len(urls) < batchSize {
+				break
+			}
+			offset += batchSize
+		}
+
+		if format == "json" {
+			w.WriteString("]")
+			w.Flush()
+		}
+	})
+
+	return nil
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/valyala/fasthttp"
+)
+
+// StreamClicks handles GET /v1/urls/:id/clicks/stream, upgrading to
+// Server-Sent Events and streaming one JSON frame per click as it's
+// recorded, plus a periodic heartbeat comment to keep idle connections
+// (and the proxies in front of them) alive.
+//
+// A client reconnecting with a Last-Event-ID header (an RFC3339Nano click
+// time) is first replayed every click recorded since that time, bounded
+// by cfg.ClickStreamReplayWindow, before the stream switches over to live
+// notifications - so a brief disconnect doesn't lose clicks in between.
+func (h *AnalyticsHandler) StreamClicks(c *fiber.Ctx) error {
+	if !h.cfg.ClickStreamEnabled {
+		return c.Status(503).JSON(fiber.Map{
+			"error": "click stream is not enabled",
+		})
+	}
+
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid URL ID",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	url, err := h.db.GetURLByID(c.Context(), id)
+	if err != nil || url.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	replaySince := time.Now().Add(-h.cfg.ClickStreamReplayWindow)
+	if lastEventID := c.Get("Last-Event-ID"); lastEventID != "" {
+		if t, err := time.Parse(time.RFC3339Nano, lastEventID); err == nil && t.After(replaySince) {
+			replaySince = t
+		}
+	}
+
+	// Subscribe before replaying, so a click recorded in the gap between
+	// the replay query and the subscription landing can't be missed.
+	streamCtx, cancel := context.WithCancel(context.Background())
+	live, err := h.db.SubscribeClicks(streamCtx, id)
+	if err != nil {
+		cancel()
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to subscribe to click stream",
+		})
+	}
+
+	replay, err := h.db.ReplayClicks(c.Context(), id, replaySince)
+	if err != nil {
+		cancel()
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to replay recent clicks",
+		})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		for _, click := range replay {
+			if !writeClickEvent(w, click) {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(h.cfg.ClickStreamHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case click, ok := <-live:
+				if !ok {
+					return
+				}
+				if !writeClickEvent(w, click) {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	}))
+
+	return nil
+}
+
+// writeClickEvent writes click as one SSE frame, using its time (formatted
+// RFC3339Nano, unique enough to double as this frame's id) as the event's
+// id so a client's next Last-Event-ID resumes exactly after it.
+func writeClickEvent(w *bufio.Writer, click db.Click) bool {
+	data, err := json.Marshal(click)
+	if err != nil {
+		return true
+	}
+	if _, err := fmt.Fprintf(w, "id: %s\ndata: %s\n\n", click.Time.Format(time.RFC3339Nano), data); err != nil {
+		return false
+	}
+	return w.Flush() == nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// strongETag returns a quoted, strong ETag (RFC 7232 §2.3) derived from
+// version - typically a resource's UpdatedAt, or a hash of a list
+// response's contents.
+func strongETag(version string) string {
+	sum := sha256.Sum256([]byte(version))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports whether c's conditional request headers
+// (If-None-Match, falling back to If-Modified-Since) show the client
+// already holds a copy of a resource matching etag/lastModified. The
+// caller still has to send the 304 response itself - this only decides
+// whether to.
+func notModified(c *fiber.Ctx, etag string, lastModified time.Time) bool {
+	if inm := c.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			if strings.TrimSpace(candidate) == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := c.Get("If-Modified-Since"); ims != "" {
+		t, err := time.Parse(http.TimeFormat, ims)
+		if err == nil && !lastModified.Truncate(time.Second).After(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeConditionalHeaders sets the ETag/Last-Modified headers a later
+// request's If-None-Match/If-Modified-Since can be checked against.
+func writeConditionalHeaders(c *fiber.Ctx, etag string, lastModified time.Time) {
+	c.Set("ETag", etag)
+	c.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+}
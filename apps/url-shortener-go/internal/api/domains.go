@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/auth"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/customdomain"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// DomainHandler handles custom domain CRUD and DNS ownership
+// verification.
+type DomainHandler struct {
+	db *db.DB
+}
+
+// NewDomainHandler creates a new domain handler.
+func NewDomainHandler(database *db.DB) *DomainHandler {
+	return &DomainHandler{db: database}
+}
+
+// getUser retrieves or creates the user from the authenticated identity
+func (h *DomainHandler) getUser(c *fiber.Ctx) (*db.User, error) {
+	subject := auth.GetSubject(c)
+	email := auth.GetEmail(c)
+	name := auth.GetName(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return h.db.GetOrCreateUser(ctx, subject, email, name)
+}
+
+// domainScopedCacheKey returns the cache key a short code should be
+// stored/looked up under: shortCode itself for the default BaseURL host
+// (customDomainID nil), unchanged from before custom domains existed, or
+// a key namespaced by customDomainID otherwise, so the same code under
+// two different domains can't collide in Redis.
+func domainScopedCacheKey(shortCode string, customDomainID *uuid.UUID) string {
+	if customDomainID == nil {
+		return shortCode
+	}
+	return "d:" + customDomainID.String() + ":" + shortCode
+}
+
+// DomainResponse represents a custom domain in API responses
+type DomainResponse struct {
+	ID             string     `json:"id"`
+	Domain         string     `json:"domain"`
+	Verified       bool       `json:"verified"`
+	ChallengeName  string     `json:"challenge_name"`
+	ChallengeValue string     `json:"challenge_value"`
+	CreatedAt      time.Time  `json:"created_at"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+}
+
+func toDomainResponse(d *db.CustomDomain) *DomainResponse {
+	return &DomainResponse{
+		ID:             d.ID.String(),
+		Domain:         d.Domain,
+		Verified:       d.Verified,
+		ChallengeName:  customdomain.ChallengeName(d.Domain),
+		ChallengeValue: d.ChallengeToken,
+		CreatedAt:      d.CreatedAt,
+		VerifiedAt:     d.VerifiedAt,
+	}
+}
+
+// CreateDomainRequest represents the request body for claiming a custom domain
+type CreateDomainRequest struct {
+	Domain string `json:"domain"`
+}
+
+// CreateDomain handles POST /v1/domains - claims a custom domain,
+// returning the DNS TXT challenge the caller must publish before
+// VerifyDomain will accept it.
+func (h *DomainHandler) CreateDomain(c *fiber.Ctx) error {
+	var req CreateDomainRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	domain := strings.ToLower(strings.TrimSpace(req.Domain))
+	if domain == "" || strings.ContainsAny(domain, "/ \t") {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "domain is required and must be a bare hostname",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	token, err := customdomain.GenerateToken()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to generate challenge token",
+		})
+	}
+
+	ctx := c.Context()
+	created, err := h.db.CreateCustomDomain(ctx, user.ID, domain, token)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to claim domain",
+		})
+	}
+
+	return c.Status(201).JSON(toDomainResponse(created))
+}
+
+// ListDomains handles GET /v1/domains - lists the caller's claimed domains
+func (h *DomainHandler) ListDomains(c *fiber.Ctx) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	domains, err := h.db.ListCustomDomainsByUser(c.Context(), user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to list domains",
+		})
+	}
+
+	response := make([]*DomainResponse, len(domains))
+	for i, d := range domains {
+		response[i] = toDomainResponse(d)
+	}
+	return c.JSON(fiber.Map{"domains": response})
+}
+
+// VerifyDomain handles POST /v1/domains/:id/verify - checks the domain's
+// DNS challenge TXT record and, if it matches, marks it verified.
+func (h *DomainHandler) VerifyDomain(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid domain ID",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	ctx := c.Context()
+
+	domain, err := h.db.GetCustomDomainByID(ctx, id)
+	if err != nil || domain.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "domain not found",
+		})
+	}
+
+	if domain.Verified {
+		return c.JSON(toDomainResponse(domain))
+	}
+
+	matched, err := customdomain.Verify(ctx, domain.Domain, domain.ChallengeToken)
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{
+			"error": "failed to look up DNS challenge: " + err.Error(),
+		})
+	}
+	if !matched {
+		return c.Status(422).JSON(fiber.Map{
+			"error": "challenge TXT record not found or does not match",
+		})
+	}
+
+	if err := h.db.MarkCustomDomainVerified(ctx, domain.ID); err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to mark domain verified",
+		})
+	}
+
+	domain.Verified = true
+	return c.JSON(toDomainResponse(domain))
+}
+
+// DeleteDomain handles DELETE /v1/domains/:id - releases a claimed domain
+func (h *DomainHandler) DeleteDomain(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid domain ID",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	if err := h.db.DeleteCustomDomain(c.Context(), user.ID, id); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "domain not found",
+		})
+	}
+
+	return c.SendStatus(204)
+}
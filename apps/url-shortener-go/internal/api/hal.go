@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// halMediaType is the media type a client must send in its Accept
+// header to opt a response into HAL hypermedia (_links/_embedded)
+// instead of its plain JSON shape.
+const halMediaType = "application/hal+json"
+
+// HALLink is a single HAL hypermedia link.
+type HALLink struct {
+	Href string `json:"href"`
+}
+
+// isHALRequest reports whether c asked for HAL+JSON via its Accept header.
+func isHALRequest(c *fiber.Ctx) bool {
+	return strings.Contains(c.Get("Accept"), halMediaType)
+}
+
+// urlHALLinks builds the self/qr/analytics/update/delete links for a
+// single URL resource, identified by its ID.
+func urlHALLinks(id string) map[string]HALLink {
+	self := "/v1/urls/" + id
+	return map[string]HALLink{
+		"self":      {Href: self},
+		"qr":        {Href: self + "/qr"},
+		"analytics": {Href: self + "/analytics"},
+		"update":    {Href: self},
+		"delete":    {Href: self},
+	}
+}
+
+// paginationHALLinks builds the self/next/prev links for a paginated
+// list response at basePath, given the limit/offset/total that produced
+// it.
+func paginationHALLinks(basePath string, limit, offset, total int) map[string]HALLink {
+	links := map[string]HALLink{
+		"self": {Href: fmt.Sprintf("%s?limit=%d&offset=%d", basePath, limit, offset)},
+	}
+	if offset+limit < total {
+		links["next"] = HALLink{Href: fmt.Sprintf("%s?limit=%d&offset=%d", basePath, limit, offset+limit)}
+	}
+	if offset > 0 {
+		prevOffset := offset - limit
+		if prevOffset < 0 {
+			prevOffset = 0
+		}
+		links["prev"] = HALLink{Href: fmt.Sprintf("%s?limit=%d&offset=%d", basePath, limit, prevOffset)}
+	}
+	return links
+}
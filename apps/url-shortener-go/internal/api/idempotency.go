@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
+	"github.com/gofiber/fiber/v2"
+)
+
+// idempotencyHeader is the header a client sets to make a mutating
+// request (CreateURL, ImportURLs) safely retryable - see
+// checkIdempotency/storeIdempotencyResponse.
+const idempotencyHeader = "Idempotency-Key"
+
+// ErrIdempotencyConflict is returned by checkIdempotency when key can't
+// be honored for this request. Retryable distinguishes the two cases:
+// true means another request using the same key is still in flight (the
+// caller should respond 409 and let the client retry shortly), false
+// means key was already used with a different request body (the caller
+// should respond 422 - retrying won't help until the client picks a new
+// key).
+type ErrIdempotencyConflict struct {
+	Msg       string
+	Retryable bool
+}
+
+func (e *ErrIdempotencyConflict) Error() string { return e.Msg }
+
+// hashRequestBody returns a hex-encoded SHA-256 digest of body, used to
+// detect an Idempotency-Key being replayed against a different request.
+func hashRequestBody(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkIdempotency looks up key (scoped to userSub) in c and, on a
+// genuine miss, reserves it via cache.ReserveIdempotencyKey before
+// returning - so two concurrent retries with the same key can't both
+// observe "no prior request" and both run the handler's side effects. A
+// nil record with a nil error means the reservation succeeded and the
+// caller should proceed, then call storeIdempotencyResponse once it has
+// a response to save. A non-nil record means this is a replay: the
+// caller should return it verbatim via replayIdempotentResponse instead
+// of re-running the handler's side effects. Any other error - including
+// a real cache lookup/reservation failure, not just a conflict - must
+// not be treated as "proceed"; a *ErrIdempotencyConflict distinguishes a
+// conflicting retry from that underlying failure.
+func checkIdempotency(ctx context.Context, c *cache.RedisCache, userSub, key, requestHash string) (*cache.IdempotencyRecord, error) {
+	rec, err := c.GetIdempotencyRecord(ctx, userSub, key)
+	if err != nil {
+		if !errors.Is(err, cache.ErrIdempotencyKeyNotFound) {
+			return nil, fmt.Errorf("check idempotency key: %w", err)
+		}
+		reserved, err := c.ReserveIdempotencyKey(ctx, userSub, key, requestHash)
+		if err != nil {
+			return nil, fmt.Errorf("reserve idempotency key: %w", err)
+		}
+		if !reserved {
+			return nil, &ErrIdempotencyConflict{
+				Msg:       fmt.Sprintf("a request with Idempotency-Key %q is already in progress", key),
+				Retryable: true,
+			}
+		}
+		return nil, nil
+	}
+	if rec.RequestHash != requestHash {
+		return nil, &ErrIdempotencyConflict{
+			Msg: fmt.Sprintf("Idempotency-Key %q was already used with a different request body", key),
+		}
+	}
+	if rec.Pending {
+		return nil, &ErrIdempotencyConflict{
+			Msg:       fmt.Sprintf("a request with Idempotency-Key %q is already in progress", key),
+			Retryable: true,
+		}
+	}
+	return rec, nil
+}
+
+// idempotencyErrorResponse maps a checkIdempotency error to the HTTP
+// response a handler should send: 409 if the client should simply retry
+// (another request with the same key is in flight), 422 if the key was
+// reused with a different body, or 500 for anything else (the idempotency
+// store itself is unavailable).
+func idempotencyErrorResponse(c *fiber.Ctx, err error) error {
+	var conflict *ErrIdempotencyConflict
+	if errors.As(err, &conflict) {
+		status := 422
+		if conflict.Retryable {
+			status = 409
+		}
+		return c.Status(status).JSON(fiber.Map{"error": conflict.Error()})
+	}
+	return c.Status(500).JSON(fiber.Map{"error": "failed to check idempotency key"})
+}
+
+// replayIdempotentResponse writes rec to c verbatim, as if the original
+// request had just completed.
+func replayIdempotentResponse(c *fiber.Ctx, rec *cache.IdempotencyRecord) error {
+	c.Set("Content-Type", rec.ContentType)
+	return c.Status(rec.StatusCode).Send(rec.ResponseBody)
+}
+
+// storeIdempotencyResponse records c's already-written response under
+// key, scoped to userSub, so a repeat request with the same
+// Idempotency-Key gets this exact response back instead of re-running the
+// handler. Call it only after c's response body and status are final.
+func storeIdempotencyResponse(cacheClient *cache.RedisCache, userSub, key, requestHash string, c *fiber.Ctx) {
+	rec := &cache.IdempotencyRecord{
+		RequestHash:  requestHash,
+		StatusCode:   c.Response().StatusCode(),
+		ContentType:  string(c.Response().Header.ContentType()),
+		ResponseBody: append([]byte(nil), c.Response().Body()...),
+	}
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		cacheClient.SetIdempotencyRecord(bgCtx, userSub, key, rec)
+	}()
+}
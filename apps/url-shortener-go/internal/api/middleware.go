@@ -0,0 +1,59 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header request IDs are read from and echoed back on.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMiddleware reads X-Request-ID from the incoming request,
+// generating a UUIDv4 when absent, stores it in c.Locals, and echoes it
+// back on the response so clients and downstream services (the Flusher,
+// analytics) can correlate logs and metrics for the same request.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+
+		c.Locals("request_id", requestID)
+		c.Set(RequestIDHeader, requestID)
+		return c.Next()
+	}
+}
+
+// GetRequestID extracts the request ID injected by RequestIDMiddleware.
+func GetRequestID(c *fiber.Ctx) string {
+	if id, ok := c.Locals("request_id").(string); ok {
+		return id
+	}
+	return ""
+}
+
+// MetricsMiddleware records request latency into
+// metrics.HTTPRequestDuration, labeled by the matched route pattern (not
+// the raw path, so "/:code" doesn't explode into one series per short
+// code), method, and response status.
+func MetricsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		route := c.Route().Path
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.HTTPRequestDuration.WithLabelValues(route, c.Method(), strconv.Itoa(c.Response().StatusCode())).
+			Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -2,6 +2,8 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -32,16 +34,45 @@ func NewQRHandler(cache *cache.RedisCache, database *db.DB, cfg *config.Config,
 	}
 }
 
-// getUser retrieves or creates the user from Auth0 claims
+// getUser retrieves or creates the user from the authenticated identity
 func (h *QRHandler) getUser(c *fiber.Ctx) (*db.User, error) {
-	auth0Sub := auth.GetAuth0Sub(c)
+	subject := auth.GetSubject(c)
 	email := auth.GetEmail(c)
 	name := auth.GetName(c)
-	
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
-	return h.db.GetOrCreateUser(ctx, auth0Sub, email, name)
+
+	return h.db.GetOrCreateUser(ctx, subject, email, name)
+}
+
+// qrOptionsFromQuery builds services.QROptions from the rendering query
+// params shared by GetQRPNG, GetQRSVG, GetQRPDF, and GetQREPS: ?level=,
+// ?fg=, ?bg=, ?quiet_zone=, and ?logo= (a base64-encoded PNG).
+func qrOptionsFromQuery(c *fiber.Ctx) (services.QROptions, error) {
+	opts := services.QROptions{
+		Level:      c.Query("level"),
+		Foreground: c.Query("fg"),
+		Background: c.Query("bg"),
+	}
+
+	if qz := c.Query("quiet_zone"); qz != "" {
+		n, err := strconv.Atoi(qz)
+		if err != nil {
+			return opts, fmt.Errorf("invalid quiet_zone: %w", err)
+		}
+		opts.QuietZone = n
+	}
+
+	if logo := c.Query("logo"); logo != "" {
+		decoded, err := base64.StdEncoding.DecodeString(logo)
+		if err != nil {
+			return opts, fmt.Errorf("invalid logo: %w", err)
+		}
+		opts.Logo = decoded
+	}
+
+	return opts, nil
 }
 
 // GetQRPNG handles GET /v1/urls/:id/qr - generates QR code as PNG
@@ -56,6 +87,10 @@ func (h *QRHandler) GetQRPNG(c *fiber.Ctx) error {
 
 	// Get size from query param (default 256)
 	size, _ := strconv.Atoi(c.Query("size", "256"))
+	opts, err := qrOptionsFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// Get user
 	user, err := h.getUser(c)
@@ -82,30 +117,20 @@ func (h *QRHandler) GetQRPNG(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check cache first
-	cached, err := h.cache.GetQRCode(ctx, id.String(), "png", size)
-	if err == nil {
-		c.Set("Content-Type", "image/png")
-		c.Set("Cache-Control", "public, max-age=86400")
-		return c.Send(cached)
-	}
-
-	// Generate QR code
-	shortURL := h.cfg.BaseURL + "/" + url.ShortCode
-	qrData, err := h.qrService.GeneratePNG(shortURL, size)
+	// Get-or-compute through a distributed lock so a popular QR code
+	// expiring doesn't trigger a render stampede from every concurrent
+	// request at once.
+	key := cache.QRCodeKey(id.String(), "png", size, opts.Fingerprint())
+	qrData, err := cache.GetOrCompute(ctx, h.cache, key, h.cfg.QRCacheTTL, func() ([]byte, error) {
+		shortURL := h.cfg.BaseURL + "/" + url.ShortCode
+		return h.qrService.GeneratePNG(shortURL, size, opts)
+	})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to generate QR code",
 		})
 	}
 
-	// Cache the QR code
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.SetQRCode(bgCtx, id.String(), "png", size, qrData)
-	}()
-
 	c.Set("Content-Type", "image/png")
 	c.Set("Cache-Control", "public, max-age=86400")
 	return c.Send(qrData)
@@ -123,6 +148,10 @@ func (h *QRHandler) GetQRSVG(c *fiber.Ctx) error {
 
 	// Get size from query param (default 256)
 	size, _ := strconv.Atoi(c.Query("size", "256"))
+	opts, err := qrOptionsFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
 
 	// Get user
 	user, err := h.getUser(c)
@@ -149,31 +178,130 @@ func (h *QRHandler) GetQRSVG(c *fiber.Ctx) error {
 		})
 	}
 
-	// Check cache first
-	cached, err := h.cache.GetQRCode(ctx, id.String(), "svg", size)
-	if err == nil {
-		c.Set("Content-Type", "image/svg+xml")
-		c.Set("Cache-Control", "public, max-age=86400")
-		return c.Send(cached)
+	// Get-or-compute through a distributed lock so a popular QR code
+	// expiring doesn't trigger a render stampede from every concurrent
+	// request at once.
+	key := cache.QRCodeKey(id.String(), "svg", size, opts.Fingerprint())
+	qrData, err := cache.GetOrCompute(ctx, h.cache, key, h.cfg.QRCacheTTL, func() (string, error) {
+		shortURL := h.cfg.BaseURL + "/" + url.ShortCode
+		return h.qrService.GenerateSVG(shortURL, size, opts)
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to generate QR code",
+		})
+	}
+
+	c.Set("Content-Type", "image/svg+xml")
+	c.Set("Cache-Control", "public, max-age=86400")
+	return c.SendString(qrData)
+}
+
+// GetQRPDF handles GET /v1/urls/:id/qr.pdf - generates a printable QR code PDF
+func (h *QRHandler) GetQRPDF(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid URL ID",
+		})
+	}
+
+	size, _ := strconv.Atoi(c.Query("size", "256"))
+	opts, err := qrOptionsFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	ctx := c.Context()
+
+	url, err := h.db.GetURLByID(ctx, id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	if url.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
 	}
 
-	// Generate QR code
-	shortURL := h.cfg.BaseURL + "/" + url.ShortCode
-	qrData, err := h.qrService.GenerateSVG(shortURL, size)
+	key := cache.QRCodeKey(id.String(), "pdf", size, opts.Fingerprint())
+	qrData, err := cache.GetOrCompute(ctx, h.cache, key, h.cfg.QRCacheTTL, func() ([]byte, error) {
+		shortURL := h.cfg.BaseURL + "/" + url.ShortCode
+		return h.qrService.GeneratePDF(shortURL, size, opts)
+	})
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to generate QR code",
 		})
 	}
 
-	// Cache the QR code
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.SetQRCode(bgCtx, id.String(), "svg", size, []byte(qrData))
-	}()
+	c.Set("Content-Type", "application/pdf")
+	c.Set("Cache-Control", "public, max-age=86400")
+	return c.Send(qrData)
+}
 
-	c.Set("Content-Type", "image/svg+xml")
+// GetQREPS handles GET /v1/urls/:id/qr.eps - generates a vector QR code
+// as Encapsulated PostScript for print workflows
+func (h *QRHandler) GetQREPS(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid URL ID",
+		})
+	}
+
+	size, _ := strconv.Atoi(c.Query("size", "256"))
+	opts, err := qrOptionsFromQuery(c)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	ctx := c.Context()
+
+	url, err := h.db.GetURLByID(ctx, id)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	if url.UserID != user.ID {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "URL not found",
+		})
+	}
+
+	key := cache.QRCodeKey(id.String(), "eps", size, opts.Fingerprint())
+	qrData, err := cache.GetOrCompute(ctx, h.cache, key, h.cfg.QRCacheTTL, func() (string, error) {
+		shortURL := h.cfg.BaseURL + "/" + url.ShortCode
+		return h.qrService.GenerateEPS(shortURL, size, opts)
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to generate QR code",
+		})
+	}
+
+	c.Set("Content-Type", "application/postscript")
 	c.Set("Cache-Control", "public, max-age=86400")
 	return c.SendString(qrData)
 }
@@ -5,27 +5,55 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/enrich"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/eventpool"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/limits"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
 )
 
 // RedirectHandler handles the hot path redirect endpoint
 type RedirectHandler struct {
-	cache *cache.RedisCache
-	db    *db.DB
-	cfg   *config.Config
+	cache  *cache.RedisCache
+	db     *db.DB
+	cfg    *config.Config
+	limits *limits.Overrides
+
+	// enricher resolves a click's geo location from its raw IP. This has
+	// to happen here, not in worker.Flusher, because recordClickEvent
+	// hashes the IP away before the click ever reaches Redis Stream - see
+	// the internal/enrich package doc.
+	enricher *enrich.Enricher
+
+	// misses collapses concurrent cache-miss DB lookups for the same
+	// short code into a single query, so a spike of misses on a hot code
+	// (e.g. right after it expires from cache) doesn't stampede Postgres.
+	misses singleflight.Group
+
+	// events runs cache write-through and click-event recording on a
+	// bounded pool instead of an unbounded goroutine per request.
+	events *eventpool.Pool
 }
 
 // NewRedirectHandler creates a new redirect handler
-func NewRedirectHandler(cache *cache.RedisCache, database *db.DB, cfg *config.Config) *RedirectHandler {
+func NewRedirectHandler(cache *cache.RedisCache, database *db.DB, cfg *config.Config, tenantLimits *limits.Overrides, enricher *enrich.Enricher) *RedirectHandler {
 	return &RedirectHandler{
-		cache: cache,
-		db:    database,
-		cfg:   cfg,
+		cache:    cache,
+		db:       database,
+		cfg:      cfg,
+		limits:   tenantLimits,
+		enricher: enricher,
+		events:   eventpool.New(cfg.ClickEventWorkers, cfg.ClickEventQueueSize),
 	}
 }
 
@@ -39,62 +67,190 @@ func (h *RedirectHandler) HandleRedirect(c *fiber.Ctx) error {
 		})
 	}
 
-	ctx := c.Context()
+	// Bound the whole request, including the DB fallback on a cache miss,
+	// so a slow Postgres doesn't pile up goroutines waiting on it.
+	ctx, cancel := context.WithTimeout(c.UserContext(), h.cfg.RedirectDeadline)
+	defer cancel()
+
+	requestID := GetRequestID(c)
 
 	// Extract metadata synchronously to avoid race conditions with Fiber context
 	userAgent := c.Get("User-Agent")
 	referrer := c.Get("Referer")
 	ip := c.IP()
 
+	// Resolve which domain namespace this request's Host belongs to, so
+	// "bit.acme.co/xyz" and "go.foo.com/xyz" can coexist as distinct URL
+	// rows sharing a short code - see GetURLByShortCodeForDomain.
+	customDomainID, err := h.resolveCustomDomain(ctx, c.Hostname())
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "unknown host",
+		})
+	}
+	cacheKey := domainScopedCacheKey(shortCode, customDomainID)
+
 	// FAST PATH: Try cache first (~1ms)
-	destinationURL, err := h.cache.GetURL(ctx, shortCode)
+	destinationURL, err := h.cache.GetURL(ctx, cacheKey)
 	if err == nil {
+		metrics.CacheHits.Inc()
+		// Best-effort tenant resolution for the owner's per-tenant
+		// redirect budget; an unresolved owner just falls back to the
+		// service defaults rather than blocking the redirect.
+		ownerSub, _ := h.cache.GetURLOwner(ctx, cacheKey)
+		if err := h.checkRedirectBudget(c, ctx, ownerSub); err != nil {
+			return err
+		}
+
 		// Cache hit - fast path success!
-		// Record click event asynchronously (non-blocking)
-		go h.recordClickEvent(shortCode, userAgent, referrer, ip)
+		h.enqueueClickEvent(requestID, shortCode, userAgent, referrer, ip)
 
 		// Redirect immediately
 		return c.Redirect(destinationURL, 302)
 	}
 
-	// SLOW PATH: Cache miss, fallback to database (~15ms)
-	url, err := h.db.GetURLByShortCode(ctx, shortCode)
+	// SLOW PATH: Cache miss, fallback to database (~15ms). A burst of
+	// misses on the same code collapses into one query via h.misses.
+	metrics.CacheMisses.Inc()
+	result, err, _ := h.misses.Do(cacheKey, func() (interface{}, error) {
+		if customDomainID != nil {
+			return h.db.GetURLByShortCodeForDomain(ctx, shortCode, *customDomainID)
+		}
+		return h.db.GetURLByShortCode(ctx, shortCode)
+	})
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "URL not found or expired",
 		})
 	}
+	url := result.(*db.URL)
+
+	owner, err := h.db.GetUserByID(ctx, url.UserID)
+	ownerSub := ""
+	if err == nil {
+		ownerSub = owner.Auth0Sub
+	}
+	if err := h.checkRedirectBudget(c, ctx, ownerSub); err != nil {
+		return err
+	}
 
 	// Write-through cache for future requests
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.SetURL(bgCtx, shortCode, url.DestinationURL)
-	}()
+	h.enqueueCacheWriteThrough(cacheKey, url.DestinationURL, ownerSub)
 
-	// Record click event asynchronously (non-blocking)
-	go h.recordClickEvent(shortCode, userAgent, referrer, ip)
+	h.enqueueClickEvent(requestID, shortCode, userAgent, referrer, ip)
 
 	// Redirect
 	return c.Redirect(url.DestinationURL, 302)
 }
 
+// resolveCustomDomain maps an incoming request's Host to the
+// CustomDomain namespace its short codes are scoped under: nil for the
+// default BaseURL host (the common case, resolved without a DB call),
+// or a verified CustomDomain's ID. An unrecognized, non-default host -
+// one that's neither the configured BaseURL nor a verified custom
+// domain - is rejected before ever reaching the short-code lookup.
+func (h *RedirectHandler) resolveCustomDomain(ctx context.Context, host string) (*uuid.UUID, error) {
+	baseHost := h.cfg.BaseURL
+	if parsed, err := url.Parse(h.cfg.BaseURL); err == nil && parsed.Host != "" {
+		baseHost = parsed.Hostname()
+	}
+	if host == "" || host == baseHost {
+		return nil, nil
+	}
+
+	domain, err := h.db.GetCustomDomainByName(ctx, host)
+	if err != nil || !domain.Verified {
+		return nil, fmt.Errorf("host %s is not a recognized domain", host)
+	}
+	return &domain.ID, nil
+}
+
+// enqueueCacheWriteThrough schedules a cache write-through on the bounded
+// event pool, dropping it (and counting the drop) if the pool is full
+// rather than blocking the redirect or leaking a goroutine.
+func (h *RedirectHandler) enqueueCacheWriteThrough(shortCode, destinationURL, ownerSub string) {
+	submitted := h.events.Submit(func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		h.cache.SetURL(bgCtx, shortCode, destinationURL)
+		if ownerSub != "" {
+			h.cache.SetURLOwner(bgCtx, shortCode, ownerSub)
+		}
+	})
+	if !submitted {
+		metrics.DroppedEvents.WithLabelValues("cache_write_through").Inc()
+	}
+}
+
+// enqueueClickEvent schedules click-event recording on the bounded event
+// pool, dropping it (and counting the drop) if the pool is full.
+func (h *RedirectHandler) enqueueClickEvent(requestID, shortCode, userAgent, referrer, ip string) {
+	submitted := h.events.Submit(func() {
+		h.recordClickEvent(requestID, shortCode, userAgent, referrer, ip)
+	})
+	if !submitted {
+		metrics.DroppedEvents.WithLabelValues("click_event").Inc()
+	}
+}
+
+// checkRedirectBudget enforces ownerSub's per-tenant redirect budget, if
+// it has one lower than unlimited. An unresolved owner (empty ownerSub)
+// or a tenant without an override both fall back to the service default,
+// which is already enforced by the redirectLimiter IP-based middleware,
+// so this only needs to act when a tenant override actually exists.
+func (h *RedirectHandler) checkRedirectBudget(c *fiber.Ctx, ctx context.Context, ownerSub string) error {
+	if ownerSub == "" {
+		return nil
+	}
+
+	tenantLimits := h.limits.For(ownerSub)
+	if tenantLimits.MaxRedirectsPerSec <= 0 {
+		return nil
+	}
+
+	allowed, _, retryAfter, err := h.cache.CheckRateLimit(ctx, "limits:redirect:"+ownerSub, tenantLimits.MaxRedirectsPerSec, time.Second)
+	if err != nil || allowed {
+		return nil
+	}
+
+	metrics.LimitRejections.WithLabelValues("redirect_budget", ownerSub).Inc()
+	c.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error": "redirect rate limit exceeded for this account",
+	})
+}
+
 // recordClickEvent records a click event to Redis Stream for async processing
 // This function runs in a goroutine and should not block the redirect
-func (h *RedirectHandler) recordClickEvent(shortCode, userAgent, referrer, ip string) {
+func (h *RedirectHandler) recordClickEvent(requestID, shortCode, userAgent, referrer, ip string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	// Resolve geo location from the raw IP before it's hashed away below -
+	// this is the only place in the pipeline that still has it.
+	geo := h.enricher.LookupGeo(ip)
+
 	// Hash IP for privacy (GDPR-compliant)
 	ipHash := hashIP(ip, h.cfg.IPHashSalt)
 
-	// Create click event
+	// Best-effort HyperLogLog write for the active-visitors/active-codes
+	// gauges. Lives in this same background goroutine so it never adds
+	// latency to the redirect itself.
+	h.cache.RecordVisitor(ctx, shortCode, ipHash)
+
+	// Create click event, stamped with the request ID so downstream
+	// analytics can join a click back to the request that produced it
 	event := map[string]interface{}{
+		"request_id": requestID,
 		"short_code": shortCode,
 		"ip_hash":    ipHash,
 		"user_agent": userAgent,
 		"referrer":   referrer,
 		"timestamp":  time.Now().Unix(),
+		"country":    geo.Country,
+		"city":       geo.City,
+		"latitude":   geo.Latitude,
+		"longitude":  geo.Longitude,
 	}
 
 	// Convert to JSON for storage
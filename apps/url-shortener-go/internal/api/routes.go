@@ -1,11 +1,21 @@
 package api
 
 import (
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/adaptive"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/auth"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/enrich"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/limits"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/promquery"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/ratelimit"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/realtime"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/safety"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/services"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/ssrf"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -15,29 +25,54 @@ type Handlers struct {
 	URL       *URLHandler
 	QR        *QRHandler
 	Analytics *AnalyticsHandler
+	Admin     *AdminHandler
+	Domain    *DomainHandler
+	Tag       *TagHandler
 }
 
-// NewHandlers creates all handlers with dependencies
+// NewHandlers creates all handlers with dependencies. prom may be nil if
+// cfg.PrometheusURL is unset, in which case /v1/admin/alerts reports
+// itself as disabled.
 func NewHandlers(
 	redisCache *cache.RedisCache,
 	database *db.DB,
 	cfg *config.Config,
+	prom *promquery.Client,
+	tenantLimits *limits.Overrides,
 ) *Handlers {
 	// Create services
 	shortener := services.NewShortenerService(redisCache, database, cfg)
-	validator := services.NewValidatorService()
+	ssrfGuard := ssrf.NewGuard(cfg.AllowPrivateTargets, cfg.SSRFResolveCacheTTL)
+	validator := services.NewValidatorService(ssrfGuard)
 	qrService := services.NewQRService()
+	safetyChecker := safety.NewChecker(safety.Config{
+		Enabled:      cfg.SafetyCheckEnabled,
+		FetchTimeout: cfg.SafetyCheckTimeout,
+		MaxBodyBytes: cfg.SafetyCheckMaxBodyBytes,
+	}, ssrfGuard)
+	hub := realtime.NewHub(redisCache)
+	enricher := enrich.New(cfg.GeoIPDBPath, cfg.UAParserRegexesPath, cfg.GeoIPReloadInterval)
 
 	return &Handlers{
-		Redirect:  NewRedirectHandler(redisCache, database, cfg),
-		URL:       NewURLHandler(redisCache, database, cfg, shortener, validator),
+		Redirect:  NewRedirectHandler(redisCache, database, cfg, tenantLimits, enricher),
+		URL:       NewURLHandler(redisCache, database, cfg, shortener, validator, tenantLimits, safetyChecker),
 		QR:        NewQRHandler(redisCache, database, cfg, qrService),
-		Analytics: NewAnalyticsHandler(database, cfg),
+		Analytics: NewAnalyticsHandler(redisCache, database, cfg, hub),
+		Admin:     NewAdminHandler(redisCache, database, cfg, prom, database.Auditor),
+		Domain:    NewDomainHandler(database),
+		Tag:       NewTagHandler(database),
 	}
 }
 
-// RegisterRoutes registers all API routes
-func RegisterRoutes(app *fiber.App, handlers *Handlers, cfg *config.Config) {
+// RegisterRoutes registers all API routes. limiter supplies the live
+// redirect-per-second budget for the hot path; pass adaptive.New(nil, cfg)
+// to keep it pinned at cfg.RateLimitRedirectPerSecond.
+func RegisterRoutes(app *fiber.App, handlers *Handlers, redisCache *cache.RedisCache, cfg *config.Config, limiter *adaptive.Limiter) {
+	// Stamp every request with a request ID before anything else runs, so
+	// it's available to every handler and to the async click-event pipeline.
+	app.Use(RequestIDMiddleware())
+	app.Use(MetricsMiddleware())
+
 	// Public routes (no auth)
 	// Root endpoint
 	app.Get("/", func(c *fiber.Ctx) error {
@@ -48,35 +83,85 @@ func RegisterRoutes(app *fiber.App, handlers *Handlers, cfg *config.Config) {
 		})
 	})
 
-	// API v1 routes (protected with Auth0)
+	// GitHub OAuth login flow, if configured
+	if cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" {
+		githubProvider := auth.NewGitHubProvider(cfg)
+		app.Get("/auth/github/login", githubProvider.LoginHandler)
+		app.Get("/auth/github/callback", githubProvider.CallbackHandler)
+	}
+
+	// API v1 routes (protected by whichever identity providers are configured)
 	v1 := app.Group("/v1")
-	
-	// Apply Auth0 middleware only if Auth0 is configured
-	if cfg.Auth0Domain != "" && cfg.Auth0Audience != "" {
-		v1.Use(auth.Middleware(cfg))
+
+	authConfigured := (cfg.Auth0Domain != "" && cfg.Auth0Audience != "") ||
+		cfg.OIDCDiscoveryURL != "" ||
+		(cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "") ||
+		cfg.AuthBackend == "apikey" || cfg.AuthBackend == "forward"
+	if authConfigured {
+		v1.Use(auth.Middleware(cfg, redisCache))
 	}
 
+	createLimiter := ratelimit.Middleware(redisCache, ratelimit.Config{
+		Limit:   cfg.RateLimitCreatePerMinute,
+		Window:  time.Minute,
+		KeyFunc: ratelimit.ByIP("create"),
+	})
+
 	// URL management endpoints
-	v1.Post("/urls", handlers.URL.CreateURL)
+	v1.Post("/urls", createLimiter, handlers.URL.CreateURL)
 	v1.Get("/urls", handlers.URL.ListURLs)
 	v1.Get("/urls/check/:code", handlers.URL.CheckCode)
+	v1.Post("/urls/import", createLimiter, handlers.URL.ImportURLs)
+	v1.Get("/urls/export", handlers.URL.ExportURLs)
 	v1.Get("/urls/:id", handlers.URL.GetURL)
 	v1.Patch("/urls/:id", handlers.URL.UpdateURL)
 	v1.Delete("/urls/:id", handlers.URL.DeleteURL)
 
+	// Custom domain endpoints
+	v1.Post("/domains", handlers.Domain.CreateDomain)
+	v1.Get("/domains", handlers.Domain.ListDomains)
+	v1.Post("/domains/:id/verify", handlers.Domain.VerifyDomain)
+	v1.Delete("/domains/:id", handlers.Domain.DeleteDomain)
+
+	// Tag endpoints
+	v1.Post("/tags", handlers.Tag.CreateTag)
+	v1.Get("/tags", handlers.Tag.ListTags)
+	v1.Delete("/tags/:id", handlers.Tag.DeleteTag)
+
 	// QR code endpoints
 	v1.Get("/urls/:id/qr", handlers.QR.GetQRPNG)
 	v1.Get("/urls/:id/qr.svg", handlers.QR.GetQRSVG)
+	v1.Get("/urls/:id/qr.pdf", handlers.QR.GetQRPDF)
+	v1.Get("/urls/:id/qr.eps", handlers.QR.GetQREPS)
 
 	// Analytics endpoints
 	v1.Get("/urls/:id/analytics", handlers.Analytics.GetAnalytics)
 	v1.Get("/urls/:id/analytics/clicks", handlers.Analytics.GetClicksOverTime)
 	v1.Get("/urls/:id/analytics/geo", handlers.Analytics.GetGeoBreakdown)
 	v1.Get("/urls/:id/analytics/devices", handlers.Analytics.GetDeviceBreakdown)
+	v1.Get("/urls/:id/clicks/stream", handlers.Analytics.StreamClicks)
+	v1.Get("/urls/:id/analytics/live", handlers.Analytics.GetLiveAnalytics)
+	v1.Post("/urls/:id/analytics/export", handlers.Analytics.CreateExport)
+	v1.Get("/exports/:job_id", handlers.Analytics.GetExport)
 	v1.Get("/dashboard", handlers.Analytics.GetDashboard)
 
+	// Admin endpoints - requires the "admin" scope claim in addition to a
+	// valid identity.
+	admin := v1.Group("/admin", auth.RequireAdminScope())
+	admin.Post("/tokens/purge", handlers.Admin.PurgeTokens)
+	admin.Get("/alerts", handlers.Admin.GetAlerts)
+	admin.Get("/audit", handlers.Admin.GetAuditLog)
+	admin.Get("/urls/:id/safety", handlers.Admin.GetSafetyChecks)
+	admin.Post("/urls/:id/safety/override", handlers.Admin.OverrideSafety)
+
+	redirectLimiter := ratelimit.Middleware(redisCache, ratelimit.Config{
+		LimitFunc: limiter.Limit,
+		Window:    time.Second,
+		KeyFunc:   ratelimit.ByIP("redirect"),
+	})
+
 	// HOT PATH: Redirect handler - must be registered LAST
 	// This catches all unmatched GET /{code} requests
-	app.Get("/:code", handlers.Redirect.HandleRedirect)
+	app.Get("/:code", redirectLimiter, handlers.Redirect.HandleRedirect)
 }
 
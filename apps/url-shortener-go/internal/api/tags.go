@@ -0,0 +1,168 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/auth"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TagHandler handles tag CRUD, used to label and group a user's URLs -
+// see URLHandler.CreateURL/UpdateURL's Tags field and ListURLs' tags
+// filter.
+type TagHandler struct {
+	db *db.DB
+}
+
+// NewTagHandler creates a new tag handler.
+func NewTagHandler(database *db.DB) *TagHandler {
+	return &TagHandler{db: database}
+}
+
+// getUser retrieves or creates the user from the authenticated identity
+func (h *TagHandler) getUser(c *fiber.Ctx) (*db.User, error) {
+	subject := auth.GetSubject(c)
+	email := auth.GetEmail(c)
+	name := auth.GetName(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return h.db.GetOrCreateUser(ctx, subject, email, name)
+}
+
+// TagResponse represents a tag in API responses
+type TagResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toTagResponse(t *db.Tag) *TagResponse {
+	return &TagResponse{
+		ID:        t.ID.String(),
+		Name:      t.Name,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+// tagNames extracts Name from a slice of tags, for embedding in
+// URLResponse.
+func tagNames(tags []*db.Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// CreateTagRequest represents the request body for creating a tag
+type CreateTagRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateTag handles POST /v1/tags - creates (or returns the existing)
+// tag named req.Name for the caller.
+func (h *TagHandler) CreateTag(c *fiber.Ctx) error {
+	var req CreateTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid request body",
+		})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	tag, err := h.db.GetOrCreateTag(c.Context(), user.ID, name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to create tag",
+		})
+	}
+
+	return c.Status(201).JSON(toTagResponse(tag))
+}
+
+// ListTags handles GET /v1/tags - lists every tag the caller has created
+func (h *TagHandler) ListTags(c *fiber.Ctx) error {
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	tags, err := h.db.ListTagsByUser(c.Context(), user.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to list tags",
+		})
+	}
+
+	response := make([]*TagResponse, len(tags))
+	for i, t := range tags {
+		response[i] = toTagResponse(t)
+	}
+	return c.JSON(fiber.Map{"tags": response})
+}
+
+// DeleteTag handles DELETE /v1/tags/:id - removes a tag and detaches it
+// from every URL it was attached to.
+func (h *TagHandler) DeleteTag(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "invalid tag ID",
+		})
+	}
+
+	user, err := h.getUser(c)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	if err := h.db.DeleteTag(c.Context(), user.ID, id); err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "tag not found",
+		})
+	}
+
+	return c.SendStatus(204)
+}
+
+// resolveTagIDs resolves a set of tag names to IDs, creating any that
+// don't exist yet for userID - see CreateTagRequest and
+// URLHandler.CreateURL/UpdateURL's Tags field.
+func (h *URLHandler) resolveTagIDs(ctx context.Context, userID uuid.UUID, names []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		tag, err := h.db.GetOrCreateTag(ctx, userID, name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, tag.ID)
+	}
+	return ids, nil
+}
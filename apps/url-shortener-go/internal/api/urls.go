@@ -2,13 +2,22 @@ package api
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/auth"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/bg"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/limits"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/safety"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/services"
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
@@ -16,30 +25,55 @@ import (
 
 // URLHandler handles URL CRUD operations
 type URLHandler struct {
-	cache     *cache.RedisCache
-	db        *db.DB
-	cfg       *config.Config
-	shortener *services.ShortenerService
-	validator *services.ValidatorService
+	cache         *cache.RedisCache
+	db            *db.DB
+	cfg           *config.Config
+	shortener     *services.ShortenerService
+	validator     *services.ValidatorService
+	limits        *limits.Overrides
+	safetyChecker *safety.Checker
+
+	// bgTasks runs the cache warm/invalidate work that follows
+	// CreateURL/UpdateURL/DeleteURL, with a deadline and per-user
+	// cancellation instead of a bare detached goroutine - see internal/bg.
+	bgTasks *bg.Scheduler
 }
 
 // NewURLHandler creates a new URL handler
-func NewURLHandler(cache *cache.RedisCache, database *db.DB, cfg *config.Config, shortener *services.ShortenerService, validator *services.ValidatorService) *URLHandler {
+func NewURLHandler(cache *cache.RedisCache, database *db.DB, cfg *config.Config, shortener *services.ShortenerService, validator *services.ValidatorService, tenantLimits *limits.Overrides, safetyChecker *safety.Checker) *URLHandler {
 	return &URLHandler{
-		cache:     cache,
-		db:        database,
-		cfg:       cfg,
-		shortener: shortener,
-		validator: validator,
+		cache:         cache,
+		db:            database,
+		cfg:           cfg,
+		shortener:     shortener,
+		validator:     validator,
+		limits:        tenantLimits,
+		safetyChecker: safetyChecker,
+		bgTasks:       bg.New(cfg.URLBgWorkers, cfg.URLBgQueueSize),
 	}
 }
 
+// Shutdown cancels any of this handler's still-pending cache
+// warm/invalidate tasks, so they don't keep running past server shutdown.
+func (h *URLHandler) Shutdown() {
+	h.bgTasks.Shutdown()
+}
+
 // CreateURLRequest represents the request body for creating a URL
 type CreateURLRequest struct {
 	DestinationURL string `json:"destination_url"`
 	CustomCode     string `json:"custom_code,omitempty"`
 	Notes          string `json:"notes,omitempty"`
 	ExpiresIn      *int   `json:"expires_in,omitempty"` // seconds
+	Reason         string `json:"reason,omitempty"`     // recorded in the audit log
+
+	// DomainID, if set, creates the short code under that verified
+	// CustomDomain (see /v1/domains) instead of the default BaseURL host.
+	DomainID string `json:"domain_id,omitempty"`
+
+	// Tags names this URL should be labeled with, creating any that don't
+	// already exist for the caller (see POST /v1/tags).
+	Tags []string `json:"tags,omitempty"`
 }
 
 // URLResponse represents a URL in API responses
@@ -53,10 +87,17 @@ type URLResponse struct {
 	ExpiresAt      *time.Time `json:"expires_at,omitempty"`
 	CreatedAt      time.Time  `json:"created_at"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+	Tags           []string   `json:"tags,omitempty"`
+
+	// Links is only populated for an Accept: application/hal+json
+	// request - see isHALRequest and respondURL.
+	Links map[string]HALLink `json:"_links,omitempty"`
 }
 
-// toResponse converts db.URL to URLResponse
-func (h *URLHandler) toResponse(url *db.URL) *URLResponse {
+// toResponseWithTags converts db.URL to URLResponse, embedding tags
+// already resolved by the caller (e.g. via db.ListTagsForURLs, to avoid
+// an N+1 query per row in ListURLs).
+func (h *URLHandler) toResponseWithTags(url *db.URL, tags []*db.Tag) *URLResponse {
 	return &URLResponse{
 		ID:             url.ID.String(),
 		ShortCode:      url.ShortCode,
@@ -67,19 +108,39 @@ func (h *URLHandler) toResponse(url *db.URL) *URLResponse {
 		ExpiresAt:      url.ExpiresAt,
 		CreatedAt:      url.CreatedAt,
 		UpdatedAt:      url.UpdatedAt,
+		Tags:           tagNames(tags),
+	}
+}
+
+// toResponse converts a single db.URL to URLResponse, resolving its tags
+// with their own query. Use toResponseWithTags directly when rendering
+// many URLs at once.
+func (h *URLHandler) toResponse(ctx context.Context, url *db.URL) *URLResponse {
+	tags, _ := h.db.ListTagsForURL(ctx, url.ID)
+	return h.toResponseWithTags(url, tags)
+}
+
+// respondURL renders url as a single-resource JSON response, adding HAL
+// hypermedia links (see isHALRequest) when the client asked for them.
+func (h *URLHandler) respondURL(c *fiber.Ctx, ctx context.Context, status int, url *db.URL) error {
+	resp := h.toResponse(ctx, url)
+	if isHALRequest(c) {
+		resp.Links = urlHALLinks(resp.ID)
+		c.Set("Content-Type", halMediaType)
 	}
+	return c.Status(status).JSON(resp)
 }
 
-// getUser retrieves or creates the user from Auth0 claims
+// getUser retrieves or creates the user from the authenticated identity
 func (h *URLHandler) getUser(c *fiber.Ctx) (*db.User, error) {
-	auth0Sub := auth.GetAuth0Sub(c)
+	subject := auth.GetSubject(c)
 	email := auth.GetEmail(c)
 	name := auth.GetName(c)
-	
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-	
-	return h.db.GetOrCreateUser(ctx, auth0Sub, email, name)
+
+	return h.db.GetOrCreateUser(ctx, subject, email, name)
 }
 
 // CreateURL handles POST /v1/urls - creates a new short URL
@@ -91,16 +152,16 @@ func (h *URLHandler) CreateURL(c *fiber.Ctx) error {
 		})
 	}
 
+	ctx := c.Context()
+
 	// Validate destination URL
-	sanitizedURL, err := h.validator.ValidateAndSanitizeURL(req.DestinationURL)
+	sanitizedURL, err := h.validator.ValidateAndSanitizeURL(ctx, req.DestinationURL)
 	if err != nil {
 		return c.Status(400).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	ctx := c.Context()
-
 	// Get user
 	user, err := h.getUser(c)
 	if err != nil {
@@ -109,11 +170,91 @@ func (h *URLHandler) CreateURL(c *fiber.Ctx) error {
 		})
 	}
 
+	// Idempotency-Key support: a retried request with the same key and
+	// body gets the original response back without creating a second URL;
+	// the same key with a different body is rejected outright.
+	idempotencyKey := c.Get(idempotencyHeader)
+	var requestHash string
+	if idempotencyKey != "" {
+		requestHash = hashRequestBody(c.Body())
+		rec, err := checkIdempotency(ctx, h.cache, user.Auth0Sub, idempotencyKey, requestHash)
+		if err != nil {
+			return idempotencyErrorResponse(c, err)
+		}
+		if rec != nil {
+			return replayIdempotentResponse(c, rec)
+		}
+	}
+
+	tenantLimits := h.limits.For(user.Auth0Sub)
+
+	if parsed, err := url.Parse(sanitizedURL); err == nil && !tenantLimits.AllowsHost(parsed.Hostname()) {
+		metrics.LimitRejections.WithLabelValues("host_denied", user.Auth0Sub).Inc()
+		return c.Status(403).JSON(fiber.Map{
+			"error": "destination host is not allowed for this account",
+		})
+	}
+
+	if tenantLimits.MaxURLsPerUser > 0 {
+		count, err := h.db.CountUserURLs(ctx, user.ID, nil)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to check URL quota",
+			})
+		}
+		if count >= tenantLimits.MaxURLsPerUser {
+			metrics.LimitRejections.WithLabelValues("max_urls", user.Auth0Sub).Inc()
+			return c.Status(429).JSON(fiber.Map{
+				"error": "maximum number of URLs for this account has been reached",
+			})
+		}
+	}
+
+	if req.ExpiresIn != nil && tenantLimits.MaxTTLDays > 0 {
+		maxSeconds := tenantLimits.MaxTTLDays * 86400
+		if *req.ExpiresIn > maxSeconds {
+			metrics.LimitRejections.WithLabelValues("ttl_days", user.Auth0Sub).Inc()
+			return c.Status(400).JSON(fiber.Map{
+				"error": "expires_in exceeds the maximum TTL allowed for this account",
+			})
+		}
+	}
+
+	if req.CustomCode != "" && tenantLimits.MaxShortCodeLength > 0 && len(req.CustomCode) > tenantLimits.MaxShortCodeLength {
+		metrics.LimitRejections.WithLabelValues("code_length", user.Auth0Sub).Inc()
+		return c.Status(400).JSON(fiber.Map{
+			"error": "custom_code exceeds the maximum length allowed for this account",
+		})
+	}
+
+	// Resolve the optional custom domain the code should be scoped to.
+	var customDomainID *uuid.UUID
+	if req.DomainID != "" {
+		domainID, err := uuid.Parse(req.DomainID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid domain_id",
+			})
+		}
+		domain, err := h.db.GetCustomDomainByID(ctx, domainID)
+		if err != nil || domain.UserID != user.ID {
+			return c.Status(404).JSON(fiber.Map{
+				"error": "domain not found",
+			})
+		}
+		if !domain.Verified {
+			return c.Status(422).JSON(fiber.Map{
+				"error": "domain has not completed DNS ownership verification",
+			})
+		}
+		customDomainID = &domain.ID
+	}
+
 	// Generate or validate short code
 	var shortCode string
 	if req.CustomCode != "" {
 		// Validate custom code
-		if err := h.shortener.ValidateCustomCode(ctx, req.CustomCode); err != nil {
+		if err := h.shortener.ValidateCustomCode(ctx, req.CustomCode, customDomainID); err != nil {
 			return c.Status(400).JSON(fiber.Map{
 				"error": err.Error(),
 			})
@@ -121,7 +262,7 @@ func (h *URLHandler) CreateURL(c *fiber.Ctx) error {
 		shortCode = req.CustomCode
 	} else {
 		// Generate short code
-		shortCode, err = h.shortener.GenerateCode(ctx)
+		shortCode, err = h.shortener.GenerateCode(ctx, customDomainID)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
 				"error": "failed to generate short code",
@@ -135,21 +276,61 @@ func (h *URLHandler) CreateURL(c *fiber.Ctx) error {
 		notes = &req.Notes
 	}
 
-	url, err := h.db.CreateURL(ctx, user.ID, shortCode, sanitizedURL, req.ExpiresIn, notes)
+	actor := audit.Actor{
+		UserID:    user.ID,
+		RequestID: GetRequestID(c),
+		RemoteIP:  c.IP(),
+		Reason:    req.Reason,
+	}
+
+	safetyMode := safety.ParseMode(user.SafetyMode)
+	var safetyResult *safety.Result
+	if safetyMode != safety.ModeOff {
+		safetyResult, err = h.safetyChecker.Check(ctx, sanitizedURL)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to classify destination content",
+			})
+		}
+	}
+
+	tagIDs, err := h.resolveTagIDs(ctx, user.ID, req.Tags)
 	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to resolve tags",
+		})
+	}
+
+	url, err := h.db.CreateURL(ctx, actor, shortCode, sanitizedURL, req.ExpiresIn, notes, safetyResult, safetyMode, customDomainID, tagIDs)
+	if err != nil {
+		var flagged *db.ErrDestinationFlagged
+		if errors.As(err, &flagged) {
+			return c.Status(422).JSON(fiber.Map{
+				"error":      "destination content was flagged and blocked by this account's safety settings",
+				"categories": flagged.Categories,
+				"score":      flagged.Score,
+			})
+		}
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to create URL",
 		})
 	}
 
 	// Pre-cache the URL for fast redirects
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.SetURL(bgCtx, shortCode, sanitizedURL)
-	}()
+	cacheKey := domainScopedCacheKey(shortCode, customDomainID)
+	h.bgTasks.Submit(user.Auth0Sub, 2*time.Second, func(bgCtx context.Context) {
+		h.cache.SetURL(bgCtx, cacheKey, sanitizedURL)
+		h.cache.SetURLOwner(bgCtx, cacheKey, user.Auth0Sub)
+		h.cache.SetUserURLsLastEdit(bgCtx, user.Auth0Sub, time.Now())
+	})
 
-	return c.Status(201).JSON(h.toResponse(url))
+	if err := h.respondURL(c, ctx, 201, url); err != nil {
+		return err
+	}
+	if idempotencyKey != "" {
+		storeIdempotencyResponse(h.cache, user.Auth0Sub, idempotencyKey, requestHash, c)
+	}
+	return nil
 }
 
 // ListURLs handles GET /v1/urls - lists user's URLs with pagination and filters
@@ -207,6 +388,49 @@ func (h *URLHandler) ListURLs(c *fiber.Ctx) error {
 
 	ctx := c.Context()
 
+	// tags filter - a comma-separated list of tag names, matched by
+	// tag_match ("or", the default, or "and")
+	if tagsParam := c.Query("tags"); tagsParam != "" {
+		names := strings.Split(tagsParam, ",")
+		allTags, err := h.db.ListTagsByUser(ctx, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to resolve tags filter",
+			})
+		}
+		byName := make(map[string]uuid.UUID, len(allTags))
+		for _, t := range allTags {
+			byName[t.Name] = t.ID
+		}
+		for _, name := range names {
+			if id, ok := byName[strings.TrimSpace(name)]; ok {
+				filters.TagIDs = append(filters.TagIDs, id)
+			}
+		}
+		if c.Query("tag_match") == "and" {
+			filters.TagMatch = "and"
+		}
+	}
+
+	// filterSignature captures everything about this request that affects
+	// the response body, so the same filters/page against stale data can't
+	// be mistaken for a hit against fresh data (or vice versa).
+	filterSignature := fmt.Sprintf("%d|%d|%s|%v|%v|%v|%v|%s|%s|%t",
+		limit, offset, sortOrder, filters.IsActive, filters.CreatedAfter, filters.CreatedBefore,
+		filters.TagIDs, filters.TagMatch, c.Query("group_by"), isHALRequest(c))
+
+	// If userSub's URLs haven't changed since Redis last recorded an edit,
+	// the response for this exact filter signature can't have changed
+	// either - short-circuit before ever touching Postgres.
+	if lastEdit, err := h.cache.GetUserURLsLastEdit(ctx, user.Auth0Sub); err == nil {
+		etag := strongETag(filterSignature + "|" + strconv.FormatInt(lastEdit.UnixNano(), 10))
+		if inm := c.Get("If-None-Match"); inm != "" && strings.TrimSpace(inm) == etag {
+			c.Set("ETag", etag)
+			return c.SendStatus(304)
+		}
+		c.Set("ETag", etag)
+	}
+
 	// Get URLs with filters
 	urls, err := h.db.ListUserURLs(ctx, user.ID, limit, offset, filters)
 	if err != nil {
@@ -223,10 +447,71 @@ func (h *URLHandler) ListURLs(c *fiber.Ctx) error {
 		})
 	}
 
+	// No Redis marker to trust (cache miss) - fall back to an ETag hashing
+	// the page's actual content, still useful for the client's *next*
+	// request even though this one couldn't skip the query.
+	if c.GetRespHeader("ETag") == "" {
+		var contentKey strings.Builder
+		contentKey.WriteString(filterSignature)
+		for _, u := range urls {
+			contentKey.WriteString("|")
+			contentKey.WriteString(u.ID.String())
+			contentKey.WriteString(u.UpdatedAt.Format(time.RFC3339Nano))
+		}
+		c.Set("ETag", strongETag(fmt.Sprintf("%s|%d", contentKey.String(), total)))
+	}
+
+	urlIDs := make([]uuid.UUID, len(urls))
+	for i, url := range urls {
+		urlIDs[i] = url.ID
+	}
+	tagsByURL, err := h.db.ListTagsForURLs(ctx, urlIDs)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to resolve URL tags",
+		})
+	}
+
 	// Convert to response
 	response := make([]*URLResponse, len(urls))
 	for i, url := range urls {
-		response[i] = h.toResponse(url)
+		response[i] = h.toResponseWithTags(url, tagsByURL[url.ID])
+	}
+
+	// group_by=tag buckets the page's results by tag name instead of
+	// returning a flat list - a URL with N tags appears in N buckets, and
+	// one with none appears under "untagged".
+	if c.Query("group_by") == "tag" {
+		groups := map[string][]*URLResponse{}
+		for _, r := range response {
+			if len(r.Tags) == 0 {
+				groups["untagged"] = append(groups["untagged"], r)
+				continue
+			}
+			for _, name := range r.Tags {
+				groups[name] = append(groups[name], r)
+			}
+		}
+		return c.JSON(fiber.Map{
+			"groups": groups,
+			"total":  total,
+			"limit":  limit,
+			"offset": offset,
+		})
+	}
+
+	if isHALRequest(c) {
+		c.Set("Content-Type", halMediaType)
+		for _, r := range response {
+			r.Links = urlHALLinks(r.ID)
+		}
+		return c.JSON(fiber.Map{
+			"_embedded": fiber.Map{"urls": response},
+			"_links":    paginationHALLinks("/v1/urls", limit, offset, total),
+			"total":     total,
+			"limit":     limit,
+			"offset":    offset,
+		})
 	}
 
 	return c.JSON(fiber.Map{
@@ -272,7 +557,14 @@ func (h *URLHandler) GetURL(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(h.toResponse(url))
+	etag := strongETag(url.ID.String() + url.UpdatedAt.Format(time.RFC3339Nano))
+	if notModified(c, etag, url.UpdatedAt) {
+		writeConditionalHeaders(c, etag, url.UpdatedAt)
+		return c.SendStatus(304)
+	}
+	writeConditionalHeaders(c, etag, url.UpdatedAt)
+
+	return h.respondURL(c, ctx, 200, url)
 }
 
 // UpdateURLRequest represents the request body for updating a URL
@@ -281,6 +573,12 @@ type UpdateURLRequest struct {
 	Notes          *string `json:"notes,omitempty"`
 	ExpiresIn      *int    `json:"expires_in,omitempty"` // seconds
 	IsActive       *bool   `json:"is_active,omitempty"`
+	Reason         string  `json:"reason,omitempty"` // recorded in the audit log
+
+	// Tags, if non-nil, replaces this URL's tags with exactly the named
+	// set (an empty slice clears all tags), creating any that don't
+	// already exist for the caller.
+	Tags *[]string `json:"tags,omitempty"`
 }
 
 // UpdateURL handles PATCH /v1/urls/:id - updates a URL
@@ -328,7 +626,7 @@ func (h *URLHandler) UpdateURL(c *fiber.Ctx) error {
 	// Validate new destination URL if provided
 	var sanitizedURL *string
 	if req.DestinationURL != nil {
-		validated, err := h.validator.ValidateAndSanitizeURL(*req.DestinationURL)
+		validated, err := h.validator.ValidateAndSanitizeURL(ctx, *req.DestinationURL)
 		if err != nil {
 			return c.Status(400).JSON(fiber.Map{
 				"error": err.Error(),
@@ -338,18 +636,35 @@ func (h *URLHandler) UpdateURL(c *fiber.Ctx) error {
 	}
 
 	// Update URL
-	if err := h.db.UpdateURL(ctx, id, sanitizedURL, req.Notes, req.ExpiresIn, req.IsActive); err != nil {
+	actor := audit.Actor{
+		UserID:    user.ID,
+		RequestID: GetRequestID(c),
+		RemoteIP:  c.IP(),
+		Reason:    req.Reason,
+	}
+	var tagIDs *[]uuid.UUID
+	if req.Tags != nil {
+		ids, err := h.resolveTagIDs(ctx, user.ID, *req.Tags)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"error": "failed to resolve tags",
+			})
+		}
+		tagIDs = &ids
+	}
+
+	if err := h.db.UpdateURL(ctx, actor, id, sanitizedURL, req.Notes, req.ExpiresIn, req.IsActive, tagIDs); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to update URL",
 		})
 	}
 
 	// Invalidate cache
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.DeleteURL(bgCtx, url.ShortCode)
-	}()
+	cacheKey := domainScopedCacheKey(url.ShortCode, url.CustomDomainID)
+	h.bgTasks.Submit(user.Auth0Sub, 2*time.Second, func(bgCtx context.Context) {
+		h.cache.DeleteURL(bgCtx, cacheKey)
+		h.cache.SetUserURLsLastEdit(bgCtx, user.Auth0Sub, time.Now())
+	})
 
 	// Get updated URL
 	updatedURL, err := h.db.GetURLByID(ctx, id)
@@ -359,7 +674,7 @@ func (h *URLHandler) UpdateURL(c *fiber.Ctx) error {
 		})
 	}
 
-	return c.JSON(h.toResponse(updatedURL))
+	return h.respondURL(c, ctx, 200, updatedURL)
 }
 
 // DeleteURL handles DELETE /v1/urls/:id - soft deletes a URL
@@ -398,19 +713,25 @@ func (h *URLHandler) DeleteURL(c *fiber.Ctx) error {
 	}
 
 	// Deactivate URL
-	if err := h.db.DeactivateURL(ctx, id); err != nil {
+	actor := audit.Actor{
+		UserID:    user.ID,
+		RequestID: GetRequestID(c),
+		RemoteIP:  c.IP(),
+		Reason:    c.Query("reason"),
+	}
+	if err := h.db.DeactivateURL(ctx, actor, id); err != nil {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to delete URL",
 		})
 	}
 
 	// Invalidate cache
-	go func() {
-		bgCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		h.cache.DeleteURL(bgCtx, url.ShortCode)
+	cacheKey := domainScopedCacheKey(url.ShortCode, url.CustomDomainID)
+	h.bgTasks.Submit(user.Auth0Sub, 2*time.Second, func(bgCtx context.Context) {
+		h.cache.DeleteURL(bgCtx, cacheKey)
 		h.cache.DeleteQRCodes(bgCtx, url.ID.String())
-	}()
+		h.cache.SetUserURLsLastEdit(bgCtx, user.Auth0Sub, time.Now())
+	})
 
 	return c.SendStatus(204)
 }
@@ -426,14 +747,43 @@ func (h *URLHandler) CheckCode(c *fiber.Ctx) error {
 
 	ctx := c.Context()
 
-	err := h.shortener.ValidateCustomCode(ctx, code)
-	if err != nil {
+	var customDomainID *uuid.UUID
+	if domainIDParam := c.Query("domain_id"); domainIDParam != "" {
+		domainID, err := uuid.Parse(domainIDParam)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"error": "invalid domain_id",
+			})
+		}
+		customDomainID = &domainID
+	}
+
+	reason := ""
+	available := true
+	if err := h.shortener.ValidateCustomCode(ctx, code, customDomainID); err != nil {
+		available = false
+		reason = err.Error()
+	}
+
+	// CheckCode has no natural last-modified time, so it's only ever
+	// conditional on If-None-Match against a hash of its own result.
+	domainKey := ""
+	if customDomainID != nil {
+		domainKey = customDomainID.String()
+	}
+	etag := strongETag(fmt.Sprintf("%s|%s|%t|%s", code, domainKey, available, reason))
+	if inm := c.Get("If-None-Match"); inm != "" && strings.TrimSpace(inm) == etag {
+		c.Set("ETag", etag)
+		return c.SendStatus(304)
+	}
+	c.Set("ETag", etag)
+
+	if !available {
 		return c.JSON(fiber.Map{
 			"available": false,
-			"reason":    err.Error(),
+			"reason":    reason,
 		})
 	}
-
 	return c.JSON(fiber.Map{
 		"available": true,
 	})
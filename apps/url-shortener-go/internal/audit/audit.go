@@ -0,0 +1,216 @@
+// Package audit records and searches a history of URL mutations
+// (create/update/deactivate) in a TimescaleDB hypertable, so operators can
+// answer "who changed this, and what did it look like before" without
+// digging through application logs.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditEvent is a single recorded mutation of a URL.
+type AuditEvent struct {
+	Time        time.Time
+	ActorUserID uuid.UUID
+	URLID       uuid.UUID
+	Action      string
+	RequestID   string
+	RemoteIP    string
+	Before      map[string]interface{}
+	After       map[string]interface{}
+	Reason      string
+}
+
+// Actor identifies who is making a change and why. It's threaded down
+// from the API layer so an audit row carries the same provenance a reader
+// would expect from an access log.
+type Actor struct {
+	UserID    uuid.UUID
+	RequestID string
+	RemoteIP  string
+	Reason    string
+}
+
+// AuditFilter narrows a Search call. Zero-valued fields aren't filtered on.
+type AuditFilter struct {
+	URLID       *uuid.UUID
+	ActorUserID *uuid.UUID
+	Action      string
+	Since       *time.Time
+	Until       *time.Time
+
+	// BeforePath/BeforeValue and AfterPath/AfterValue, when set, require a
+	// dotted JSON path (e.g. "destination_url") into the before/after
+	// image to equal the given value.
+	BeforePath  string
+	BeforeValue string
+	AfterPath   string
+	AfterValue  string
+
+	// Limit caps the number of events returned; it defaults to 100 and
+	// is capped at 500.
+	Limit int
+}
+
+// Querier is the subset of pgx satisfied by both *pgxpool.Pool and pgx.Tx,
+// so Index can be called within the same transaction as the mutation it's
+// recording.
+type Querier interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Auditor records and searches URL mutation history.
+type Auditor interface {
+	// Index writes evt via q, so callers can wrap it in the same
+	// transaction as the mutation being audited.
+	Index(ctx context.Context, q Querier, evt AuditEvent) error
+	Search(ctx context.Context, filter AuditFilter) ([]AuditEvent, error)
+}
+
+// TimescaleAuditor persists AuditEvents to the url_audit hypertable.
+type TimescaleAuditor struct {
+	pool *pgxpool.Pool
+}
+
+// NewTimescaleAuditor creates a TimescaleAuditor. pool is only used by
+// Search; Index takes its own Querier so it can run inside a
+// caller-managed transaction instead of always using pool directly.
+func NewTimescaleAuditor(pool *pgxpool.Pool) *TimescaleAuditor {
+	return &TimescaleAuditor{pool: pool}
+}
+
+// Index writes evt to url_audit via q.
+func (a *TimescaleAuditor) Index(ctx context.Context, q Querier, evt AuditEvent) error {
+	if evt.Time.IsZero() {
+		evt.Time = time.Now()
+	}
+
+	before, err := json.Marshal(evt.Before)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit before-image: %w", err)
+	}
+	after, err := json.Marshal(evt.After)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit after-image: %w", err)
+	}
+
+	_, err = q.Exec(ctx, `
+		INSERT INTO url_audit (time, actor_user_id, url_id, action, request_id, remote_ip, before, after, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`, evt.Time, evt.ActorUserID, evt.URLID, evt.Action, evt.RequestID, evt.RemoteIP, before, after, evt.Reason)
+	if err != nil {
+		return fmt.Errorf("failed to index audit event: %w", err)
+	}
+	return nil
+}
+
+// Search retrieves audit events matching filter, most recent first.
+func (a *TimescaleAuditor) Search(ctx context.Context, filter AuditFilter) ([]AuditEvent, error) {
+	query := `
+		SELECT time, actor_user_id, url_id, action, request_id, remote_ip, before, after, reason
+		FROM url_audit
+		WHERE true`
+	args := []interface{}{}
+	argIndex := 1
+
+	if filter.URLID != nil {
+		query += fmt.Sprintf(" AND url_id = $%d", argIndex)
+		args = append(args, *filter.URLID)
+		argIndex++
+	}
+	if filter.ActorUserID != nil {
+		query += fmt.Sprintf(" AND actor_user_id = $%d", argIndex)
+		args = append(args, *filter.ActorUserID)
+		argIndex++
+	}
+	if filter.Action != "" {
+		query += fmt.Sprintf(" AND action = $%d", argIndex)
+		args = append(args, filter.Action)
+		argIndex++
+	}
+	if filter.Since != nil {
+		query += fmt.Sprintf(" AND time >= $%d", argIndex)
+		args = append(args, *filter.Since)
+		argIndex++
+	}
+	if filter.Until != nil {
+		query += fmt.Sprintf(" AND time <= $%d", argIndex)
+		args = append(args, *filter.Until)
+		argIndex++
+	}
+	if filter.BeforePath != "" {
+		query += fmt.Sprintf(" AND before #>> $%d = $%d", argIndex, argIndex+1)
+		args = append(args, jsonPath(filter.BeforePath), filter.BeforeValue)
+		argIndex += 2
+	}
+	if filter.AfterPath != "" {
+		query += fmt.Sprintf(" AND after #>> $%d = $%d", argIndex, argIndex+1)
+		args = append(args, jsonPath(filter.AfterPath), filter.AfterValue)
+		argIndex += 2
+	}
+
+	query += " ORDER BY time DESC"
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	} else if limit > 500 {
+		limit = 500
+	}
+	query += fmt.Sprintf(" LIMIT $%d", argIndex)
+	args = append(args, limit)
+
+	rows, err := a.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []AuditEvent{}
+	for rows.Next() {
+		var evt AuditEvent
+		var before, after []byte
+		if err := rows.Scan(&evt.Time, &evt.ActorUserID, &evt.URLID, &evt.Action, &evt.RequestID, &evt.RemoteIP, &before, &after, &evt.Reason); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		if len(before) > 0 {
+			_ = json.Unmarshal(before, &evt.Before)
+		}
+		if len(after) > 0 {
+			_ = json.Unmarshal(after, &evt.After)
+		}
+		events = append(events, evt)
+	}
+	return events, nil
+}
+
+// jsonPath splits a dotted JSON path ("metadata.campaign") into the text
+// array the jsonb #>> operator expects.
+func jsonPath(path string) []string {
+	return strings.Split(path, ".")
+}
+
+// InstallRetentionPolicy installs a TimescaleDB drop_chunks policy on the
+// url_audit hypertable, so audit rows older than keepFor are reclaimed
+// automatically instead of growing the table without bound.
+func InstallRetentionPolicy(ctx context.Context, pool *pgxpool.Pool, keepFor time.Duration) error {
+	_, err := pool.Exec(ctx, fmt.Sprintf(
+		`SELECT add_retention_policy('url_audit', INTERVAL '%d seconds', if_not_exists => true)`,
+		int64(keepFor.Seconds()),
+	))
+	if err != nil {
+		return fmt.Errorf("failed to install url_audit retention policy: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/auth0/go-jwt-middleware/v2/jwks"
+	"github.com/auth0/go-jwt-middleware/v2/validator"
+)
+
+// Auth0Provider validates Auth0-issued RS256 JWTs against the tenant's
+// JWKS endpoint.
+type Auth0Provider struct {
+	issuer    string
+	validator *validator.Validator
+}
+
+// NewAuth0Provider builds a Provider backed by Auth0's JWKS endpoint.
+func NewAuth0Provider(cfg *config.Config) (*Auth0Provider, error) {
+	issuerURL, err := url.Parse("https://" + cfg.Auth0Domain + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parse auth0 issuer url: %w", err)
+	}
+
+	jwksProvider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
+
+	jwtValidator, err := validator.New(
+		jwksProvider.KeyFunc,
+		validator.RS256,
+		issuerURL.String(),
+		[]string{cfg.Auth0Audience},
+		validator.WithCustomClaims(func() validator.CustomClaims {
+			return &CustomClaims{}
+		}),
+		validator.WithAllowedClockSkew(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create auth0 validator: %w", err)
+	}
+
+	return &Auth0Provider{issuer: issuerURL.String(), validator: jwtValidator}, nil
+}
+
+// Name identifies this provider for c.Locals("auth_provider").
+func (p *Auth0Provider) Name() string { return "auth0" }
+
+// Issuer is the Auth0 tenant's issuer URL, used to route JWTs to this
+// provider by their "iss" claim.
+func (p *Auth0Provider) Issuer() string { return p.issuer }
+
+// ValidateToken validates raw as an Auth0 RS256 JWT and resolves its Identity.
+func (p *Auth0Provider) ValidateToken(ctx context.Context, raw string) (Identity, error) {
+	claims, err := p.validator.ValidateToken(ctx, raw)
+	if err != nil {
+		return Identity{}, fmt.Errorf("validate auth0 token: %w", err)
+	}
+
+	validatedClaims, ok := claims.(*validator.ValidatedClaims)
+	if !ok {
+		return Identity{}, fmt.Errorf("unexpected auth0 claims type")
+	}
+
+	identity := Identity{
+		Sub:       validatedClaims.RegisteredClaims.Subject,
+		JTI:       validatedClaims.RegisteredClaims.ID,
+		ExpiresAt: time.Unix(validatedClaims.RegisteredClaims.Expiry, 0),
+	}
+	if customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims); ok {
+		identity.Email = customClaims.Email
+		identity.Name = customClaims.Name
+		identity.Scopes = customClaims.Scopes()
+	}
+	return identity, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+// forwardAuthMaxBodyBytes bounds how much of a rejecting forward-auth
+// response's body is proxied back to the caller.
+const forwardAuthMaxBodyBytes = 64 * 1024
+
+// forwardAuthMiddleware implements the "forward" AUTH_BACKEND (à la
+// Traefik's forward-auth): it issues a subrequest to cfg.ForwardAuthURL
+// carrying the incoming request's Authorization and Cookie headers, and
+// on a 2xx response copies cfg.ForwardAuthHeaders's allow-listed response
+// headers onto the request context - the first becomes the resolved
+// subject, the second (if present) a comma-separated scopes/groups list,
+// the third (if present) the display name. A non-2xx response's status,
+// Location and Content-Type headers, and body (up to
+// forwardAuthMaxBodyBytes) are proxied back to the caller, so the
+// forward-auth service can itself redirect to a login page or render an
+// error.
+func forwardAuthMiddleware(cfg *config.Config) fiber.Handler {
+	var headerNames []string
+	for _, h := range strings.Split(cfg.ForwardAuthHeaders, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			headerNames = append(headerNames, h)
+		}
+	}
+
+	client := &http.Client{Timeout: cfg.ForwardAuthTimeout}
+
+	return func(c *fiber.Ctx) error {
+		req, err := http.NewRequestWithContext(c.Context(), http.MethodGet, cfg.ForwardAuthURL, nil)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{"error": "failed to build forward-auth request"})
+		}
+		if authHeader := c.Get("Authorization"); authHeader != "" {
+			req.Header.Set("Authorization", authHeader)
+		}
+		if cookie := c.Get("Cookie"); cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		req.Header.Set("X-Forwarded-Method", c.Method())
+		req.Header.Set("X-Forwarded-Uri", c.OriginalURL())
+		req.Header.Set("X-Forwarded-For", c.IP())
+
+		resp, err := client.Do(req)
+		if err != nil {
+			log.Printf("auth: forward-auth request failed: %v", err)
+			return c.Status(401).JSON(fiber.Map{"error": "forward-auth unreachable"})
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, forwardAuthMaxBodyBytes))
+			if loc := resp.Header.Get("Location"); loc != "" {
+				c.Set("Location", loc)
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "" {
+				c.Set("Content-Type", ct)
+			}
+			return c.Status(resp.StatusCode).Send(body)
+		}
+
+		if len(headerNames) == 0 {
+			return c.Status(500).JSON(fiber.Map{"error": "forward-auth has no configured headers"})
+		}
+
+		sub := resp.Header.Get(headerNames[0])
+		if sub == "" {
+			return c.Status(401).JSON(fiber.Map{"error": "forward-auth response missing identity header"})
+		}
+
+		c.Locals("auth_provider", "forward")
+		c.Locals("auth0_sub", sub)
+		if len(headerNames) > 1 {
+			if groups := resp.Header.Get(headerNames[1]); groups != "" {
+				var scopes []string
+				for _, s := range strings.Split(groups, ",") {
+					if s = strings.TrimSpace(s); s != "" {
+						scopes = append(scopes, s)
+					}
+				}
+				c.Locals("scopes", scopes)
+			}
+		}
+		if len(headerNames) > 2 {
+			c.Locals("name", resp.Header.Get(headerNames[2]))
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	githubAuthorizeURL  = "https://github.com/login/oauth/authorize"
+	githubTokenURL      = "https://github.com/login/oauth/access_token"
+	githubUserURL       = "https://api.github.com/user"
+	githubUserEmailsURL = "https://api.github.com/user/emails"
+
+	githubStateCookie = "github_oauth_state"
+)
+
+// GitHubProvider resolves identities from GitHub access tokens and drives
+// the authorization-code flow that issues them. GitHub's tokens are
+// opaque, so ValidateToken confirms them by calling the GitHub API rather
+// than checking a local signature.
+type GitHubProvider struct {
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	httpClient   *http.Client
+}
+
+// NewGitHubProvider builds a GitHub OAuth provider from config.
+func NewGitHubProvider(cfg *config.Config) *GitHubProvider {
+	return &GitHubProvider{
+		clientID:     cfg.GitHubClientID,
+		clientSecret: cfg.GitHubClientSecret,
+		redirectURL:  cfg.GitHubRedirectURL,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name identifies this provider for c.Locals("auth_provider").
+func (p *GitHubProvider) Name() string { return "github" }
+
+// ValidateToken resolves the Identity for a GitHub access token.
+func (p *GitHubProvider) ValidateToken(ctx context.Context, raw string) (Identity, error) {
+	var user struct {
+		Login string `json:"login"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := p.getJSON(ctx, githubUserURL, raw, &user); err != nil {
+		return Identity{}, fmt.Errorf("fetch github user: %w", err)
+	}
+	if user.Login == "" {
+		return Identity{}, fmt.Errorf("github user response missing login")
+	}
+
+	email := user.Email
+	if email == "" {
+		var err error
+		email, err = p.fetchPrimaryEmail(ctx, raw)
+		if err != nil {
+			return Identity{}, err
+		}
+	}
+
+	return Identity{
+		Sub:   "github|" + user.Login,
+		Email: email,
+		Name:  user.Name,
+	}, nil
+}
+
+// fetchPrimaryEmail looks up the user's verified primary email, used when
+// /user doesn't expose one (e.g. the user's email is private).
+func (p *GitHubProvider) fetchPrimaryEmail(ctx context.Context, token string) (string, error) {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := p.getJSON(ctx, githubUserEmailsURL, token, &emails); err != nil {
+		return "", fmt.Errorf("fetch github emails: %w", err)
+	}
+
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			return e.Email, nil
+		}
+	}
+	return "", nil
+}
+
+func (p *GitHubProvider) getJSON(ctx context.Context, apiURL, token string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github api %s returned status %d", apiURL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// LoginHandler redirects to GitHub's OAuth authorize URL, storing a random
+// state value in a short-lived cookie that CallbackHandler checks to guard
+// against CSRF.
+func (p *GitHubProvider) LoginHandler(c *fiber.Ctx) error {
+	state, err := randomState()
+	if err != nil {
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to start github oauth flow",
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     githubStateCookie,
+		Value:    state,
+		HTTPOnly: true,
+		Secure:   true,
+		MaxAge:   600,
+		SameSite: "Lax",
+	})
+
+	authorizeURL := fmt.Sprintf("%s?client_id=%s&redirect_uri=%s&scope=%s&state=%s",
+		githubAuthorizeURL,
+		url.QueryEscape(p.clientID),
+		url.QueryEscape(p.redirectURL),
+		url.QueryEscape("read:user user:email"),
+		url.QueryEscape(state),
+	)
+
+	return c.Redirect(authorizeURL, http.StatusFound)
+}
+
+// CallbackHandler exchanges the authorization code GitHub redirected back
+// with for an access token and resolves the caller's identity.
+func (p *GitHubProvider) CallbackHandler(c *fiber.Ctx) error {
+	state := c.Query("state")
+	cookie := c.Cookies(githubStateCookie)
+	if state == "" || cookie == "" || state != cookie {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid oauth state",
+		})
+	}
+	c.ClearCookie(githubStateCookie)
+
+	code := c.Query("code")
+	if code == "" {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "missing code",
+		})
+	}
+
+	token, err := p.exchangeCode(c.Context(), code)
+	if err != nil {
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{
+			"error": "failed to exchange github code",
+		})
+	}
+
+	identity, err := p.ValidateToken(c.Context(), token)
+	if err != nil {
+		return c.Status(http.StatusBadGateway).JSON(fiber.Map{
+			"error": "failed to resolve github identity",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"access_token": token,
+		"sub":          identity.Sub,
+		"email":        identity.Email,
+		"name":         identity.Name,
+	})
+}
+
+func (p *GitHubProvider) exchangeCode(ctx context.Context, code string) (string, error) {
+	form := url.Values{
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, githubTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("github token exchange returned status %d: %s", resp.StatusCode, body)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("decode github token response: %w", err)
+	}
+	if tokenResp.Error != "" {
+		return "", fmt.Errorf("github oauth error: %s", tokenResp.Error)
+	}
+	return tokenResp.AccessToken, nil
+}
+
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
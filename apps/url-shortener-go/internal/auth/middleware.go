@@ -2,20 +2,26 @@ package auth
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"log"
-	"net/url"
+	"strings"
 	"time"
 
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
-	"github.com/auth0/go-jwt-middleware/v2/jwks"
-	"github.com/auth0/go-jwt-middleware/v2/validator"
 	"github.com/gofiber/fiber/v2"
 )
 
-// CustomClaims contains custom claims from Auth0 token
+// adminScope is the scope claim that gates operator-only endpoints, e.g.
+// the token revocation admin route.
+const adminScope = "admin"
+
+// CustomClaims contains custom claims from an identity provider's JWT.
 type CustomClaims struct {
 	Email string `json:"email"`
 	Name  string `json:"name"`
+	Scope string `json:"scope"`
 }
 
 // Validate validates the custom claims (required by validator.CustomClaims interface)
@@ -23,33 +29,145 @@ func (c *CustomClaims) Validate(ctx context.Context) error {
 	return nil
 }
 
-// Middleware creates an Auth0 JWT validation middleware for Fiber
-func Middleware(cfg *config.Config) fiber.Handler {
-	issuerURL, err := url.Parse("https://" + cfg.Auth0Domain + "/")
-	if err != nil {
-		log.Fatalf("Failed to parse Auth0 issuer URL: %v", err)
-	}
-
-	// Setup JWKS provider with caching
-	provider := jwks.NewCachingProvider(issuerURL, 5*time.Minute)
-
-	// Create JWT validator
-	jwtValidator, err := validator.New(
-		provider.KeyFunc,
-		validator.RS256,
-		issuerURL.String(),
-		[]string{cfg.Auth0Audience},
-		validator.WithCustomClaims(func() validator.CustomClaims {
-			return &CustomClaims{}
-		}),
-		validator.WithAllowedClockSkew(time.Minute),
-	)
-	if err != nil {
-		log.Fatalf("Failed to create JWT validator: %v", err)
+// Scopes splits the space-delimited OAuth2 "scope" claim into individual
+// scope values.
+func (c *CustomClaims) Scopes() []string {
+	if c.Scope == "" {
+		return nil
+	}
+	return strings.Fields(c.Scope)
+}
+
+// Identity is the authenticated principal resolved by a Provider, normalized
+// across identity providers.
+type Identity struct {
+	Sub    string
+	Email  string
+	Name   string
+	Scopes []string
+
+	// JTI and ExpiresAt come from the token's registered claims, when the
+	// provider's tokens are JWTs that carry them. Opaque tokens (e.g.
+	// GitHub's) leave these zero.
+	JTI       string
+	ExpiresAt time.Time
+}
+
+// HasScope reports whether the identity was granted scope.
+func (i Identity) HasScope(scope string) bool {
+	for _, s := range i.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Provider validates a raw bearer token and resolves the caller's Identity.
+type Provider interface {
+	// ValidateToken validates raw and returns the identity it represents.
+	ValidateToken(ctx context.Context, raw string) (Identity, error)
+	// Name identifies the provider, stored in c.Locals("auth_provider").
+	Name() string
+}
+
+// issuerProvider is implemented by JWT-based providers that know the
+// issuer they expect, so Middleware can match a token to its provider by
+// the "iss" claim instead of trying each one in turn.
+type issuerProvider interface {
+	Provider
+	Issuer() string
+}
+
+// Providers builds the set of identity providers enabled by cfg: Auth0 and
+// GitHub OAuth if their credentials are configured, plus a generic OIDC
+// provider if a discovery URL is set.
+func Providers(cfg *config.Config) ([]Provider, error) {
+	var providers []Provider
+
+	if cfg.Auth0Domain != "" && cfg.Auth0Audience != "" {
+		p, err := NewAuth0Provider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	if cfg.OIDCDiscoveryURL != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		name := cfg.OIDCProviderName
+		if name == "" {
+			name = "oidc"
+		}
+		p, err := NewOIDCProvider(ctx, name, cfg.OIDCDiscoveryURL, cfg.OIDCAudience)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+
+	if cfg.GitHubClientID != "" && cfg.GitHubClientSecret != "" {
+		providers = append(providers, NewGitHubProvider(cfg))
+	}
+
+	return providers, nil
+}
+
+// Middleware authenticates incoming requests. By default (AuthBackend
+// unset) it tries every configured provider's bearer-token validation,
+// preferring the one whose issuer matches the token's (unverified) "iss"
+// claim for JWTs and falling back to the configured order - e.g.
+// GitHub's opaque tokens - for everything else. Setting AuthBackend to
+// "auth0", "oidc", "apikey", or "forward" pins it to exactly one backend
+// instead, which is required for "apikey" and "forward" since neither
+// has an issuer claim to auto-detect against.
+func Middleware(cfg *config.Config, redisCache *cache.RedisCache) fiber.Handler {
+	switch cfg.AuthBackend {
+	case "forward":
+		return forwardAuthMiddleware(cfg)
+	case "apikey":
+		if cfg.APIKeyID == "" || cfg.APIKeySecret == "" {
+			log.Fatalf("AUTH_BACKEND=apikey requires API_KEY_ID and API_KEY_SECRET")
+		}
+		return tokenMiddleware([]Provider{NewAPIKeyProvider(cfg.APIKeyID, cfg.APIKeySecret)}, redisCache, cfg.AuthRevocationFailOpen)
+	case "auth0":
+		p, err := NewAuth0Provider(cfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize Auth0 provider: %v", err)
+		}
+		return tokenMiddleware([]Provider{p}, redisCache, cfg.AuthRevocationFailOpen)
+	case "oidc":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		p, err := NewOIDCProvider(ctx, cfg.OIDCProviderName, cfg.OIDCDiscoveryURL, cfg.OIDCAudience)
+		if err != nil {
+			log.Fatalf("Failed to initialize OIDC provider: %v", err)
+		}
+		return tokenMiddleware([]Provider{p}, redisCache, cfg.AuthRevocationFailOpen)
+	default:
+		providers, err := Providers(cfg)
+		if err != nil {
+			log.Fatalf("Failed to initialize identity providers: %v", err)
+		}
+		if len(providers) == 0 {
+			log.Fatalf("No identity providers configured")
+		}
+		return tokenMiddleware(providers, redisCache, cfg.AuthRevocationFailOpen)
 	}
+}
 
+// tokenMiddleware authenticates the request's bearer token against
+// providers, preferring the one whose issuer matches the token's
+// (unverified) "iss" claim for JWTs and falling back to the configured
+// order for everything else. Once a provider resolves an identity, the
+// token is rejected if its sub or jti has been revoked, and the session
+// is recorded for later lapsed-session cleanup. If the revocation check
+// itself errors, the request is rejected (401) unless failOpen is set -
+// see config.Config.AuthRevocationFailOpen.
+func tokenMiddleware(providers []Provider, redisCache *cache.RedisCache, failOpen bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get token from Authorization header
 		authHeader := c.Get("Authorization")
 		if authHeader == "" {
 			return c.Status(401).JSON(fiber.Map{
@@ -57,7 +175,6 @@ func Middleware(cfg *config.Config) fiber.Handler {
 			})
 		}
 
-		// Remove "Bearer " prefix
 		if len(authHeader) < 7 || authHeader[:7] != "Bearer " {
 			return c.Status(401).JSON(fiber.Map{
 				"error": "invalid authorization header format",
@@ -65,37 +182,122 @@ func Middleware(cfg *config.Config) fiber.Handler {
 		}
 		token := authHeader[7:]
 
-		// Validate token
-		claims, err := jwtValidator.ValidateToken(c.Context(), token)
-		if err != nil {
-			log.Printf("Token validation failed: %v", err)
-			return c.Status(401).JSON(fiber.Map{
-				"error": "invalid token",
-			})
+		for _, p := range candidateOrder(providers, token) {
+			identity, err := p.ValidateToken(c.Context(), token)
+			if err != nil {
+				continue
+			}
+
+			revoked, err := redisCache.IsRevoked(c.Context(), identity.Sub, identity.JTI)
+			if err != nil {
+				log.Printf("auth: revocation check failed: %v", err)
+				if !failOpen {
+					return c.Status(401).JSON(fiber.Map{
+						"error": "revocation check unavailable",
+					})
+				}
+			} else if revoked {
+				return c.Status(401).JSON(fiber.Map{
+					"error": "token_revoked",
+				})
+			}
+
+			if !identity.ExpiresAt.IsZero() {
+				if err := redisCache.RecordSession(c.Context(), identity.Sub, identity.JTI, identity.ExpiresAt); err != nil {
+					log.Printf("auth: failed to record session: %v", err)
+				}
+			}
+
+			c.Locals("auth_provider", p.Name())
+			c.Locals("auth0_sub", identity.Sub)
+			c.Locals("email", identity.Email)
+			c.Locals("name", identity.Name)
+			c.Locals("scopes", identity.Scopes)
+			return c.Next()
 		}
 
-		// Extract validated claims
-		validatedClaims, ok := claims.(*validator.ValidatedClaims)
-		if !ok {
-			return c.Status(401).JSON(fiber.Map{
-				"error": "invalid claims format",
-			})
+		return c.Status(401).JSON(fiber.Map{
+			"error": "invalid token",
+		})
+	}
+}
+
+// RequireScope returns middleware that rejects requests whose identity
+// (resolved by a preceding Middleware call) lacks scope, with 403. Used to
+// gate operator-only routes like the admin token-purge endpoint.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, s := range GetScopes(c) {
+			if s == scope {
+				return c.Next()
+			}
 		}
+		return c.Status(403).JSON(fiber.Map{
+			"error": "missing required scope: " + scope,
+		})
+	}
+}
+
+// RequireAdminScope is a shorthand for RequireScope(adminScope).
+func RequireAdminScope() fiber.Handler {
+	return RequireScope(adminScope)
+}
+
+// candidateOrder moves the provider whose issuer matches the token's
+// unverified "iss" claim to the front, leaving the rest in their
+// configured order. Non-JWT tokens (e.g. GitHub's opaque access tokens)
+// aren't reordered at all, since there's no issuer claim to read.
+func candidateOrder(providers []Provider, token string) []Provider {
+	iss, isJWT := unverifiedIssuer(token)
+	if !isJWT {
+		return providers
+	}
 
-		// Store claims in context for use by handlers
-		c.Locals("auth0_sub", validatedClaims.RegisteredClaims.Subject)
-		
-		if customClaims, ok := validatedClaims.CustomClaims.(*CustomClaims); ok {
-			c.Locals("email", customClaims.Email)
-			c.Locals("name", customClaims.Name)
+	ordered := make([]Provider, 0, len(providers))
+	var rest []Provider
+	for _, p := range providers {
+		if ip, ok := p.(issuerProvider); ok && ip.Issuer() == iss {
+			ordered = append(ordered, p)
+			continue
 		}
+		rest = append(rest, p)
+	}
+	return append(ordered, rest...)
+}
+
+// unverifiedIssuer peeks at a JWT's payload to read its "iss" claim without
+// verifying the signature. This only picks which provider to try first -
+// the provider itself still fully validates the token before trusting it.
+func unverifiedIssuer(token string) (issuer string, isJWT bool) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", false
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", true
+	}
 
-		return c.Next()
+	var claims struct {
+		Issuer string `json:"iss"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return "", true
 	}
+	return claims.Issuer, true
 }
 
-// GetAuth0Sub extracts the Auth0 subject from the context
+// GetAuth0Sub extracts the authenticated subject from the context. Kept for
+// existing call sites; prefer GetSubject in new code since the subject may
+// now come from any configured provider, not just Auth0.
 func GetAuth0Sub(c *fiber.Ctx) string {
+	return GetSubject(c)
+}
+
+// GetSubject extracts the authenticated subject from the context,
+// regardless of which provider resolved it.
+func GetSubject(c *fiber.Ctx) string {
 	if sub, ok := c.Locals("auth0_sub").(string); ok {
 		return sub
 	}
@@ -117,3 +319,21 @@ func GetName(c *fiber.Ctx) string {
 	}
 	return ""
 }
+
+// GetScopes extracts the authenticated identity's granted scopes from the
+// context.
+func GetScopes(c *fiber.Ctx) []string {
+	if scopes, ok := c.Locals("scopes").([]string); ok {
+		return scopes
+	}
+	return nil
+}
+
+// GetProviderName extracts the name of the identity provider that resolved
+// the request's identity, e.g. "auth0" or "github".
+func GetProviderName(c *fiber.Ctx) string {
+	if name, ok := c.Locals("auth_provider").(string); ok {
+		return name
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+// Package bg provides a managed dispatcher for the best-effort cache
+// warm/invalidate work that follows a mutating request (CreateURL,
+// UpdateURL, DeleteURL). It replaces a bare
+// "go func() { ctx, _ := context.WithTimeout(context.Background(), ...); ... }()"
+// with one that can be cancelled: per-user, when a later mutation
+// supersedes an earlier one still in flight, and globally, on server
+// shutdown - so this work can no longer outlive the process the way a
+// detached goroutine's context.Background() can.
+package bg
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/eventpool"
+)
+
+// Scheduler runs submitted tasks on a bounded eventpool.Pool, each under
+// its own deadline derived from a shared root context. It plays the same
+// role here that a deadlineTimer plays for a net.Conn in gonet: a single
+// owned cancellation point per logical stream of work (here, per user)
+// instead of a goroutine that runs to completion no matter what happens
+// to the thing it was working on behalf of.
+type Scheduler struct {
+	pool   *eventpool.Pool
+	root   context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[string]*task // keyed by user sub
+}
+
+// task tracks the cancel func for one user's most recently submitted,
+// not-yet-finished work, so Submit can tell whether it's still the
+// current one when deciding whether to clear it from pending.
+type task struct {
+	cancel context.CancelFunc
+}
+
+// New creates a Scheduler backed by an eventpool.Pool of the given size.
+func New(workers, queueSize int) *Scheduler {
+	root, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		pool:    eventpool.New(workers, queueSize),
+		root:    root,
+		cancel:  cancel,
+		pending: make(map[string]*task),
+	}
+}
+
+// Submit schedules fn to run on the bounded pool with a ctx that's
+// cancelled after timeout, when Shutdown is called, or as soon as userSub
+// submits another task - whichever comes first. That last case is what
+// lets a rapid create-then-delete cancel the create's now-pointless cache
+// write-through instead of letting it race the delete's invalidation.
+// fn must itself watch ctx (e.g. pass it through to cache calls) for
+// cancellation to actually stop its work early; Submit only arranges for
+// ctx to report done, it can't interrupt fn by force.
+//
+// Submit never blocks: if the pool's queue is full, it returns false and
+// fn never runs.
+func (s *Scheduler) Submit(userSub string, timeout time.Duration, fn func(ctx context.Context)) bool {
+	ctx, cancel := context.WithTimeout(s.root, timeout)
+	t := &task{cancel: cancel}
+
+	s.mu.Lock()
+	if prev, ok := s.pending[userSub]; ok {
+		prev.cancel()
+	}
+	s.pending[userSub] = t
+	s.mu.Unlock()
+
+	submitted := s.pool.Submit(func() {
+		fn(ctx)
+		cancel()
+		s.mu.Lock()
+		if s.pending[userSub] == t {
+			delete(s.pending, userSub)
+		}
+		s.mu.Unlock()
+	})
+	if !submitted {
+		cancel()
+		s.mu.Lock()
+		if s.pending[userSub] == t {
+			delete(s.pending, userSub)
+		}
+		s.mu.Unlock()
+	}
+	return submitted
+}
+
+// Shutdown cancels every task's context, in flight or still queued, so
+// in-flight tasks see ctx done and queued ones still run fn, but with ctx
+// already done. It
+// doesn't wait for the pool's workers to drain - callers that need a
+// clean stop should stop accepting new HTTP requests (and therefore new
+// Submit calls) before calling Shutdown.
+func (s *Scheduler) Shutdown() {
+	s.cancel()
+}
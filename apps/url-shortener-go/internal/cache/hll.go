@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// activityBucketSpan is the width of one HyperLogLog time bucket.
+// Buckets are merged across trailingBuckets windows (e.g. 12 buckets of
+// 5 minutes each for a trailing 1h window) rather than keeping one huge
+// key, so old activity ages out on its own via the key TTL instead of
+// requiring an explicit cleanup job.
+const activityBucketSpan = 5 * time.Minute
+
+// activityBucketTTL is kept comfortably longer than the widest trailing
+// window callers are expected to merge (1h), so a bucket is never
+// evicted out from under a merge that's still reading it.
+const activityBucketTTL = 90 * time.Minute
+
+// activityBucket returns the bucket index t falls into.
+func activityBucket(t time.Time) int64 {
+	return t.Unix() / int64(activityBucketSpan.Seconds())
+}
+
+func visitorsKey(shortCode string, bucket int64) string {
+	return fmt.Sprintf("hll:visitors:%s:%d", shortCode, bucket)
+}
+
+func codesKey(bucket int64) string {
+	return fmt.Sprintf("hll:codes:%d", bucket)
+}
+
+// RecordVisitor adds ipHash to the current bucket's per-code visitor HLL
+// and shortCode to the current bucket's global active-codes HLL. Both
+// are best-effort: a failure here must never affect the redirect, so
+// callers should invoke this from the same background goroutine that
+// already records the click event rather than on the hot path.
+func (c *RedisCache) RecordVisitor(ctx context.Context, shortCode, ipHash string) error {
+	bucket := activityBucket(time.Now())
+
+	vKey := visitorsKey(shortCode, bucket)
+	if err := c.client.PFAdd(ctx, vKey, ipHash).Err(); err != nil {
+		return fmt.Errorf("pfadd visitors error: %w", err)
+	}
+	c.client.Expire(ctx, vKey, activityBucketTTL)
+
+	cKey := codesKey(bucket)
+	if err := c.client.PFAdd(ctx, cKey, shortCode).Err(); err != nil {
+		return fmt.Errorf("pfadd codes error: %w", err)
+	}
+	c.client.Expire(ctx, cKey, activityBucketTTL)
+
+	return nil
+}
+
+// trailingBucketKeys returns the keys for the trailingBuckets most
+// recent buckets (inclusive of the current one) built by keyFn.
+func trailingBucketKeys(trailingBuckets int, keyFn func(int64) string) []string {
+	now := activityBucket(time.Now())
+	keys := make([]string, 0, trailingBuckets)
+	for i := 0; i < trailingBuckets; i++ {
+		keys = append(keys, keyFn(now-int64(i)))
+	}
+	return keys
+}
+
+// ActiveShortCodes approximates the number of distinct short codes
+// redirected within the trailing trailingBuckets*activityBucketSpan
+// window, by merging their per-bucket HyperLogLogs.
+func (c *RedisCache) ActiveShortCodes(ctx context.Context, trailingBuckets int) (int64, error) {
+	return c.pfMergeCount(ctx, "hll:codes:merge:tmp", trailingBucketKeys(trailingBuckets, codesKey))
+}
+
+// ActiveVisitors approximates the number of distinct visitors (by
+// hashed IP) a short code has had within the trailing window.
+func (c *RedisCache) ActiveVisitors(ctx context.Context, shortCode string, trailingBuckets int) (int64, error) {
+	tmpKey := "hll:visitors:merge:tmp:" + shortCode
+	keys := trailingBucketKeys(trailingBuckets, func(b int64) string {
+		return visitorsKey(shortCode, b)
+	})
+	return c.pfMergeCount(ctx, tmpKey, keys)
+}
+
+// pfMergeCount merges keys into tmpKey, counts it, and cleans tmpKey up.
+// A missing source key is simply not counted by PFMERGE, so callers
+// don't need to check each bucket exists first.
+func (c *RedisCache) pfMergeCount(ctx context.Context, tmpKey string, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	if err := c.client.PFMerge(ctx, tmpKey, keys...).Err(); err != nil {
+		return 0, fmt.Errorf("pfmerge error: %w", err)
+	}
+	defer c.client.Del(ctx, tmpKey)
+
+	count, err := c.client.PFCount(ctx, tmpKey).Result()
+	if err != nil {
+		return 0, fmt.Errorf("pfcount error: %w", err)
+	}
+	return count, nil
+}
+
+// ActiveShortCodeNames scans for short codes that have at least one
+// visitor HLL bucket within the trailing window, so the caller knows
+// which per-code gauges to update without iterating every URL ever
+// created.
+func (c *RedisCache) ActiveShortCodeNames(ctx context.Context, trailingBuckets int) ([]string, error) {
+	now := activityBucket(time.Now())
+	seen := make(map[string]struct{})
+
+	for i := 0; i < trailingBuckets; i++ {
+		bucket := now - int64(i)
+		pattern := "hll:visitors:*:" + strconv.FormatInt(bucket, 10)
+
+		iter := c.client.Scan(ctx, 0, pattern, 0).Iterator()
+		for iter.Next(ctx) {
+			code, ok := shortCodeFromVisitorsKey(iter.Val())
+			if ok {
+				seen[code] = struct{}{}
+			}
+		}
+		if err := iter.Err(); err != nil {
+			return nil, fmt.Errorf("scan visitors keys error: %w", err)
+		}
+	}
+
+	codes := make([]string, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+// shortCodeFromVisitorsKey extracts the short code from a
+// "hll:visitors:{code}:{bucket}" key.
+func shortCodeFromVisitorsKey(key string) (string, bool) {
+	const prefix = "hll:visitors:"
+	if len(key) <= len(prefix) {
+		return "", false
+	}
+	rest := key[len(prefix):]
+	for i := len(rest) - 1; i >= 0; i-- {
+		if rest[i] == ':' {
+			return rest[:i], true
+		}
+	}
+	return "", false
+}
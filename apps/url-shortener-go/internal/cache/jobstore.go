@@ -0,0 +1,20 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// SetJSON marshals val as JSON and stores it under key with the given
+// TTL. It's the exported form of setCached, for packages outside cache
+// (like worker.Exporter) that need to persist job-like state without
+// going through GetOrCompute's stampede-collapsing lock dance.
+func SetJSON[T any](ctx context.Context, c *RedisCache, key string, val T, ttl time.Duration) error {
+	return setCached(ctx, c, key, val, ttl)
+}
+
+// GetJSON unmarshals the JSON stored under key into T, returning an error
+// if key doesn't exist or holds something else.
+func GetJSON[T any](ctx context.Context, c *RedisCache, key string) (T, error) {
+	return getCached[T](ctx, c, key)
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// unlockScript deletes a lock only if it still holds the token we set when
+// we acquired it, so a caller can never release a lock that another owner
+// has since acquired after our own TTL already expired.
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+else
+	return 0
+end
+`)
+
+// Lock attempts to acquire a short-lived distributed lock on key using
+// SET NX PX, returning a random token that identifies this acquisition.
+// acquired is false if another caller already holds the lock.
+func (c *RedisCache) Lock(ctx context.Context, key string, ttl time.Duration) (token string, acquired bool, err error) {
+	token = uuid.NewString()
+	ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return "", false, fmt.Errorf("lock acquire error: %w", err)
+	}
+	return token, ok, nil
+}
+
+// Unlock releases a lock previously acquired with Lock, as long as token
+// still matches what's stored.
+func (c *RedisCache) Unlock(ctx context.Context, key, token string) error {
+	if err := unlockScript.Run(ctx, c.client, []string{key}, token).Err(); err != nil && err != redis.Nil {
+		return fmt.Errorf("unlock error: %w", err)
+	}
+	return nil
+}
+
+// GetOrCompute returns the cached value stored under key, computing it via
+// compute on a cache miss. Concurrent misses for the same key don't all run
+// compute: the first caller to acquire lock:<key> becomes the winner and
+// populates the cache, while the rest poll the cache with exponential
+// backoff until the winner finishes (or the lock's TTL elapses), falling
+// back to computing directly rather than blocking forever if the winner
+// never releases the lock. This prevents a cache stampede when a popular
+// key expires and many requests miss it at once.
+func GetOrCompute[T any](ctx context.Context, c *RedisCache, key string, ttl time.Duration, compute func() (T, error)) (T, error) {
+	if val, err := getCached[T](ctx, c, key); err == nil {
+		return val, nil
+	}
+
+	lockKey := "lock:" + key
+	token, acquired, err := c.Lock(ctx, lockKey, ttl)
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("acquire lock: %w", err)
+	}
+
+	if acquired {
+		defer func() {
+			unlockCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if err := c.Unlock(unlockCtx, lockKey, token); err != nil {
+				log.Printf("GetOrCompute: failed to release lock %s: %v", lockKey, err)
+			}
+		}()
+
+		val, err := compute()
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		if err := setCached(ctx, c, key, val, ttl); err != nil {
+			log.Printf("GetOrCompute: failed to cache %s: %v", key, err)
+		}
+		return val, nil
+	}
+
+	return pollOrCompute(ctx, c, key, ttl, compute)
+}
+
+// pollOrCompute is the loser's path: poll the cache with exponential
+// backoff until the lock's winner populates it, bailing out to compute()
+// directly once the lock's TTL has elapsed so a stalled winner can't block
+// every other caller indefinitely.
+func pollOrCompute[T any](ctx context.Context, c *RedisCache, key string, ttl time.Duration, compute func() (T, error)) (T, error) {
+	backoff := 10 * time.Millisecond
+	deadline := time.Now().Add(ttl)
+
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if val, err := getCached[T](ctx, c, key); err == nil {
+			return val, nil
+		}
+
+		backoff *= 2
+		if backoff > time.Second {
+			backoff = time.Second
+		}
+	}
+
+	return compute()
+}
+
+func getCached[T any](ctx context.Context, c *RedisCache, key string) (T, error) {
+	var val T
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		return val, err
+	}
+	if err := json.Unmarshal(data, &val); err != nil {
+		return val, err
+	}
+	return val, nil
+}
+
+func setCached[T any](ctx context.Context, c *RedisCache, key string, val T, ttl time.Duration) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("marshal cached value: %w", err)
+	}
+	if err := c.client.Set(ctx, key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("cache set error: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Publish publishes payload on channel via Redis Pub/Sub, for callers that
+// need cross-process fan-out rather than the durable, consumer-group
+// semantics of RecordClickEvent/ConsumeStream.
+func (c *RedisCache) Publish(ctx context.Context, channel string, payload []byte) error {
+	if err := c.client.Publish(ctx, channel, payload).Err(); err != nil {
+		return fmt.Errorf("publish error: %w", err)
+	}
+	return nil
+}
+
+// Subscribe opens a Redis Pub/Sub subscription on channel. The caller is
+// responsible for closing the returned PubSub once done with it.
+func (c *RedisCache) Subscribe(ctx context.Context, channel string) *redis.PubSub {
+	return c.client.Subscribe(ctx, channel)
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript implements a sliding-window rate limit against a
+// sorted set: stale entries (older than the window) are trimmed, the
+// remaining count is compared against the limit, and - if still under it -
+// a new entry is added and the key's TTL refreshed, all atomically so
+// there's no gap between counting and expiring like a separate INCR+EXPIRE
+// pair has.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
+local count = redis.call("ZCARD", key)
+
+if count < limit then
+	redis.call("ZADD", key, now, member)
+	redis.call("PEXPIRE", key, window)
+	return {1, limit - count - 1, window}
+end
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+local resetMs = window
+if oldest[2] then
+	resetMs = tonumber(oldest[2]) + window - now
+end
+return {0, 0, resetMs}
+`)
+
+// CheckRateLimit enforces a sliding-window rate limit of limit requests per
+// window for key, implemented as a single EVAL so the trim-count-add
+// sequence can't race or leave the key without a TTL on a crash between
+// steps. remaining is how many more requests are allowed in the current
+// window; retryAfter is how long until the oldest counted request ages out.
+func (c *RedisCache) CheckRateLimit(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, remaining int, retryAfter time.Duration, err error) {
+	rateLimitKey := "ratelimit:" + key
+	now := time.Now().UnixMilli()
+	windowMs := window.Milliseconds()
+	member := fmt.Sprintf("%d:%s", now, uuid.NewString())
+
+	res, err := slidingWindowScript.Run(ctx, c.client, []string{rateLimitKey}, now, windowMs, limit, member).Result()
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("rate limit error: %w", err)
+	}
+
+	allowedVal, remainingVal, resetMs, err := parseTriple(res)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("rate limit script: %w", err)
+	}
+
+	return allowedVal == 1, int(remainingVal), time.Duration(resetMs) * time.Millisecond, nil
+}
+
+// tokenBucketScript implements a token bucket with linear refill, stored as
+// a {tokens, last_refill} hash. Refill and consumption happen in one EVAL
+// so concurrent callers can't both read the same token count before either
+// writes it back.
+var tokenBucketScript = redis.NewScript(`
+local key = KEYS[1]
+local capacity = tonumber(ARGV[1])
+local refillPerSecond = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local bucket = redis.call("HMGET", key, "tokens", "last_refill")
+local tokens = tonumber(bucket[1])
+local lastRefill = tonumber(bucket[2])
+
+if tokens == nil then
+	tokens = capacity
+	lastRefill = now
+end
+
+local elapsedSeconds = math.max(0, now - lastRefill) / 1000
+local refilled = math.min(capacity, tokens + elapsedSeconds * refillPerSecond)
+
+local allowed = 0
+local retryMs = 0
+if refilled >= 1 then
+	allowed = 1
+	refilled = refilled - 1
+else
+	local deficit = 1 - refilled
+	retryMs = math.ceil(deficit / refillPerSecond * 1000)
+end
+
+redis.call("HSET", key, "tokens", tostring(refilled), "last_refill", tostring(now))
+redis.call("PEXPIRE", key, math.ceil(capacity / refillPerSecond * 1000) + 1000)
+
+return {allowed, math.floor(refilled), retryMs}
+`)
+
+// CheckTokenBucket enforces a token-bucket rate limit for key: capacity
+// tokens max, refilled linearly at refillPerSecond tokens/sec. Like
+// CheckRateLimit, the refill-and-consume sequence runs as one EVAL so it
+// can't race with a concurrent caller reading the same bucket state.
+func (c *RedisCache) CheckTokenBucket(ctx context.Context, key string, capacity int, refillPerSecond float64) (allowed bool, remaining int, retryAfter time.Duration, err error) {
+	bucketKey := "tokenbucket:" + key
+	now := time.Now().UnixMilli()
+
+	res, err := tokenBucketScript.Run(ctx, c.client, []string{bucketKey}, capacity, refillPerSecond, now).Result()
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("token bucket error: %w", err)
+	}
+
+	allowedVal, remainingVal, retryMs, err := parseTriple(res)
+	if err != nil {
+		return false, 0, 0, fmt.Errorf("token bucket script: %w", err)
+	}
+
+	return allowedVal == 1, int(remainingVal), time.Duration(retryMs) * time.Millisecond, nil
+}
+
+// parseTriple unpacks the {allowed, remaining, reset_or_retry_ms} array
+// both rate-limiting scripts return.
+func parseTriple(res interface{}) (allowed, remaining, thirdMs int64, err error) {
+	vals, ok := res.([]interface{})
+	if !ok || len(vals) != 3 {
+		return 0, 0, 0, fmt.Errorf("unexpected script result: %v", res)
+	}
+
+	allowed, ok1 := vals[0].(int64)
+	remaining, ok2 := vals[1].(int64)
+	thirdMs, ok3 := vals[2].(int64)
+	if !ok1 || !ok2 || !ok3 {
+		return 0, 0, 0, fmt.Errorf("unexpected script result types: %v", res)
+	}
+
+	return allowed, remaining, thirdMs, nil
+}
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
@@ -13,6 +16,12 @@ import (
 type RedisCache struct {
 	client *redis.Client
 	cfg    *config.Config
+
+	// consumerWG tracks in-flight ConsumeStream loops so CloseConsumers can
+	// wait for the current handler call to finish before returning.
+	consumerWG       sync.WaitGroup
+	stopConsume      chan struct{}
+	closeConsumeOnce sync.Once
 }
 
 // New creates a new Redis cache client
@@ -37,8 +46,9 @@ func New(cfg *config.Config) (*RedisCache, error) {
 	}
 
 	return &RedisCache{
-		client: client,
-		cfg:    cfg,
+		client:      client,
+		cfg:         cfg,
+		stopConsume: make(chan struct{}),
 	}, nil
 }
 
@@ -81,6 +91,34 @@ func (c *RedisCache) SetURL(ctx context.Context, shortCode, destinationURL strin
 	return nil
 }
 
+// SetURLOwner caches the Auth0 sub of a short code's owner alongside the
+// destination URL, so the redirect hot path can resolve a tenant for
+// per-tenant rate limiting (see internal/limits) without a DB join on
+// every request.
+func (c *RedisCache) SetURLOwner(ctx context.Context, shortCode, ownerSub string) error {
+	key := "url:owner:" + shortCode
+	if err := c.client.Set(ctx, key, ownerSub, c.cfg.URLCacheTTL).Err(); err != nil {
+		return fmt.Errorf("cache set error: %w", err)
+	}
+	return nil
+}
+
+// GetURLOwner retrieves the cached owner sub for a short code, set by
+// SetURLOwner. Callers should treat a cache miss as "unknown tenant"
+// rather than an error, since limits.Overrides.For("") safely falls
+// back to the service defaults.
+func (c *RedisCache) GetURLOwner(ctx context.Context, shortCode string) (string, error) {
+	key := "url:owner:" + shortCode
+	val, err := c.client.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", fmt.Errorf("cache miss")
+	}
+	if err != nil {
+		return "", fmt.Errorf("cache get error: %w", err)
+	}
+	return val, nil
+}
+
 // DeleteURL removes a URL from cache
 func (c *RedisCache) DeleteURL(ctx context.Context, shortCode string) error {
 	key := "url:" + shortCode
@@ -107,27 +145,16 @@ func (c *RedisCache) DeleteQRCodes(ctx context.Context, urlID string) error {
 	return nil
 }
 
-// GetQRCode retrieves a QR code from cache
-func (c *RedisCache) GetQRCode(ctx context.Context, urlID, format string, size int) ([]byte, error) {
-	key := fmt.Sprintf("qr:%s:%s:%d", urlID, format, size)
-	val, err := c.client.Get(ctx, key).Bytes()
-	if err == redis.Nil {
-		return nil, fmt.Errorf("cache miss")
-	}
-	if err != nil {
-		return nil, fmt.Errorf("cache get error: %w", err)
+// QRCodeKey builds the cache key for a QR code variant, shared with
+// GetOrCompute so callers don't have to duplicate the key format. optsFingerprint
+// distinguishes renders of the same urlID/format/size made with different
+// services.QROptions (colors, error-correction level, logo, ...); pass ""
+// for the default-options render.
+func QRCodeKey(urlID, format string, size int, optsFingerprint string) string {
+	if optsFingerprint == "" {
+		return fmt.Sprintf("qr:%s:%s:%d", urlID, format, size)
 	}
-	return val, nil
-}
-
-// SetQRCode caches a QR code
-func (c *RedisCache) SetQRCode(ctx context.Context, urlID, format string, size int, data []byte) error {
-	key := fmt.Sprintf("qr:%s:%s:%d", urlID, format, size)
-	err := c.client.Set(ctx, key, data, c.cfg.QRCacheTTL).Err()
-	if err != nil {
-		return fmt.Errorf("cache set error: %w", err)
-	}
-	return nil
+	return fmt.Sprintf("qr:%s:%s:%d:%s", urlID, format, size, optsFingerprint)
 }
 
 // RecordClickEvent adds a click event to the Redis Stream for async processing
@@ -142,24 +169,147 @@ func (c *RedisCache) RecordClickEvent(ctx context.Context, event map[string]inte
 	return nil
 }
 
-// CheckRateLimit implements token bucket rate limiting
-// Returns true if request is allowed, false if rate limited
-func (c *RedisCache) CheckRateLimit(ctx context.Context, key string, limit int, window time.Duration) (bool, error) {
-	rateLimitKey := "ratelimit:" + key
+// EnqueueJSON JSON-marshals val and adds it as the "data" field of a new
+// entry on stream, following the same single-field convention
+// RecordClickEvent uses for analytics:stream. It's how CreateExport hands
+// an analytics export job off to worker.Exporter via analytics:exports.
+func (c *RedisCache) EnqueueJSON(ctx context.Context, stream string, val interface{}) error {
+	data, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Errorf("marshal stream payload: %w", err)
+	}
+	err = c.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: stream,
+		Values: map[string]interface{}{"data": string(data)},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("stream add error: %w", err)
+	}
+	return nil
+}
+
+// userURLsLastEditKey builds the key SetUserURLsLastEdit/
+// GetUserURLsLastEdit use to track when a user last created, updated, or
+// deleted a URL - see ListURLs' conditional-request short circuit.
+func userURLsLastEditKey(userSub string) string {
+	return "user:urls:last_edit:" + userSub
+}
+
+// SetUserURLsLastEdit records the current time as userSub's most recent
+// URL edit. It's never given a TTL - unlike the per-code caches above,
+// it has to remain valid indefinitely for ETag comparisons to stay
+// correct, not just for a performance window.
+func (c *RedisCache) SetUserURLsLastEdit(ctx context.Context, userSub string, at time.Time) error {
+	if err := c.client.Set(ctx, userURLsLastEditKey(userSub), at.UnixNano(), 0).Err(); err != nil {
+		return fmt.Errorf("cache set error: %w", err)
+	}
+	return nil
+}
+
+// GetUserURLsLastEdit returns the timestamp set by SetUserURLsLastEdit.
+// A cache miss means "unknown", not "never edited" - callers should
+// treat it like any other cache miss and fall back to computing
+// freshness from Postgres instead.
+func (c *RedisCache) GetUserURLsLastEdit(ctx context.Context, userSub string) (time.Time, error) {
+	val, err := c.client.Get(ctx, userURLsLastEditKey(userSub)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, fmt.Errorf("cache miss")
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cache get error: %w", err)
+	}
+	return time.Unix(0, val), nil
+}
+
+// idempotencyTTL is how long an IdempotencyRecord stays valid - long
+// enough to cover a client's retry window, short enough that keys don't
+// accumulate forever for one-off requests.
+const idempotencyTTL = 24 * time.Hour
 
-	// Increment counter
-	count, err := c.client.Incr(ctx, rateLimitKey).Result()
+// idempotencyReservationTTL bounds how long ReserveIdempotencyKey's
+// placeholder blocks a key if the reserving request dies before calling
+// SetIdempotencyRecord (crash, deploy, timeout) - short enough that a
+// stuck reservation self-heals well within a client's retry window.
+const idempotencyReservationTTL = 30 * time.Second
+
+// ErrIdempotencyKeyNotFound is returned by GetIdempotencyRecord when no
+// record - pending or completed - exists yet for key/userSub, unlike the
+// other Get* methods in this file which report a plain cache miss: callers
+// here need to tell "never seen" apart from "lookup failed" so they know
+// it's safe to call ReserveIdempotencyKey.
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+// IdempotencyRecord is what SetIdempotencyRecord/GetIdempotencyRecord
+// store for one Idempotency-Key: enough to detect a reused key with a
+// different body, and to replay the original response verbatim. Pending
+// is set only on the placeholder ReserveIdempotencyKey writes before the
+// handler's side effects have run; SetIdempotencyRecord always overwrites
+// it with a completed (Pending: false) record.
+type IdempotencyRecord struct {
+	RequestHash  string `json:"request_hash"`
+	Pending      bool   `json:"pending,omitempty"`
+	StatusCode   int    `json:"status_code"`
+	ContentType  string `json:"content_type"`
+	ResponseBody []byte `json:"response_body"`
+}
+
+// idempotencyRecordKey builds the key SetIdempotencyRecord/
+// GetIdempotencyRecord use, scoped per-user so two accounts can never
+// collide on the same client-chosen key.
+func idempotencyRecordKey(userSub, key string) string {
+	return "idempotency:" + userSub + ":" + key
+}
+
+// ReserveIdempotencyKey atomically claims key for userSub before the
+// handler's side effects run, so two concurrent retries can't both
+// observe ErrIdempotencyKeyNotFound and both execute. It returns false,
+// without error, if the key is already reserved or completed by another
+// request - the caller should treat that as a conflict, not a second
+// cache miss.
+func (c *RedisCache) ReserveIdempotencyKey(ctx context.Context, userSub, key, requestHash string) (bool, error) {
+	data, err := json.Marshal(&IdempotencyRecord{RequestHash: requestHash, Pending: true})
+	if err != nil {
+		return false, fmt.Errorf("marshal idempotency record: %w", err)
+	}
+	ok, err := c.client.SetNX(ctx, idempotencyRecordKey(userSub, key), data, idempotencyReservationTTL).Result()
 	if err != nil {
-		return false, fmt.Errorf("rate limit error: %w", err)
+		return false, fmt.Errorf("cache set error: %w", err)
 	}
+	return ok, nil
+}
 
-	// Set expiry on first request
-	if count == 1 {
-		c.client.Expire(ctx, rateLimitKey, window)
+// SetIdempotencyRecord stores rec for key, scoped to userSub, for
+// idempotencyTTL, overwriting any reservation placeholder left by
+// ReserveIdempotencyKey.
+func (c *RedisCache) SetIdempotencyRecord(ctx context.Context, userSub, key string, rec *IdempotencyRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshal idempotency record: %w", err)
 	}
+	if err := c.client.Set(ctx, idempotencyRecordKey(userSub, key), data, idempotencyTTL).Err(); err != nil {
+		return fmt.Errorf("cache set error: %w", err)
+	}
+	return nil
+}
 
-	// Check if over limit
-	return count <= int64(limit), nil
+// GetIdempotencyRecord retrieves the record stored by SetIdempotencyRecord
+// or ReserveIdempotencyKey for key/userSub. Unlike this file's other Get*
+// methods, a miss is reported as ErrIdempotencyKeyNotFound specifically
+// (not a generic "cache miss" error) so callers can tell it apart from a
+// real lookup failure.
+func (c *RedisCache) GetIdempotencyRecord(ctx context.Context, userSub, key string) (*IdempotencyRecord, error) {
+	val, err := c.client.Get(ctx, idempotencyRecordKey(userSub, key)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cache get error: %w", err)
+	}
+	var rec IdempotencyRecord
+	if err := json.Unmarshal(val, &rec); err != nil {
+		return nil, fmt.Errorf("unmarshal idempotency record: %w", err)
+	}
+	return &rec, nil
 }
 
 // IncrementCounter atomically increments a distributed counter
@@ -176,33 +326,3 @@ func (c *RedisCache) Stats(ctx context.Context) (*redis.PoolStats, error) {
 	stats := c.client.PoolStats()
 	return stats, nil
 }
-
-// ReadStream reads events from a Redis Stream
-func (c *RedisCache) ReadStream(ctx context.Context, stream string, count int) ([]map[string]interface{}, error) {
-	// Read from stream with XREAD
-	result, err := c.client.XRead(ctx, &redis.XReadArgs{
-		Streams: []string{stream, "0"},
-		Count:   int64(count),
-		Block:   0, // Non-blocking
-	}).Result()
-	if err != nil {
-		return nil, err
-	}
-
-	events := make([]map[string]interface{}, 0)
-	for _, stream := range result {
-		for _, msg := range stream.Messages {
-			event := make(map[string]interface{})
-			for k, v := range msg.Values {
-				event[k] = v
-			}
-			event["_id"] = msg.ID
-			events = append(events, event)
-
-			// Acknowledge and delete the message
-			c.client.XDel(ctx, stream.Stream, msg.ID)
-		}
-	}
-
-	return events, nil
-}
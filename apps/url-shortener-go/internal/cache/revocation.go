@@ -0,0 +1,138 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	revokedSetKey    = "auth:revoked"
+	sessionKeyPrefix = "auth:sessions:"
+)
+
+// RevokeTokens adds sub/jti identifiers to the revocation set, so
+// auth.Middleware rejects any token carrying one of them even if its
+// signature is still valid. The set's TTL is refreshed to maxTokenLifetime
+// on every call, since a revoked identifier only needs to be remembered
+// for as long as a token issued before the revocation could still work.
+func (c *RedisCache) RevokeTokens(ctx context.Context, ids []string, maxTokenLifetime time.Duration) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	members := make([]interface{}, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+
+	pipe := c.client.TxPipeline()
+	pipe.SAdd(ctx, revokedSetKey, members...)
+	pipe.Expire(ctx, revokedSetKey, maxTokenLifetime)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("revoke tokens: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether sub or jti has been explicitly revoked.
+// Either may be empty; at least one must be non-empty for the check to
+// mean anything.
+func (c *RedisCache) IsRevoked(ctx context.Context, sub, jti string) (bool, error) {
+	for _, id := range []string{sub, jti} {
+		if id == "" {
+			continue
+		}
+		revoked, err := c.client.SIsMember(ctx, revokedSetKey, id).Result()
+		if err != nil {
+			return false, fmt.Errorf("check revocation: %w", err)
+		}
+		if revoked {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// sessionKey builds the key recording sub's session identified by jti,
+// falling back to sub itself for providers whose tokens don't carry one.
+func sessionKey(sub, jti string) string {
+	id := jti
+	if id == "" {
+		id = sub
+	}
+	return sessionKeyPrefix + sub + ":" + id
+}
+
+// RecordSession records that sub has an active session expiring at
+// expiresAt, so PurgeLapsed and PurgeUserSessions can later find it. The
+// key's own TTL already expires it at the right time; the recorded value
+// lets PurgeLapsed identify already-lapsed entries without waiting on
+// Redis's own expiry sweep. Callers that don't know a token's expiry
+// (e.g. GitHub's opaque access tokens) should skip recording rather than
+// pass a zero time.
+func (c *RedisCache) RecordSession(ctx context.Context, sub, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	key := sessionKey(sub, jti)
+	value := strconv.FormatInt(expiresAt.Unix(), 10)
+	if err := c.client.Set(ctx, key, value, ttl).Err(); err != nil {
+		return fmt.Errorf("record session: %w", err)
+	}
+	return nil
+}
+
+// PurgeLapsed scans auth:sessions:* keys and deletes any whose recorded
+// expiry has already passed. Redis would reclaim these on its own via the
+// key TTL; this exists so an operator can force an immediate sweep, e.g.
+// right after shortening MaxTokenLifetime.
+func (c *RedisCache) PurgeLapsed(ctx context.Context, now time.Time) (int, error) {
+	purged := 0
+
+	iter := c.client.Scan(ctx, 0, sessionKeyPrefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		val, err := c.client.Get(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+		exp, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			continue
+		}
+		if time.Unix(exp, 0).Before(now) {
+			if err := c.client.Del(ctx, key).Err(); err != nil {
+				return purged, fmt.Errorf("delete lapsed session %s: %w", key, err)
+			}
+			purged++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return purged, fmt.Errorf("scan sessions: %w", err)
+	}
+
+	return purged, nil
+}
+
+// PurgeUserSessions deletes every recorded session belonging to sub, e.g.
+// as part of a full account purge.
+func (c *RedisCache) PurgeUserSessions(ctx context.Context, sub string) (int, error) {
+	purged := 0
+
+	iter := c.client.Scan(ctx, 0, sessionKeyPrefix+sub+":*", 0).Iterator()
+	for iter.Next(ctx) {
+		if err := c.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return purged, fmt.Errorf("delete session %s: %w", iter.Val(), err)
+		}
+		purged++
+	}
+	if err := iter.Err(); err != nil {
+		return purged, fmt.Errorf("scan user sessions: %w", err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,317 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Event is a single message read off a Redis Stream.
+type Event struct {
+	ID     string
+	Values map[string]interface{}
+}
+
+const deadLetterStream = "analytics:stream:dlq"
+
+// StreamConsumerConfig configures a ConsumeStream run.
+type StreamConsumerConfig struct {
+	// BatchSize bounds how many messages are read per XREADGROUP call.
+	BatchSize int
+	// BlockTimeout is how long XREADGROUP blocks waiting for new messages.
+	BlockTimeout time.Duration
+	// ClaimInterval is how often the pending-entry reclaim pass runs.
+	ClaimInterval time.Duration
+	// ClaimMinIdle is the minimum idle time before a pending message is
+	// considered abandoned and eligible for XCLAIM.
+	ClaimMinIdle time.Duration
+	// MaxDeliveries is how many times a message may be claimed before it is
+	// moved to the dead-letter stream.
+	MaxDeliveries int64
+}
+
+func (c StreamConsumerConfig) withDefaults() StreamConsumerConfig {
+	if c.BatchSize <= 0 {
+		c.BatchSize = 100
+	}
+	if c.BlockTimeout <= 0 {
+		c.BlockTimeout = 5 * time.Second
+	}
+	if c.ClaimInterval <= 0 {
+		c.ClaimInterval = 30 * time.Second
+	}
+	if c.ClaimMinIdle <= 0 {
+		c.ClaimMinIdle = time.Minute
+	}
+	if c.MaxDeliveries <= 0 {
+		c.MaxDeliveries = 5
+	}
+	return c
+}
+
+// EnsureConsumerGroup creates the consumer group for a stream if it doesn't
+// already exist, creating the stream itself via MKSTREAM if necessary.
+func (c *RedisCache) EnsureConsumerGroup(ctx context.Context, stream, group string) error {
+	err := c.client.XGroupCreateMkStream(ctx, stream, group, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return fmt.Errorf("create consumer group %s/%s: %w", stream, group, err)
+	}
+	return nil
+}
+
+// ConsumeStream runs a consumer-group based read loop against stream/group
+// as consumer. It backfills any entries already pending for this consumer
+// (from a prior crash) before switching to live `>` reads, explicitly XACKs
+// after handler returns successfully, and periodically reclaims messages
+// abandoned by dead consumers via XPENDING+XCLAIM. It blocks until ctx is
+// cancelled or CloseConsumers is called, then drains the in-flight handler call.
+func (c *RedisCache) ConsumeStream(ctx context.Context, stream, group, consumer string, cfg StreamConsumerConfig, handler func([]Event) error) error {
+	cfg = cfg.withDefaults()
+
+	if err := c.EnsureConsumerGroup(ctx, stream, group); err != nil {
+		return err
+	}
+
+	c.consumerWG.Add(1)
+	defer c.consumerWG.Done()
+
+	claimTicker := time.NewTicker(cfg.ClaimInterval)
+	defer claimTicker.Stop()
+
+	// Backfill: drain anything already assigned to this consumer from a
+	// previous run before moving on to new messages.
+	if err := c.backfillPending(ctx, stream, group, consumer, cfg, handler); err != nil {
+		log.Printf("stream backfill error: %v", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-c.stopConsume:
+			return nil
+		case <-claimTicker.C:
+			if err := c.reclaimPending(ctx, stream, group, consumer, cfg, handler); err != nil {
+				log.Printf("stream reclaim error: %v", err)
+			}
+		default:
+		}
+
+		result, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, ">"},
+			Count:    int64(cfg.BatchSize),
+			Block:    cfg.BlockTimeout,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+				continue
+			}
+			if ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("XREADGROUP error: %v", err)
+			continue
+		}
+
+		for _, s := range result {
+			events := toEvents(s.Messages)
+			if len(events) == 0 {
+				continue
+			}
+			if err := handler(events); err != nil {
+				log.Printf("stream handler error, leaving %d messages pending: %v", len(events), err)
+				continue
+			}
+			c.ackEvents(ctx, stream, group, events)
+		}
+	}
+}
+
+// backfillPending reads this consumer's own pending entries (history "0")
+// once at startup, so messages in flight when a previous instance crashed
+// get reprocessed before we move on to new messages.
+func (c *RedisCache) backfillPending(ctx context.Context, stream, group, consumer string, cfg StreamConsumerConfig, handler func([]Event) error) error {
+	for {
+		result, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    group,
+			Consumer: consumer,
+			Streams:  []string{stream, "0"},
+			Count:    int64(cfg.BatchSize),
+		}).Result()
+		if err != nil {
+			return err
+		}
+
+		total := 0
+		for _, s := range result {
+			events := toEvents(s.Messages)
+			total += len(events)
+			if len(events) == 0 {
+				continue
+			}
+			if err := handler(events); err != nil {
+				return fmt.Errorf("backfill handler: %w", err)
+			}
+			c.ackEvents(ctx, stream, group, events)
+		}
+
+		if total < cfg.BatchSize {
+			return nil
+		}
+	}
+}
+
+// reclaimPending finds messages idle for longer than cfg.ClaimMinIdle
+// (abandoned by a dead consumer), claims them for this consumer, and moves
+// them to the dead-letter stream once they've been claimed MaxDeliveries times.
+func (c *RedisCache) reclaimPending(ctx context.Context, stream, group, consumer string, cfg StreamConsumerConfig, handler func([]Event) error) error {
+	pending, err := c.client.XPendingExt(ctx, &redis.XPendingExtArgs{
+		Stream: stream,
+		Group:  group,
+		Idle:   cfg.ClaimMinIdle,
+		Start:  "-",
+		End:    "+",
+		Count:  int64(cfg.BatchSize),
+	}).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil
+		}
+		return fmt.Errorf("xpending: %w", err)
+	}
+
+	var toDeadLetter []string
+	var toClaim []string
+	for _, p := range pending {
+		if p.RetryCount >= cfg.MaxDeliveries {
+			toDeadLetter = append(toDeadLetter, p.ID)
+			continue
+		}
+		toClaim = append(toClaim, p.ID)
+	}
+
+	if len(toDeadLetter) > 0 {
+		c.deadLetter(ctx, stream, group, toDeadLetter)
+	}
+
+	if len(toClaim) == 0 {
+		return nil
+	}
+
+	msgs, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  cfg.ClaimMinIdle,
+		Messages: toClaim,
+	}).Result()
+	if err != nil {
+		return fmt.Errorf("xclaim: %w", err)
+	}
+
+	events := toEvents(msgs)
+	if len(events) == 0 {
+		return nil
+	}
+	if err := handler(events); err != nil {
+		log.Printf("stream handler error for reclaimed messages, leaving pending: %v", err)
+		return nil
+	}
+	c.ackEvents(ctx, stream, group, events)
+	return nil
+}
+
+// deadLetter claims+acks entries just long enough to move a copy into
+// analytics:stream:dlq, then acknowledges the originals so they stop
+// appearing in XPENDING.
+func (c *RedisCache) deadLetter(ctx context.Context, stream, group string, ids []string) {
+	msgs, err := c.client.XClaim(ctx, &redis.XClaimArgs{
+		Stream:   stream,
+		Group:    group,
+		Consumer: "deadletter-mover",
+		MinIdle:  0,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		log.Printf("dead-letter claim error: %v", err)
+		return
+	}
+
+	for _, msg := range msgs {
+		values := make(map[string]interface{}, len(msg.Values)+1)
+		for k, v := range msg.Values {
+			values[k] = v
+		}
+		values["original_id"] = msg.ID
+		values["original_stream"] = stream
+
+		if err := c.client.XAdd(ctx, &redis.XAddArgs{Stream: deadLetterStream, Values: values}).Err(); err != nil {
+			log.Printf("dead-letter xadd error: %v", err)
+			continue
+		}
+		if err := c.client.XAck(ctx, stream, group, msg.ID).Err(); err != nil {
+			log.Printf("dead-letter ack error: %v", err)
+		}
+	}
+}
+
+func (c *RedisCache) ackEvents(ctx context.Context, stream, group string, events []Event) {
+	ids := make([]string, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	if err := c.client.XAck(ctx, stream, group, ids...).Err(); err != nil {
+		log.Printf("XACK error: %v", err)
+	}
+}
+
+func toEvents(msgs []redis.XMessage) []Event {
+	events := make([]Event, 0, len(msgs))
+	for _, msg := range msgs {
+		events = append(events, Event{ID: msg.ID, Values: msg.Values})
+	}
+	return events
+}
+
+// CloseConsumers signals any running ConsumeStream loops to stop and waits
+// for their current handler call to finish before returning.
+func (c *RedisCache) CloseConsumers() {
+	c.closeConsumeOnce.Do(func() { close(c.stopConsume) })
+	c.consumerWG.Wait()
+}
+
+// StreamStats summarizes a consumer group's backlog and the shared
+// dead-letter stream's depth, for a debug/monitoring endpoint.
+type StreamStats struct {
+	Pending    int64
+	DeadLetter int64
+}
+
+// StreamDebugStats returns the number of entries still pending ack for
+// stream/group (per XPENDING's summary form) and analytics:stream:dlq's
+// current length.
+func (c *RedisCache) StreamDebugStats(ctx context.Context, stream, group string) (StreamStats, error) {
+	summary, err := c.client.XPending(ctx, stream, group).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return StreamStats{}, fmt.Errorf("xpending summary: %w", err)
+	}
+
+	var pending int64
+	if summary != nil {
+		pending = summary.Count
+	}
+
+	dlqLen, err := c.client.XLen(ctx, deadLetterStream).Result()
+	if err != nil {
+		return StreamStats{}, fmt.Errorf("xlen dead-letter stream: %w", err)
+	}
+
+	return StreamStats{Pending: pending, DeadLetter: dlqLen}, nil
+}
@@ -15,6 +15,19 @@ type Config struct {
 	// Base URL for short links
 	BaseURL string
 
+	// ProxyProtocolEnabled makes cmd/server wrap its listener in
+	// proxyproto.Listener so a connection from ProxyProtocolTrustedCIDRs
+	// can carry the real client address (PROXY protocol v1/v2) past an L4
+	// load balancer. Disabled by default - clicks are attributed to
+	// whatever connects to the listener until this is turned on.
+	ProxyProtocolEnabled bool
+
+	// ProxyProtocolTrustedCIDRs is a comma-separated list of CIDRs (e.g.
+	// the load balancer subnet) allowed to supply a PROXY protocol
+	// header. A connection from outside these ranges is never parsed for
+	// one, so an untrusted client can't spoof its IP by sending its own.
+	ProxyProtocolTrustedCIDRs string
+
 	// Database configuration
 	PostgresHost     string
 	PostgresPort     string
@@ -29,8 +42,9 @@ type Config struct {
 	RedisDB   int
 
 	// Cache TTLs
-	URLCacheTTL time.Duration
-	QRCacheTTL  time.Duration
+	URLCacheTTL        time.Duration
+	QRCacheTTL         time.Duration
+	CodeExistsCacheTTL time.Duration
 
 	// Rate limiting
 	RateLimitCreatePerMinute   int
@@ -40,15 +54,258 @@ type Config struct {
 	Auth0Domain   string
 	Auth0Audience string
 
+	// AuthRevocationFailOpen controls what tokenMiddleware does when the
+	// revocation check itself fails (e.g. Redis is unreachable), as
+	// opposed to succeeding and reporting the token revoked or not. The
+	// default is fail closed (401) - revocation is a security control, so
+	// an infrastructure blip shouldn't silently re-enable every revoked
+	// token. Only flip this for a deployment that has decided availability
+	// matters more than that guarantee.
+	AuthRevocationFailOpen bool
+
+	// GitHub OAuth configuration (authorization-code flow identity provider)
+	GitHubClientID     string
+	GitHubClientSecret string
+	GitHubRedirectURL  string
+
+	// Generic OIDC configuration (discovery-based identity provider)
+	OIDCProviderName string
+	OIDCDiscoveryURL string
+	OIDCAudience     string
+
+	// AuthBackend pins auth.Middleware to a single identity backend
+	// ("auth0", "oidc", "apikey", or "forward") instead of its default
+	// behavior of trying every configured provider in turn. Empty keeps
+	// that default.
+	AuthBackend string
+
+	// APIKeyID/APIKeySecret configure the static API-key backend:
+	// requests authenticate with "Bearer <APIKeyID>.<hex HMAC-SHA256 of
+	// APIKeyID keyed by APIKeySecret>", so the key can be distributed
+	// without ever transmitting APIKeySecret itself.
+	APIKeyID     string
+	APIKeySecret string
+
+	// ForwardAuthURL is the external endpoint auth.Middleware calls for
+	// the "forward" backend (à la Traefik's forward-auth): the incoming
+	// request's Authorization and Cookie headers are forwarded to it, and
+	// a 2xx response authenticates the request.
+	ForwardAuthURL string
+
+	// ForwardAuthHeaders is a comma-separated allow-list of response
+	// headers (e.g. "X-Auth-User,X-Auth-Groups,X-Auth-Tenant") copied
+	// from a successful forward-auth response onto the request context.
+	// The first header is used as the resolved subject.
+	ForwardAuthHeaders string
+
+	// ForwardAuthTimeout bounds the forward-auth subrequest.
+	ForwardAuthTimeout time.Duration
+
 	// GeoIP configuration
 	GeoIPDBPath string
 
+	// GeoIPReloadInterval is how often enrich.Enricher checks GeoIPDBPath's
+	// mtime to hot-reload the GeoLite2 database.
+	GeoIPReloadInterval time.Duration
+
+	// UAParserRegexesPath configures enrich.Enricher's uap-go regex file.
+	// Empty degrades ParseUA to heuristic substring matching.
+	UAParserRegexesPath string
+
 	// Short code configuration
 	ShortCodeMinLength int
 	DefaultTTLDays     int
 
 	// Security
 	IPHashSalt string
+
+	// MaxTokenLifetime bounds how long a revoked token/session entry needs
+	// to be remembered for - once this much time has passed since a token
+	// was revoked, no token issued before the revocation could still be
+	// unexpired anyway.
+	MaxTokenLifetime time.Duration
+
+	// PrometheusURL is the base URL of the Prometheus server this service
+	// can query to read back its own exported metrics. Leave empty to
+	// disable the self-adaptive redirect limiter and /v1/admin/alerts.
+	PrometheusURL string
+
+	// LatencySLOSeconds is the P95 redirect-handler latency budget the
+	// self-adaptive limiter tries to stay under.
+	LatencySLOSeconds float64
+
+	// RateLimitRedirectMinPerSecond is the floor the self-adaptive
+	// limiter will tighten RateLimitRedirectPerSecond down to.
+	RateLimitRedirectMinPerSecond int
+
+	// AdaptiveLimiterPollInterval is how often the self-adaptive limiter
+	// re-queries Prometheus and adjusts the redirect rate limit.
+	AdaptiveLimiterPollInterval time.Duration
+
+	// LimitsFilePath is a YAML file of per-tenant limit overrides, hot
+	// reloaded on change. Empty disables per-tenant overrides.
+	LimitsFilePath string
+
+	// RedirectDeadline bounds the entire GET /{code} hot path, including
+	// the database fallback on a cache miss.
+	RedirectDeadline time.Duration
+
+	// ClickEventWorkers is the number of background workers draining the
+	// click-event/cache-write-through queue.
+	ClickEventWorkers int
+
+	// ClickEventQueueSize bounds how many pending click events/cache
+	// writes may queue before new ones are dropped.
+	ClickEventQueueSize int
+
+	// ClickIngestFlushInterval is the longest a buffered click batch waits
+	// before being flushed to TimescaleDB via CopyFrom.
+	ClickIngestFlushInterval time.Duration
+
+	// ClickIngestMaxBatchSize flushes a click batch early, without waiting
+	// out ClickIngestFlushInterval, once it reaches this many rows.
+	ClickIngestMaxBatchSize int
+
+	// ClickIngestQueueSize bounds how many clicks may be buffered awaiting
+	// a batch flush before Enqueue starts dropping them.
+	ClickIngestQueueSize int
+
+	// AuditRetentionDays, if greater than zero, installs a TimescaleDB
+	// retention policy on url_audit at startup that drops chunks older
+	// than this many days. Zero leaves audit rows to accumulate forever.
+	AuditRetentionDays int
+
+	// SafetyCheckEnabled gates the content-classification gate on
+	// CreateURL. Disabled by default since it adds an outbound fetch of
+	// the destination to every create.
+	SafetyCheckEnabled bool
+
+	// SafetyCheckTimeout bounds the robots.txt, HEAD, and GET requests
+	// CreateURL's content-classification gate issues against a
+	// destination URL.
+	SafetyCheckTimeout time.Duration
+
+	// SafetyCheckMaxBodyBytes caps how much of a destination's response
+	// body the content-classification gate reads before classifying it.
+	SafetyCheckMaxBodyBytes int64
+
+	// AllowPrivateTargets disables the SSRF guard's IP-range checks
+	// entirely, for on-prem deployments that legitimately shorten links
+	// to internal hosts. Leave false everywhere else.
+	AllowPrivateTargets bool
+
+	// SSRFResolveCacheTTL bounds how long ssrf.Guard reuses a hostname's
+	// resolved IPs before looking it up again, so repeated checks of the
+	// same destination (shorten time, then a safety-check fetch) don't
+	// double DNS load.
+	SSRFResolveCacheTTL time.Duration
+
+	// ClickStreamEnabled gates GET /v1/urls/:id/clicks/stream and installs
+	// the clicks_notify_trigger that backs it at startup. Disabled by
+	// default since it adds a pg_notify to every click insert.
+	ClickStreamEnabled bool
+
+	// ClickStreamReplayWindow bounds how far back a reconnecting SSE
+	// client's Last-Event-ID may ask the StreamClicks handler to
+	// replay from the clicks hypertable.
+	ClickStreamReplayWindow time.Duration
+
+	// ClickStreamHeartbeatInterval is how often the SSE stream writes a
+	// heartbeat comment to keep idle connections (and the proxies in
+	// front of them) from timing out.
+	ClickStreamHeartbeatInterval time.Duration
+
+	// LiveAnalyticsEnabled gates GET /v1/urls/:id/analytics/live and the
+	// flusher's per-click publish to the realtime hub. Unlike
+	// ClickStreamEnabled this has no schema footprint - it's Redis
+	// Pub/Sub only, no trigger to install - so it defaults to on.
+	LiveAnalyticsEnabled bool
+
+	// FlusherDebugAddr is the address cmd/worker serves GET /debug/flusher
+	// (pending-entries and dead-letter queue depth) on. Empty disables it.
+	FlusherDebugAddr string
+
+	// AdminMetricsAddr is the address GET /metrics (and, if PprofEnabled,
+	// /debug/pprof/) is served on, separate from the main app/worker
+	// listener so scraping never competes with redirect traffic.
+	AdminMetricsAddr string
+
+	// PprofEnabled attaches net/http/pprof's handlers to the admin
+	// metrics listener. Disabled by default since profiling endpoints
+	// shouldn't be reachable without deliberately opting in.
+	PprofEnabled bool
+
+	// S3Endpoint, S3AccessKey, S3SecretKey, and S3Bucket configure the
+	// S3-compatible object store analytics exports are uploaded to.
+	// S3Endpoint empty disables POST /v1/urls/:id/analytics/export.
+	S3Endpoint  string
+	S3AccessKey string
+	S3SecretKey string
+	S3Bucket    string
+	S3UseSSL    bool
+
+	// ExportJobTTL bounds how long a completed (or failed) export job's
+	// status stays readable from GET /v1/exports/:job_id before Redis
+	// expires it - this is the export subsystem's cleanup mechanism.
+	ExportJobTTL time.Duration
+
+	// ExportDownloadTTL is how long a completed export's presigned
+	// download URL remains valid.
+	ExportDownloadTTL time.Duration
+
+	// ACMEEnabled turns on automatic TLS for verified CustomDomains via
+	// golang.org/x/crypto/acme/autocert, with certs and the ACME account
+	// key stored in db.DB (see internal/acmecache) so every pod shares
+	// them. The original ask was DNS-01 challenges so wildcard/behind-CDN
+	// domains could get certs without exposing port 80/443 to the CA,
+	// but autocert only implements TLS-ALPN-01 and HTTP-01 - DNS-01 isn't
+	// one of its supported challenge types - so issuance here uses
+	// TLS-ALPN-01 (autocert's default, served over the same TLS listener
+	// as normal traffic) with HTTP-01 available as the configurable
+	// fallback below for domains/CDNs that can't reach this process on
+	// its TLS port directly.
+	ACMEEnabled bool
+
+	// ACMEEmail is the contact address autocert registers with the ACME
+	// CA for expiry/problem notifications.
+	ACMEEmail string
+
+	// ACMEDirectoryURL overrides the ACME CA directory URL, e.g. Let's
+	// Encrypt's staging directory for tests. Empty uses autocert's
+	// built-in default (Let's Encrypt production).
+	ACMEDirectoryURL string
+
+	// ACMEHTTPFallbackEnabled starts a second listener serving
+	// autocert.Manager.HTTPHandler, for domains that terminate HTTP-01
+	// challenges on port 80 instead of completing them over TLS-ALPN-01.
+	ACMEHTTPFallbackEnabled bool
+
+	// ACMEHTTPFallbackAddr is the address the HTTP-01 fallback listener
+	// binds, when ACMEHTTPFallbackEnabled.
+	ACMEHTTPFallbackAddr string
+
+	// BulkImportMaxRows caps how many rows POST /v1/urls/import accepts
+	// in a single upload.
+	BulkImportMaxRows int
+
+	// BulkImportConcurrency bounds how many rows of a bulk import are
+	// validated and created concurrently, so a large upload can't exhaust
+	// the database/Redis connection pools the way an unbounded fan-out
+	// would.
+	BulkImportConcurrency int
+
+	// BulkExportBatchSize is how many URLs GET /v1/urls/export reads from
+	// Postgres per page while streaming a user's full export.
+	BulkExportBatchSize int
+
+	// URLBgWorkers is the number of background workers draining
+	// URLHandler's bg.Scheduler (cache warm/invalidate after
+	// CreateURL/UpdateURL/DeleteURL).
+	URLBgWorkers int
+
+	// URLBgQueueSize bounds how many pending cache warm/invalidate tasks
+	// URLHandler's bg.Scheduler will hold before dropping new ones.
+	URLBgQueueSize int
 }
 
 // Load loads configuration from environment variables with defaults
@@ -61,6 +318,10 @@ func Load() *Config {
 		// Base URL
 		BaseURL: getEnv("BASE_URL", "http://localhost:8000"),
 
+		// PROXY protocol
+		ProxyProtocolEnabled:      getEnvBool("PROXY_PROTOCOL_ENABLED", false),
+		ProxyProtocolTrustedCIDRs: getEnv("PROXY_PROTOCOL_TRUSTED_CIDRS", ""),
+
 		// Database
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
@@ -75,8 +336,9 @@ func Load() *Config {
 		RedisDB:   getEnvInt("REDIS_DB", 0),
 
 		// Cache TTLs
-		URLCacheTTL: time.Duration(getEnvInt("URL_CACHE_TTL", 3600)) * time.Second,
-		QRCacheTTL:  time.Duration(getEnvInt("QR_CACHE_TTL", 86400)) * time.Second,
+		URLCacheTTL:        time.Duration(getEnvInt("URL_CACHE_TTL", 3600)) * time.Second,
+		QRCacheTTL:         time.Duration(getEnvInt("QR_CACHE_TTL", 86400)) * time.Second,
+		CodeExistsCacheTTL: time.Duration(getEnvInt("CODE_EXISTS_CACHE_TTL", 10)) * time.Second,
 
 		// Rate limiting
 		RateLimitCreatePerMinute:   getEnvInt("RATE_LIMIT_CREATE_PER_MINUTE", 10),
@@ -86,8 +348,30 @@ func Load() *Config {
 		Auth0Domain:   getEnv("AUTH0_DOMAIN", ""),
 		Auth0Audience: getEnv("AUTH0_AUDIENCE", ""),
 
+		AuthRevocationFailOpen: getEnvBool("AUTH_REVOCATION_FAIL_OPEN", false),
+
+		// GitHub OAuth
+		GitHubClientID:     getEnv("GITHUB_CLIENT_ID", ""),
+		GitHubClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
+		GitHubRedirectURL:  getEnv("GITHUB_REDIRECT_URL", ""),
+
+		// Generic OIDC
+		OIDCProviderName: getEnv("OIDC_PROVIDER_NAME", ""),
+		OIDCDiscoveryURL: getEnv("OIDC_DISCOVERY_URL", ""),
+		OIDCAudience:     getEnv("OIDC_AUDIENCE", ""),
+
+		// Auth backend selection, API key, and forward-auth
+		AuthBackend:        getEnv("AUTH_BACKEND", ""),
+		APIKeyID:           getEnv("API_KEY_ID", ""),
+		APIKeySecret:       getEnv("API_KEY_SECRET", ""),
+		ForwardAuthURL:     getEnv("AUTH_FORWARD_URL", ""),
+		ForwardAuthHeaders: getEnv("AUTH_FORWARD_HEADERS", "X-Auth-User,X-Auth-Groups"),
+		ForwardAuthTimeout: getEnvDuration("AUTH_FORWARD_TIMEOUT_SECONDS", 5*time.Second),
+
 		// GeoIP
-		GeoIPDBPath: getEnv("GEOIP_DB_PATH", "/data/GeoLite2-City.mmdb"),
+		GeoIPDBPath:         getEnv("GEOIP_DB_PATH", "/data/GeoLite2-City.mmdb"),
+		GeoIPReloadInterval: getEnvDuration("GEOIP_RELOAD_INTERVAL_SECONDS", 60*time.Second),
+		UAParserRegexesPath: getEnv("UA_PARSER_REGEXES_PATH", ""),
 
 		// Short codes
 		ShortCodeMinLength: getEnvInt("SHORT_CODE_MIN_LENGTH", 6),
@@ -95,6 +379,79 @@ func Load() *Config {
 
 		// Security
 		IPHashSalt: getEnv("IP_HASH_SALT", "change-this-in-production"),
+
+		// Token revocation
+		MaxTokenLifetime: time.Duration(getEnvInt("MAX_TOKEN_LIFETIME_HOURS", 24)) * time.Hour,
+
+		// Self-adaptive rate limiting
+		PrometheusURL:                 getEnv("PROMETHEUS_URL", ""),
+		LatencySLOSeconds:             getEnvFloat("LATENCY_SLO_SECONDS", 0.1),
+		RateLimitRedirectMinPerSecond: getEnvInt("RATE_LIMIT_REDIRECT_MIN_PER_SECOND", 10),
+		AdaptiveLimiterPollInterval:   getEnvDuration("ADAPTIVE_LIMITER_POLL_INTERVAL", 30*time.Second),
+
+		// Per-tenant limits
+		LimitsFilePath: getEnv("LIMITS_FILE_PATH", ""),
+
+		// Hot path deadline and bounded background event pool
+		RedirectDeadline:    getEnvDuration("REDIRECT_DEADLINE_SECONDS", 250*time.Millisecond),
+		ClickEventWorkers:   getEnvInt("CLICK_EVENT_WORKERS", 64),
+		ClickEventQueueSize: getEnvInt("CLICK_EVENT_QUEUE_SIZE", 4096),
+
+		// Batched click ingestion into TimescaleDB
+		ClickIngestFlushInterval: getEnvDuration("CLICK_INGEST_FLUSH_INTERVAL_SECONDS", 500*time.Millisecond),
+		ClickIngestMaxBatchSize:  getEnvInt("CLICK_INGEST_MAX_BATCH_SIZE", 1000),
+		ClickIngestQueueSize:     getEnvInt("CLICK_INGEST_QUEUE_SIZE", 20000),
+
+		// URL mutation audit log
+		AuditRetentionDays: getEnvInt("AUDIT_RETENTION_DAYS", 0),
+
+		// Destination content-classification gate
+		SafetyCheckEnabled:      getEnvBool("SAFETY_CHECK_ENABLED", false),
+		SafetyCheckTimeout:      getEnvDuration("SAFETY_CHECK_TIMEOUT_SECONDS", 5*time.Second),
+		SafetyCheckMaxBodyBytes: int64(getEnvInt("SAFETY_CHECK_MAX_BODY_KB", 64)) * 1024,
+
+		// SSRF guard
+		AllowPrivateTargets: getEnvBool("ALLOW_PRIVATE_TARGETS", false),
+		SSRFResolveCacheTTL: getEnvDuration("SSRF_RESOLVE_CACHE_TTL_SECONDS", 30*time.Second),
+
+		// Real-time click stream (LISTEN/NOTIFY + SSE)
+		ClickStreamEnabled:           getEnvBool("CLICK_STREAM_ENABLED", false),
+		ClickStreamReplayWindow:      getEnvDuration("CLICK_STREAM_REPLAY_WINDOW_SECONDS", 60*time.Second),
+		ClickStreamHeartbeatInterval: getEnvDuration("CLICK_STREAM_HEARTBEAT_INTERVAL_SECONDS", 15*time.Second),
+
+		// Live analytics (Redis Pub/Sub + SSE)
+		LiveAnalyticsEnabled: getEnvBool("LIVE_ANALYTICS_ENABLED", true),
+
+		// Flusher debug endpoint
+		FlusherDebugAddr: getEnv("FLUSHER_DEBUG_ADDR", ":9091"),
+
+		// Admin metrics/pprof endpoint
+		AdminMetricsAddr: getEnv("ADMIN_METRICS_ADDR", ":9090"),
+		PprofEnabled:     getEnvBool("PPROF_ENABLED", false),
+
+		// Analytics export storage
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3AccessKey:       getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:       getEnv("S3_SECRET_KEY", ""),
+		S3Bucket:          getEnv("S3_BUCKET", "url-shortener-exports"),
+		S3UseSSL:          getEnvBool("S3_USE_SSL", true),
+		ExportJobTTL:      getEnvDuration("EXPORT_JOB_TTL_SECONDS", 24*time.Hour),
+		ExportDownloadTTL: getEnvDuration("EXPORT_DOWNLOAD_TTL_SECONDS", time.Hour),
+
+		// Custom domain TLS via ACME
+		ACMEEnabled:             getEnvBool("ACME_ENABLED", false),
+		ACMEEmail:               getEnv("ACME_EMAIL", ""),
+		ACMEDirectoryURL:        getEnv("ACME_DIRECTORY_URL", ""),
+		ACMEHTTPFallbackEnabled: getEnvBool("ACME_HTTP_FALLBACK_ENABLED", true),
+		ACMEHTTPFallbackAddr:    getEnv("ACME_HTTP_FALLBACK_ADDR", ":80"),
+
+		// Bulk import/export
+		BulkImportMaxRows:     getEnvInt("BULK_IMPORT_MAX_ROWS", 10000),
+		BulkImportConcurrency: getEnvInt("BULK_IMPORT_CONCURRENCY", 8),
+		BulkExportBatchSize:   getEnvInt("BULK_EXPORT_BATCH_SIZE", 500),
+
+		URLBgWorkers:   getEnvInt("URL_BG_WORKERS", 16),
+		URLBgQueueSize: getEnvInt("URL_BG_QUEUE_SIZE", 1024),
 	}
 }
 
@@ -127,3 +484,34 @@ func getEnvInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvFloat gets a float environment variable or returns a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration gets a duration environment variable, expressed in
+// seconds, or returns a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+			return time.Duration(floatVal * float64(time.Second))
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolVal, err := strconv.ParseBool(value); err == nil {
+			return boolVal
+		}
+	}
+	return defaultValue
+}
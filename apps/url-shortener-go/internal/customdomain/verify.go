@@ -0,0 +1,52 @@
+// Package customdomain implements DNS-based ownership verification for
+// user-claimed custom short domains: claiming <domain> requires
+// publishing a TXT record at _shortener-challenge.<domain> containing a
+// token this package generates, before the domain is eligible for
+// redirects or TLS.
+package customdomain
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+)
+
+// challengeLabel is prepended to a claimed domain to build the DNS name
+// its challenge TXT record must be published at.
+const challengeLabel = "_shortener-challenge"
+
+// tokenBytes is the amount of randomness in a generated challenge token.
+const tokenBytes = 20
+
+// GenerateToken returns a random hex token for a new domain claim's TXT
+// record challenge.
+func GenerateToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate challenge token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ChallengeName returns the DNS name a domain's challenge TXT record
+// must be published under to prove ownership of domain.
+func ChallengeName(domain string) string {
+	return challengeLabel + "." + domain
+}
+
+// Verify looks up domain's challenge TXT record and reports whether any
+// of its values match token.
+func Verify(ctx context.Context, domain, token string) (bool, error) {
+	records, err := net.DefaultResolver.LookupTXT(ctx, ChallengeName(domain))
+	if err != nil {
+		return false, fmt.Errorf("failed to look up DNS challenge for %s: %w", domain, err)
+	}
+	for _, record := range records {
+		if record == token {
+			return true, nil
+		}
+	}
+	return false, nil
+}
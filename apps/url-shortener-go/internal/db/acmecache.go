@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// GetACMECacheValue returns key's stored value, or (nil, nil) if no row
+// exists. Unlike GetURLByShortCode et al., a miss here is the expected,
+// common case for autocert.Cache (see internal/acmecache), not an error
+// condition - the caller (acmecache.Cache.Get) is what turns a nil into
+// autocert.ErrCacheMiss.
+func (db *DB) GetACMECacheValue(ctx context.Context, key string) ([]byte, error) {
+	var data []byte
+	err := db.Pool.QueryRow(ctx, `
+		SELECT value FROM acme_cache WHERE key = $1
+	`, key).Scan(&data)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ACME cache value: %w", err)
+	}
+	return data, nil
+}
+
+// PutACMECacheValue upserts key's stored value.
+func (db *DB) PutACMECacheValue(ctx context.Context, key string, data []byte) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO acme_cache (key, value, updated_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (key) DO UPDATE SET value = $2, updated_at = NOW()
+	`, key, data)
+	if err != nil {
+		return fmt.Errorf("failed to put ACME cache value: %w", err)
+	}
+	return nil
+}
+
+// DeleteACMECacheValue removes key's stored value, if any.
+func (db *DB) DeleteACMECacheValue(ctx context.Context, key string) error {
+	_, err := db.Pool.Exec(ctx, `DELETE FROM acme_cache WHERE key = $1`, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete ACME cache value: %w", err)
+	}
+	return nil
+}
@@ -2,26 +2,29 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-// Click represents a click event for TimescaleDB
+// Click represents a click event for TimescaleDB. Its JSON tags match the
+// clicks table's column names, since InstallClickStreamTrigger's
+// row_to_json(NEW) notification payload is unmarshaled straight into one.
 type Click struct {
-	Time       time.Time
-	URLID      uuid.UUID
-	IPHash     string
-	UserAgent  string
-	Referrer   string
-	Country    string
-	City       string
-	Latitude   float64
-	Longitude  float64
-	DeviceType string
-	Browser    string
-	OS         string
+	Time       time.Time `json:"time"`
+	URLID      uuid.UUID `json:"url_id"`
+	IPHash     string    `json:"ip_hash"`
+	UserAgent  string    `json:"user_agent"`
+	Referrer   string    `json:"referrer"`
+	Country    string    `json:"country"`
+	City       string    `json:"city"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	DeviceType string    `json:"device_type"`
+	Browser    string    `json:"browser"`
+	OS         string    `json:"os"`
 }
 
 // InsertClick inserts a click event into the TimescaleDB clicks hypertable
@@ -48,11 +51,105 @@ type ClickStats struct {
 	TabletClicks   int64 `json:"tablet_clicks"`
 }
 
-// TimeSeriesPoint represents a point in time-series data
+// TimeSeriesPoint represents a point in time-series data. Clicks/Unique
+// are pointers because a gap-filled bucket with Fill == FillNull has no
+// value to report for that series.
 type TimeSeriesPoint struct {
 	Bucket time.Time `json:"bucket"`
-	Clicks int64     `json:"clicks"`
-	Unique int64     `json:"unique"`
+	Clicks *int64    `json:"clicks"`
+	Unique *int64    `json:"unique"`
+}
+
+// Resolution is the time_bucket_gapfill width GetClicksOverTime groups by.
+type Resolution string
+
+const (
+	Resolution1Minute Resolution = "1m"
+	Resolution5Minute Resolution = "5m"
+	Resolution1Hour   Resolution = "1h"
+	Resolution1Day    Resolution = "1d"
+)
+
+// ErrInvalidResolution is returned by GetClicksOverTime for a Resolution
+// outside the 1m/5m/1h/1d set.
+var ErrInvalidResolution = errors.New("invalid resolution")
+
+// bucketWidth returns the wall-clock duration of one bucket at this
+// resolution, so callers/clients can render axes without hard-coding it.
+func (r Resolution) bucketWidth() (time.Duration, error) {
+	switch r {
+	case Resolution1Minute:
+		return time.Minute, nil
+	case Resolution5Minute:
+		return 5 * time.Minute, nil
+	case Resolution1Hour:
+		return time.Hour, nil
+	case Resolution1Day:
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrInvalidResolution, string(r))
+	}
+}
+
+// interval renders the resolution as a time_bucket_gapfill interval literal.
+func (r Resolution) interval() string {
+	switch r {
+	case Resolution1Minute:
+		return "1 minute"
+	case Resolution5Minute:
+		return "5 minutes"
+	case Resolution1Hour:
+		return "1 hour"
+	case Resolution1Day:
+		return "1 day"
+	default:
+		return ""
+	}
+}
+
+// Fill selects how a gap-filled bucket with no rows is reported. It's
+// chosen independently per series - e.g. Zero for clicks (no clicks in a
+// quiet bucket really is zero) and LOCF for unique_visitors (carrying the
+// last known cumulative value forward reads better than a false zero).
+type Fill int
+
+const (
+	FillZero Fill = iota
+	FillLOCF
+	FillInterpolate
+	FillNull
+)
+
+// fillExpr wraps a gapfill-able aggregate expression with the SQL that
+// implements fill's semantics.
+func fillExpr(aggExpr string, fill Fill) string {
+	switch fill {
+	case FillLOCF:
+		return "locf(" + aggExpr + ")"
+	case FillInterpolate:
+		return "interpolate(" + aggExpr + ")"
+	case FillNull:
+		return aggExpr
+	default: // FillZero
+		return "COALESCE(" + aggExpr + ", 0)"
+	}
+}
+
+// MaxBuckets bounds how many buckets a single GetClicksOverTime query may
+// gapfill, so a wide start/end paired with a fine Resolution can't turn
+// into a pathologically large result set.
+const MaxBuckets = 5000
+
+// ErrTooManyBuckets is returned by GetClicksOverTime when (end-start)/resolution
+// exceeds MaxBuckets.
+var ErrTooManyBuckets = errors.New("requested range and resolution exceed MaxBuckets")
+
+// TimeSeriesResult is GetClicksOverTime's return value. BucketWidth is the
+// resolution's actual duration, so a client can render axes correctly
+// without re-deriving it from the Resolution string it sent.
+type TimeSeriesResult struct {
+	Points      []TimeSeriesPoint `json:"points"`
+	BucketWidth time.Duration     `json:"bucket_width"`
 }
 
 // GeoBreakdown represents geographic breakdown
@@ -98,22 +195,35 @@ func (db *DB) GetURLStats(ctx context.Context, urlID uuid.UUID) (*ClickStats, er
 	return stats, nil
 }
 
-// GetClicksOverTime retrieves click data over time
-// For recent data (last 3 hours), queries raw clicks table directly for real-time accuracy
-// For older data, uses hourly_stats continuous aggregate for performance
-func (db *DB) GetClicksOverTime(ctx context.Context, urlID uuid.UUID, days int) ([]TimeSeriesPoint, error) {
-	// Always query raw clicks for the most recent data (last 3 hours)
-	// This ensures we show real-time analytics without waiting for the continuous aggregate to refresh
-	rows, err := db.Pool.Query(ctx, `
+// GetClicksOverTime retrieves click data over [start, end) bucketed at
+// resolution, gap-filling buckets with no clicks via time_bucket_gapfill
+// rather than leaving the caller to zero-fill sparse results itself.
+// clicksFill/visitorsFill pick that gap-fill's semantics independently per
+// series - e.g. FillZero for clicks and FillLOCF for unique_visitors.
+func (db *DB) GetClicksOverTime(ctx context.Context, urlID uuid.UUID, start, end time.Time, resolution Resolution, clicksFill, visitorsFill Fill) (*TimeSeriesResult, error) {
+	width, err := resolution.bucketWidth()
+	if err != nil {
+		return nil, err
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end must be after start")
+	}
+	if numBuckets := end.Sub(start) / width; numBuckets > MaxBuckets {
+		return nil, fmt.Errorf("%w: %d buckets requested for a %s resolution, max %d", ErrTooManyBuckets, numBuckets, resolution, MaxBuckets)
+	}
+
+	query := fmt.Sprintf(`
 		SELECT
-			time_bucket('1 hour', time) AS bucket,
-			COUNT(*) AS click_count,
-			COUNT(DISTINCT ip_hash) AS unique_visitors
+			time_bucket_gapfill('%s', time, $2, $3) AS bucket,
+			%s AS clicks,
+			%s AS unique_visitors
 		FROM clicks
-		WHERE url_id = $1 AND time > NOW() - ($2 || ' days')::interval
+		WHERE url_id = $1 AND time >= $2 AND time < $3
 		GROUP BY bucket
 		ORDER BY bucket ASC
-	`, urlID, days)
+	`, resolution.interval(), fillExpr("count(*)", clicksFill), fillExpr("count(distinct ip_hash)", visitorsFill))
+
+	rows, err := db.Pool.Query(ctx, query, urlID, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get clicks over time: %w", err)
 	}
@@ -127,8 +237,8 @@ func (db *DB) GetClicksOverTime(ctx context.Context, urlID uuid.UUID, days int)
 		}
 		points = append(points, p)
 	}
-	
-	return points, nil
+
+	return &TimeSeriesResult{Points: points, BucketWidth: width}, nil
 }
 
 // GetGeoBreakdown retrieves clicks by country
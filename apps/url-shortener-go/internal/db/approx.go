@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Accuracy selects between an exact COUNT(DISTINCT ip_hash) query and an
+// approximate one backed by timescaledb_toolkit HyperLogLog sketches.
+// Approx trades a small, bounded error for staying cheap past the point
+// where COUNT(DISTINCT ip_hash) over the raw clicks hypertable stops
+// scaling for a high-volume URL.
+type Accuracy int
+
+const (
+	Exact Accuracy = iota
+	Approx
+)
+
+// realtimeWindow is how far back GetURLStatsApprox/GetClicksOverTimeApprox
+// always query the raw clicks table for exact, real-time numbers -
+// hourly_stats_hll won't have rolled up anything more recent than this yet.
+const realtimeWindow = "3 hours"
+
+// GetURLStatsApprox is GetURLStats's approximate counterpart: unique
+// visitors are estimated from rolled-up HyperLogLog sketches in the
+// hourly_stats_hll continuous aggregate instead of COUNT(DISTINCT
+// ip_hash) over the raw hypertable, with the last realtimeWindow always
+// computed exactly from raw rows so very recent clicks aren't missed
+// while the aggregate catches up.
+func (db *DB) GetURLStatsApprox(ctx context.Context, urlID uuid.UUID) (*ClickStats, error) {
+	stats := &ClickStats{}
+	err := db.Pool.QueryRow(ctx, `
+		WITH recent AS (
+			SELECT
+				COUNT(*) AS total_clicks,
+				COUNT(*) FILTER (WHERE device_type = 'mobile') AS mobile_clicks,
+				COUNT(*) FILTER (WHERE device_type = 'desktop') AS desktop_clicks,
+				COUNT(*) FILTER (WHERE device_type = 'tablet') AS tablet_clicks,
+				hyperloglog(1000, ip_hash) AS visitor_hll
+			FROM clicks
+			WHERE url_id = $1 AND time >= date_trunc('hour', NOW() - INTERVAL '`+realtimeWindow+`')
+		),
+		historical AS (
+			SELECT
+				COALESCE(SUM(total_clicks), 0) AS total_clicks,
+				COALESCE(SUM(mobile_clicks), 0) AS mobile_clicks,
+				COALESCE(SUM(desktop_clicks), 0) AS desktop_clicks,
+				COALESCE(SUM(tablet_clicks), 0) AS tablet_clicks,
+				rollup(visitor_hll) AS visitor_hll
+			FROM hourly_stats_hll
+			WHERE url_id = $1 AND bucket < date_trunc('hour', NOW() - INTERVAL '`+realtimeWindow+`')
+		),
+		combined AS (
+			SELECT visitor_hll FROM recent
+			UNION ALL
+			SELECT visitor_hll FROM historical
+		)
+		SELECT
+			(SELECT total_clicks FROM recent) + (SELECT total_clicks FROM historical),
+			(SELECT mobile_clicks FROM recent) + (SELECT mobile_clicks FROM historical),
+			(SELECT desktop_clicks FROM recent) + (SELECT desktop_clicks FROM historical),
+			(SELECT tablet_clicks FROM recent) + (SELECT tablet_clicks FROM historical),
+			(SELECT distinct_count(rollup(visitor_hll)) FROM combined)
+	`, urlID).Scan(
+		&stats.TotalClicks,
+		&stats.MobileClicks,
+		&stats.DesktopClicks,
+		&stats.TabletClicks,
+		&stats.UniqueVisitors,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approximate URL stats: %w", err)
+	}
+	return stats, nil
+}
+
+// GetClicksOverTimeApprox is GetClicksOverTime's approximate counterpart.
+// Buckets inside realtimeWindow come from the raw clicks table with an
+// exact COUNT(DISTINCT ip_hash); older buckets come straight from the
+// hourly_stats_hll continuous aggregate's pre-computed sketches, so
+// scanning a long days window doesn't mean scanning every raw click in it.
+func (db *DB) GetClicksOverTimeApprox(ctx context.Context, urlID uuid.UUID, days int) ([]TimeSeriesPoint, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT bucket, clicks, unique_visitors FROM (
+			SELECT
+				time_bucket('1 hour', time) AS bucket,
+				COUNT(*) AS clicks,
+				COUNT(DISTINCT ip_hash) AS unique_visitors
+			FROM clicks
+			WHERE url_id = $1 AND time >= date_trunc('hour', NOW() - INTERVAL '`+realtimeWindow+`')
+			GROUP BY bucket
+
+			UNION ALL
+
+			SELECT
+				bucket,
+				total_clicks AS clicks,
+				distinct_count(visitor_hll) AS unique_visitors
+			FROM hourly_stats_hll
+			WHERE url_id = $1
+				AND bucket < date_trunc('hour', NOW() - INTERVAL '`+realtimeWindow+`')
+				AND bucket > NOW() - ($2 || ' days')::interval
+		) combined
+		ORDER BY bucket ASC
+	`, urlID, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approximate clicks over time: %w", err)
+	}
+	defer rows.Close()
+
+	points := []TimeSeriesPoint{}
+	for rows.Next() {
+		var bucket time.Time
+		var clicks, unique int64
+		if err := rows.Scan(&bucket, &clicks, &unique); err != nil {
+			return nil, fmt.Errorf("failed to scan approximate time series point: %w", err)
+		}
+		points = append(points, TimeSeriesPoint{Bucket: bucket, Clicks: &clicks, Unique: &unique})
+	}
+
+	return points, nil
+}
+
+// CheckApproxAnalyticsHealth verifies the timescaledb_toolkit extension
+// that GetURLStatsApprox/GetClicksOverTimeApprox depend on is installed.
+// It's kept separate from HealthCheck because approximate analytics is an
+// opt-in feature, not a hard requirement for the service to be ready.
+func (db *DB) CheckApproxAnalyticsHealth(ctx context.Context) error {
+	var version string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT extversion FROM pg_extension WHERE extname = 'timescaledb_toolkit'
+	`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("timescaledb_toolkit extension not found: %w", err)
+	}
+	return nil
+}
+
+// InstallApproxAnalytics installs the timescaledb_toolkit extension and
+// the hourly_stats_hll continuous aggregate that GetURLStatsApprox and
+// GetClicksOverTimeApprox query. Unlike audit.InstallRetentionPolicy, which
+// cmd/server runs at startup, nothing in this codebase calls it: it's run
+// once, e.g. from an operator runbook or a deploy hook - this codebase has
+// no migration tool to hang a versioned migration off of.
+func InstallApproxAnalytics(ctx context.Context, db *DB) error {
+	if _, err := db.Pool.Exec(ctx, `CREATE EXTENSION IF NOT EXISTS timescaledb_toolkit`); err != nil {
+		return fmt.Errorf("failed to install timescaledb_toolkit: %w", err)
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		CREATE MATERIALIZED VIEW IF NOT EXISTS hourly_stats_hll
+		WITH (timescaledb.continuous) AS
+		SELECT
+			url_id,
+			time_bucket('1 hour', time) AS bucket,
+			hyperloglog(1000, ip_hash) AS visitor_hll,
+			COUNT(*) AS total_clicks,
+			COUNT(*) FILTER (WHERE device_type = 'mobile') AS mobile_clicks,
+			COUNT(*) FILTER (WHERE device_type = 'desktop') AS desktop_clicks,
+			COUNT(*) FILTER (WHERE device_type = 'tablet') AS tablet_clicks
+		FROM clicks
+		GROUP BY url_id, bucket
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create hourly_stats_hll continuous aggregate: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ClickStreamChannel is the Postgres NOTIFY channel the clicks_notify_trigger
+// (installed by InstallClickStreamTrigger) publishes new rows to.
+const ClickStreamChannel = "click_events"
+
+// InstallClickStreamTrigger installs the trigger function and AFTER INSERT
+// trigger on the clicks hypertable that pg_notify's ClickStreamChannel with
+// the new row as JSON, so SubscribeClicks can fan new clicks out to
+// dashboards without polling. Like InstallRetentionPolicy and
+// InstallApproxAnalytics, this is a Go function standing in for the
+// migration tooling this repo doesn't have; it's idempotent and safe to
+// call on every startup.
+func InstallClickStreamTrigger(ctx context.Context, pool *pgxpool.Pool) error {
+	if _, err := pool.Exec(ctx, `
+		CREATE OR REPLACE FUNCTION notify_click_event() RETURNS trigger AS $$
+		BEGIN
+			PERFORM pg_notify('click_events', row_to_json(NEW)::text);
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql
+	`); err != nil {
+		return fmt.Errorf("failed to create notify_click_event function: %w", err)
+	}
+
+	if _, err := pool.Exec(ctx, `DROP TRIGGER IF EXISTS clicks_notify_trigger ON clicks`); err != nil {
+		return fmt.Errorf("failed to drop existing clicks_notify_trigger: %w", err)
+	}
+
+	if _, err := pool.Exec(ctx, `
+		CREATE TRIGGER clicks_notify_trigger
+		AFTER INSERT ON clicks
+		FOR EACH ROW EXECUTE FUNCTION notify_click_event()
+	`); err != nil {
+		return fmt.Errorf("failed to create clicks_notify_trigger: %w", err)
+	}
+
+	return nil
+}
+
+// clickSubscriberBufferSize bounds how many unconsumed clicks a single
+// SubscribeClicks caller may have buffered before clickBroker starts
+// dropping the oldest queued click to make room for the newest one.
+const clickSubscriberBufferSize = 32
+
+// clickSubscriber is one SubscribeClicks caller's mailbox.
+type clickSubscriber struct {
+	ch chan Click
+}
+
+// clickBroker holds a single dedicated LISTEN connection against
+// ClickStreamChannel and fans each notification out to every subscriber
+// registered for that click's URLID, instead of every SubscribeClicks
+// caller opening its own LISTEN connection.
+type clickBroker struct {
+	pool *pgxpool.Pool
+
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[*clickSubscriber]struct{}
+}
+
+// newClickBroker creates a clickBroker and starts its listen loop in the
+// background. The loop runs for the lifetime of the process: it isn't
+// tied to any one subscriber's context, since other subscribers must keep
+// receiving notifications after the first one disconnects.
+func newClickBroker(pool *pgxpool.Pool) *clickBroker {
+	b := &clickBroker{
+		pool: pool,
+		subs: map[uuid.UUID]map[*clickSubscriber]struct{}{},
+	}
+	go b.listenLoop()
+	return b
+}
+
+// listenLoop holds a dedicated connection LISTENing on ClickStreamChannel
+// and dispatches every notification it receives, reconnecting with
+// jittered backoff if the connection is lost.
+func (b *clickBroker) listenLoop() {
+	ctx := context.Background()
+	backoff := 500 * time.Millisecond
+	const maxBackoff = 30 * time.Second
+
+	for {
+		conn, err := b.pool.Acquire(ctx)
+		if err != nil {
+			log.Printf("click stream: failed to acquire listen connection: %v", err)
+			time.Sleep(backoff + time.Duration(rand.Int63n(int64(backoff))))
+			if backoff < maxBackoff {
+				backoff *= 2
+			}
+			continue
+		}
+
+		if _, err := conn.Exec(ctx, "LISTEN "+ClickStreamChannel); err != nil {
+			log.Printf("click stream: failed to LISTEN: %v", err)
+			conn.Release()
+			time.Sleep(backoff)
+			continue
+		}
+
+		backoff = 500 * time.Millisecond
+		b.drain(ctx, conn)
+		conn.Release()
+	}
+}
+
+// drain reads notifications off conn until it errors (connection lost),
+// dispatching each one to matching subscribers.
+func (b *clickBroker) drain(ctx context.Context, conn *pgxpool.Conn) {
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			log.Printf("click stream: lost listen connection: %v", err)
+			return
+		}
+
+		var click Click
+		if err := json.Unmarshal([]byte(notification.Payload), &click); err != nil {
+			log.Printf("click stream: failed to unmarshal notification payload: %v", err)
+			continue
+		}
+
+		b.dispatch(click)
+	}
+}
+
+// dispatch fans click out to every subscriber registered for its URLID,
+// dropping the oldest buffered click for a subscriber whose buffer is
+// full rather than blocking the shared listen loop on a slow consumer.
+func (b *clickBroker) dispatch(click Click) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs[click.URLID] {
+		select {
+		case sub.ch <- click:
+		default:
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- click:
+			default:
+			}
+			metrics.DroppedEvents.WithLabelValues("click_stream").Inc()
+		}
+	}
+}
+
+// subscribe registers a new subscriber for urlID and returns its receive
+// channel. The subscriber is unregistered and its channel closed once ctx
+// is done.
+func (b *clickBroker) subscribe(ctx context.Context, urlID uuid.UUID) <-chan Click {
+	sub := &clickSubscriber{ch: make(chan Click, clickSubscriberBufferSize)}
+
+	b.mu.Lock()
+	if b.subs[urlID] == nil {
+		b.subs[urlID] = map[*clickSubscriber]struct{}{}
+	}
+	b.subs[urlID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		delete(b.subs[urlID], sub)
+		if len(b.subs[urlID]) == 0 {
+			delete(b.subs, urlID)
+		}
+		b.mu.Unlock()
+		close(sub.ch)
+	}()
+
+	return sub.ch
+}
+
+// SubscribeClicks registers for live clicks on urlID, fanned out from a
+// single shared LISTEN connection (lazily started on first call) against
+// ClickStreamChannel. The returned channel is closed once ctx is done;
+// callers on a slow consumer may miss clicks rather than backpressure the
+// shared broker, see clickSubscriberBufferSize.
+func (db *DB) SubscribeClicks(ctx context.Context, urlID uuid.UUID) (<-chan Click, error) {
+	db.clickBrokerOnce.Do(func() {
+		db.clickBroker = newClickBroker(db.Pool)
+	})
+	return db.clickBroker.subscribe(ctx, urlID), nil
+}
+
+// ReplayClicks retrieves urlID's raw clicks since since, ordered oldest
+// first, for a reconnecting SSE client to catch up on what it missed
+// before SubscribeClicks picks up with live notifications.
+func (db *DB) ReplayClicks(ctx context.Context, urlID uuid.UUID, since time.Time) ([]Click, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT time, url_id, ip_hash, user_agent, referrer, country, city, latitude, longitude, device_type, browser, os
+		FROM clicks
+		WHERE url_id = $1 AND time > $2
+		ORDER BY time ASC
+	`, urlID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay clicks: %w", err)
+	}
+	defer rows.Close()
+
+	clicks := []Click{}
+	for rows.Next() {
+		var c Click
+		if err := rows.Scan(&c.Time, &c.URLID, &c.IPHash, &c.UserAgent, &c.Referrer, &c.Country, &c.City, &c.Latitude, &c.Longitude, &c.DeviceType, &c.Browser, &c.OS); err != nil {
+			return nil, fmt.Errorf("failed to scan replayed click: %w", err)
+		}
+		clicks = append(clicks, c)
+	}
+	return clicks, nil
+}
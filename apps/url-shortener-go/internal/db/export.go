@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// clicksCursorFetchSize is how many rows QueryClicksCursor FETCHes from
+// its server-side cursor per round trip - large enough to amortize
+// round-trip latency, small enough that exporting a long date range
+// never holds more than this many rows in memory at once.
+const clicksCursorFetchSize = 1000
+
+// QueryClicksCursor streams every click for urlID in [start, end) through
+// fn, ordered by time, using a server-side cursor declared in its own
+// transaction so a multi-million-row export never has to load the whole
+// result set into memory. fn returning an error aborts the export and
+// rolls back the transaction.
+func (db *DB) QueryClicksCursor(ctx context.Context, urlID uuid.UUID, start, end time.Time, fn func(Click) error) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin export tx: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	const cursorName = "clicks_export_cursor"
+	_, err = tx.Exec(ctx, fmt.Sprintf(`
+		DECLARE %s CURSOR FOR
+		SELECT time, url_id, ip_hash, user_agent, referrer, country, city, latitude, longitude, device_type, browser, os
+		FROM clicks
+		WHERE url_id = $1 AND time >= $2 AND time < $3
+		ORDER BY time
+	`, cursorName), urlID, start, end)
+	if err != nil {
+		return fmt.Errorf("declare cursor: %w", err)
+	}
+
+	for {
+		fetched, err := fetchCursorBatch(ctx, tx, cursorName, fn)
+		if err != nil {
+			return err
+		}
+		if fetched < clicksCursorFetchSize {
+			break
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// fetchCursorBatch runs a single FETCH against cursorName and passes each
+// row to fn, returning how many rows were returned so the caller knows
+// whether the cursor is exhausted.
+func fetchCursorBatch(ctx context.Context, tx pgx.Tx, cursorName string, fn func(Click) error) (int, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("FETCH %d FROM %s", clicksCursorFetchSize, cursorName))
+	if err != nil {
+		return 0, fmt.Errorf("fetch cursor batch: %w", err)
+	}
+	defer rows.Close()
+
+	fetched := 0
+	for rows.Next() {
+		var c Click
+		if err := rows.Scan(&c.Time, &c.URLID, &c.IPHash, &c.UserAgent, &c.Referrer, &c.Country, &c.City, &c.Latitude, &c.Longitude, &c.DeviceType, &c.Browser, &c.OS); err != nil {
+			return fetched, fmt.Errorf("scan click: %w", err)
+		}
+		if err := fn(c); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+	if err := rows.Err(); err != nil {
+		return fetched, fmt.Errorf("fetch cursor batch: %w", err)
+	}
+	return fetched, nil
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrIngestQueueFull is returned by Enqueue when the ingester's buffer is
+// full. The click is dropped rather than the caller blocking on it.
+var ErrIngestQueueFull = errors.New("click ingest queue full")
+
+// ClickIngesterConfig controls how a ClickIngester batches and flushes clicks.
+type ClickIngesterConfig struct {
+	// FlushInterval is the longest a buffered click waits before being
+	// flushed, even if MaxBatchSize hasn't been reached yet.
+	FlushInterval time.Duration
+
+	// MaxBatchSize flushes the buffer early, without waiting out
+	// FlushInterval, once this many clicks have accumulated.
+	MaxBatchSize int
+
+	// QueueSize bounds how many clicks may be buffered awaiting a flush.
+	QueueSize int
+
+	// MaxAttempts is how many times a batch is tried against Postgres,
+	// including the first attempt, before its rows are counted as failed.
+	MaxAttempts int
+
+	// BaseRetryDelay is the starting delay for the exponential backoff
+	// between batch retries.
+	BaseRetryDelay time.Duration
+}
+
+func (c ClickIngesterConfig) withDefaults() ClickIngesterConfig {
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = 500 * time.Millisecond
+	}
+	if c.MaxBatchSize <= 0 {
+		c.MaxBatchSize = 1000
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 20000
+	}
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.BaseRetryDelay <= 0 {
+		c.BaseRetryDelay = 100 * time.Millisecond
+	}
+	return c
+}
+
+// ClickIngester batches Click events and flushes them to the clicks
+// hypertable with pgx's CopyFrom (binary COPY) instead of one INSERT per
+// click, so a redirect burst doesn't turn into a round-trip-per-click
+// storm against TimescaleDB.
+type ClickIngester struct {
+	db      *DB
+	cfg     ClickIngesterConfig
+	buf     chan Click
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewClickIngester creates a ClickIngester and starts its background flush
+// loop. Call Shutdown before the process exits so the residual buffer is
+// flushed rather than dropped.
+func NewClickIngester(database *DB, cfg ClickIngesterConfig) *ClickIngester {
+	cfg = cfg.withDefaults()
+	ci := &ClickIngester{
+		db:      database,
+		cfg:     cfg,
+		buf:     make(chan Click, cfg.QueueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go ci.run()
+	return ci
+}
+
+// Enqueue buffers a click for batched insertion. It never blocks: if the
+// buffer is full it returns ErrIngestQueueFull immediately, having already
+// counted the drop, rather than stalling the redirect hot path.
+func (ci *ClickIngester) Enqueue(ctx context.Context, click Click) error {
+	select {
+	case ci.buf <- click:
+		metrics.ClickIngestEnqueued.Inc()
+		return nil
+	default:
+		metrics.ClickIngestDropped.Inc()
+		return ErrIngestQueueFull
+	}
+}
+
+// run drains the buffer into the clicks table, flushing whenever
+// MaxBatchSize clicks have accumulated or FlushInterval elapses, whichever
+// comes first.
+func (ci *ClickIngester) run() {
+	ticker := time.NewTicker(ci.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Click, 0, ci.cfg.MaxBatchSize)
+	for {
+		select {
+		case click := <-ci.buf:
+			batch = append(batch, click)
+			if len(batch) >= ci.cfg.MaxBatchSize {
+				ci.flush(batch)
+				batch = make([]Click, 0, ci.cfg.MaxBatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				ci.flush(batch)
+				batch = make([]Click, 0, ci.cfg.MaxBatchSize)
+			}
+		case <-ci.done:
+			ci.drain(batch)
+			close(ci.stopped)
+			return
+		}
+	}
+}
+
+// drain flushes whatever is left in batch plus anything still sitting in
+// the buffer, for use during Shutdown.
+func (ci *ClickIngester) drain(batch []Click) {
+	for {
+		select {
+		case click := <-ci.buf:
+			batch = append(batch, click)
+		default:
+			if len(batch) > 0 {
+				ci.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+// flush writes a batch via CopyFrom, retrying transient Postgres errors
+// with jittered exponential backoff before counting the batch as failed.
+func (ci *ClickIngester) flush(batch []Click) {
+	var err error
+	for attempt := 0; attempt < ci.cfg.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			delay := ci.cfg.BaseRetryDelay * time.Duration(1<<uint(attempt-1))
+			delay += time.Duration(rand.Int63n(int64(delay) + 1))
+			time.Sleep(delay)
+		}
+
+		_, err = ci.db.Pool.CopyFrom(
+			context.Background(),
+			pgx.Identifier{"clicks"},
+			[]string{"time", "url_id", "ip_hash", "user_agent", "referrer", "country", "city", "latitude", "longitude", "device_type", "browser", "os"},
+			pgx.CopyFromSlice(len(batch), func(i int) ([]interface{}, error) {
+				c := batch[i]
+				return []interface{}{
+					c.Time, c.URLID, c.IPHash, c.UserAgent, c.Referrer,
+					c.Country, c.City, c.Latitude, c.Longitude,
+					c.DeviceType, c.Browser, c.OS,
+				}, nil
+			}),
+		)
+		if err == nil {
+			metrics.ClickIngestFlushed.Add(float64(len(batch)))
+			return
+		}
+		log.Printf("click ingest: batch of %d failed (attempt %d/%d): %v", len(batch), attempt+1, ci.cfg.MaxAttempts, err)
+	}
+
+	metrics.ClickIngestFailed.Add(float64(len(batch)))
+}
+
+// Shutdown stops the flush loop and flushes whatever clicks are still
+// buffered. It blocks until the residual flush completes or ctx expires.
+func (ci *ClickIngester) Shutdown(ctx context.Context) error {
+	close(ci.done)
+	select {
+	case <-ci.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -3,8 +3,10 @@ package db
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,6 +14,19 @@ import (
 // DB wraps the PostgreSQL connection pool
 type DB struct {
 	Pool *pgxpool.Pool
+
+	// Auditor, if set, records CreateURL/UpdateURL/DeactivateURL as audit
+	// events. It's nil-safe to leave unset - the mutation just isn't
+	// audited, the same way other optional dependencies are wired in this
+	// service (e.g. promquery.Client being nil when PROMETHEUS_URL unset).
+	Auditor audit.Auditor
+
+	// clickBroker fans out live clicks to SubscribeClicks callers over a
+	// single shared LISTEN connection, lazily started by clickBrokerOnce
+	// on the first SubscribeClicks call so a process that never streams
+	// clicks never holds a connection open for it.
+	clickBroker     *clickBroker
+	clickBrokerOnce sync.Once
 }
 
 // New creates a new database connection pool
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
+	"github.com/google/uuid"
+)
+
+// SafetyCheck is a single content-classification verdict recorded against
+// a URL, either from its creation-time gate or a later admin override.
+type SafetyCheck struct {
+	ID         uuid.UUID `json:"id"`
+	URLID      uuid.UUID `json:"url_id"`
+	Flagged    bool      `json:"flagged"`
+	Score      float64   `json:"score"`
+	Categories []string  `json:"categories"`
+	Mode       string    `json:"mode"`
+	Overridden bool      `json:"overridden"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// RecordSafetyCheck inserts check via q, so it can be called inside the
+// same transaction CreateURL uses for the URL row and its audit event.
+func (db *DB) RecordSafetyCheck(ctx context.Context, q audit.Querier, check SafetyCheck) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := q.QueryRow(ctx, `
+		INSERT INTO url_safety_checks (url_id, flagged, score, categories, mode)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, check.URLID, check.Flagged, check.Score, check.Categories, check.Mode).Scan(&id)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to record safety check: %w", err)
+	}
+	return id, nil
+}
+
+// ListSafetyChecks retrieves every safety check recorded against urlID,
+// most recent first.
+func (db *DB) ListSafetyChecks(ctx context.Context, urlID uuid.UUID) ([]SafetyCheck, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, url_id, flagged, score, categories, mode, overridden, created_at
+		FROM url_safety_checks
+		WHERE url_id = $1
+		ORDER BY created_at DESC
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list safety checks: %w", err)
+	}
+	defer rows.Close()
+
+	checks := []SafetyCheck{}
+	for rows.Next() {
+		var c SafetyCheck
+		if err := rows.Scan(&c.ID, &c.URLID, &c.Flagged, &c.Score, &c.Categories, &c.Mode, &c.Overridden, &c.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan safety check: %w", err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, nil
+}
+
+// OverrideSafetyCheck marks urlID's safety checks as overridden and
+// reactivates the URL, for an admin who's reviewed a ModeWarn flag and
+// decided the destination is fine. The update and its audit row run in
+// the same transaction.
+func (db *DB) OverrideSafetyCheck(ctx context.Context, actor audit.Actor, urlID uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin override safety check transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := getURLByID(ctx, tx, urlID)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE url_safety_checks SET overridden = true WHERE url_id = $1
+	`, urlID); err != nil {
+		return fmt.Errorf("failed to override safety checks: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, `
+		UPDATE urls SET is_active = true, updated_at = NOW() WHERE id = $1
+	`, urlID); err != nil {
+		return fmt.Errorf("failed to reactivate URL: %w", err)
+	}
+
+	if db.Auditor != nil {
+		after, err := getURLByID(ctx, tx, urlID)
+		if err != nil {
+			return err
+		}
+		if err := db.Auditor.Index(ctx, tx, audit.AuditEvent{
+			ActorUserID: actor.UserID,
+			URLID:       urlID,
+			Action:      "safety_override",
+			RequestID:   actor.RequestID,
+			RemoteIP:    actor.RemoteIP,
+			Before:      urlSnapshot(before),
+			After:       urlSnapshot(after),
+			Reason:      actor.Reason,
+		}); err != nil {
+			return fmt.Errorf("failed to audit safety override: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit override safety check transaction: %w", err)
+	}
+
+	return nil
+}
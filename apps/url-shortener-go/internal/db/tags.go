@@ -0,0 +1,147 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Tag is a short, user-defined label a user can attach to any number of
+// their URLs for organization. Tag names are unique per user (not
+// globally) - see GetOrCreateTag.
+type Tag struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GetOrCreateTag returns userID's existing tag named name, creating it
+// if this is the first time they've used it, mirroring
+// GetOrCreateUser's upsert pattern.
+func (db *DB) GetOrCreateTag(ctx context.Context, userID uuid.UUID, name string) (*Tag, error) {
+	tag := &Tag{}
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO tags (user_id, name)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, name) DO UPDATE SET name = tags.name
+		RETURNING id, user_id, name, created_at
+	`, userID, name).Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get or create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// ListTagsByUser returns every tag userID has ever created, alphabetically.
+func (db *DB) ListTagsByUser(ctx context.Context, userID uuid.UUID) ([]*Tag, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, user_id, name, created_at
+		FROM tags
+		WHERE user_id = $1
+		ORDER BY name ASC
+	`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+	for rows.Next() {
+		tag := &Tag{}
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// DeleteTag removes userID's tag, and with it every URL's association
+// with it (url_tags.tag_id cascades). Scoped to userID so one user can't
+// delete another's tag.
+func (db *DB) DeleteTag(ctx context.Context, userID, id uuid.UUID) error {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM tags WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("tag not found")
+	}
+	return nil
+}
+
+// setURLTags replaces urlID's tag associations with exactly tagIDs, as
+// part of tx - the same transaction as the URL write that's changing
+// them, so a reader never sees a URL with half its new tag set applied.
+func setURLTags(ctx context.Context, tx pgx.Tx, urlID uuid.UUID, tagIDs []uuid.UUID) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM url_tags WHERE url_id = $1`, urlID); err != nil {
+		return fmt.Errorf("failed to clear URL tags: %w", err)
+	}
+	for _, tagID := range tagIDs {
+		if _, err := tx.Exec(ctx, `INSERT INTO url_tags (url_id, tag_id) VALUES ($1, $2)`, urlID, tagID); err != nil {
+			return fmt.Errorf("failed to set URL tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListTagsForURL returns the tags attached to a single URL, alphabetically.
+func (db *DB) ListTagsForURL(ctx context.Context, urlID uuid.UUID) ([]*Tag, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT t.id, t.user_id, t.name, t.created_at
+		FROM tags t
+		JOIN url_tags ut ON ut.tag_id = t.id
+		WHERE ut.url_id = $1
+		ORDER BY t.name ASC
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URL tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []*Tag{}
+	for rows.Next() {
+		tag := &Tag{}
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// ListTagsForURLs batch-resolves the tags attached to each of urlIDs, to
+// avoid an N+1 query per row when rendering a page of ListUserURLs
+// results. URLs with no tags are simply absent from the returned map.
+func (db *DB) ListTagsForURLs(ctx context.Context, urlIDs []uuid.UUID) (map[uuid.UUID][]*Tag, error) {
+	result := make(map[uuid.UUID][]*Tag, len(urlIDs))
+	if len(urlIDs) == 0 {
+		return result, nil
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT ut.url_id, t.id, t.user_id, t.name, t.created_at
+		FROM tags t
+		JOIN url_tags ut ON ut.tag_id = t.id
+		WHERE ut.url_id = ANY($1)
+		ORDER BY t.name ASC
+	`, urlIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list URL tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlID uuid.UUID
+		tag := &Tag{}
+		if err := rows.Scan(&urlID, &tag.ID, &tag.UserID, &tag.Name, &tag.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+		result[urlID] = append(result[urlID], tag)
+	}
+	return result, nil
+}
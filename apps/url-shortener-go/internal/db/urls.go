@@ -2,13 +2,29 @@ package db
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/audit"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/safety"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 )
 
+// ErrDestinationFlagged is returned by CreateURL when the destination's
+// content was classified as unsafe and the caller's safety.Mode is
+// safety.ModeBlock.
+type ErrDestinationFlagged struct {
+	Categories []string
+	Score      float64
+}
+
+func (e *ErrDestinationFlagged) Error() string {
+	return fmt.Sprintf("destination content flagged (score %.2f): %s", e.Score, strings.Join(e.Categories, ", "))
+}
+
 // URL represents a shortened URL
 type URL struct {
 	ID             uuid.UUID              `json:"id"`
@@ -21,46 +37,183 @@ type URL struct {
 	ExpiresAt      *time.Time             `json:"expires_at,omitempty"`
 	CreatedAt      time.Time              `json:"created_at"`
 	UpdatedAt      time.Time              `json:"updated_at"`
+
+	// CustomDomainID is nil for a URL served from the default BaseURL
+	// host, or the owning CustomDomain's ID for one created under a
+	// verified custom domain. Two URLs may share a ShortCode as long as
+	// at most one of them has a nil CustomDomainID and the rest each
+	// belong to a distinct domain - see GetURLByShortCode and
+	// GetURLByShortCodeForDomain.
+	CustomDomainID *uuid.UUID `json:"custom_domain_id,omitempty"`
 }
 
-// CreateURL inserts a new URL into the database
-func (db *DB) CreateURL(ctx context.Context, userID uuid.UUID, shortCode, destinationURL string, expiresIn *int, notes *string) (*URL, error) {
+// CreateURL inserts a new URL into the database. The insert, its safety
+// check row (if safetyResult is non-nil), and its audit row (if
+// db.Auditor is set) are all written in the same transaction, so a
+// reader of url_audit or url_safety_checks never sees one half of an
+// aborted creation.
+//
+// safetyResult is the content-classification gate's verdict on
+// destinationURL, or nil if the gate is disabled for this call.
+// safetyMode controls what a Flagged result does: ModeBlock rejects the
+// request with ErrDestinationFlagged before any row is written, ModeWarn
+// still creates the URL but with is_active=false and a metadata.safety
+// block, and ModeOff is equivalent to a nil safetyResult.
+//
+// customDomainID scopes shortCode to a verified CustomDomain instead of
+// the default BaseURL host - nil creates it on the default host, exactly
+// as before custom domains existed.
+//
+// tagIDs attaches the given tags (see GetOrCreateTag) to the new URL, in
+// the same transaction as its insert.
+func (db *DB) CreateURL(ctx context.Context, actor audit.Actor, shortCode, destinationURL string, expiresIn *int, notes *string, safetyResult *safety.Result, safetyMode safety.Mode, customDomainID *uuid.UUID, tagIDs []uuid.UUID) (*URL, error) {
 	var expiresAt *time.Time
 	if expiresIn != nil && *expiresIn > 0 {
 		expiry := time.Now().Add(time.Duration(*expiresIn) * time.Second)
 		expiresAt = &expiry
 	}
 
+	isActive := true
+	metadata := map[string]interface{}{}
+	if safetyResult != nil && safetyResult.Flagged {
+		switch safetyMode {
+		case safety.ModeBlock:
+			return nil, &ErrDestinationFlagged{Categories: safetyResult.Categories, Score: safetyResult.Score}
+		case safety.ModeWarn:
+			isActive = false
+			metadata["safety"] = map[string]interface{}{
+				"flagged":    true,
+				"score":      safetyResult.Score,
+				"categories": safetyResult.Categories,
+			}
+		}
+	}
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal URL metadata: %w", err)
+	}
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin create URL transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
 	url := &URL{}
-	err := db.Pool.QueryRow(ctx, `
-		INSERT INTO urls (user_id, short_code, destination_url, notes, expires_at)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at
-	`, userID, shortCode, destinationURL, notes, expiresAt).Scan(
+	err = tx.QueryRow(ctx, `
+		INSERT INTO urls (user_id, short_code, destination_url, notes, expires_at, is_active, metadata, custom_domain_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at, custom_domain_id
+	`, actor.UserID, shortCode, destinationURL, notes, expiresAt, isActive, metadataJSON, customDomainID).Scan(
 		&url.ID, &url.UserID, &url.ShortCode, &url.DestinationURL,
 		&url.Notes, &url.Metadata, &url.IsActive, &url.ExpiresAt,
-		&url.CreatedAt, &url.UpdatedAt,
+		&url.CreatedAt, &url.UpdatedAt, &url.CustomDomainID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create URL: %w", err)
 	}
 
+	if len(tagIDs) > 0 {
+		if err := setURLTags(ctx, tx, url.ID, tagIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if safetyResult != nil {
+		if _, err := db.RecordSafetyCheck(ctx, tx, SafetyCheck{
+			URLID:      url.ID,
+			Flagged:    safetyResult.Flagged,
+			Score:      safetyResult.Score,
+			Categories: safetyResult.Categories,
+			Mode:       safetyMode.String(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if db.Auditor != nil {
+		if err := db.Auditor.Index(ctx, tx, audit.AuditEvent{
+			ActorUserID: actor.UserID,
+			URLID:       url.ID,
+			Action:      "create",
+			RequestID:   actor.RequestID,
+			RemoteIP:    actor.RemoteIP,
+			After:       urlSnapshot(url),
+			Reason:      actor.Reason,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to audit URL creation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit create URL transaction: %w", err)
+	}
+
 	return url, nil
 }
 
-// GetURLByShortCode retrieves an active URL by its short code
+// urlSnapshot renders a URL as a plain map for storage as an audit
+// before/after image, reusing its existing JSON tags.
+func urlSnapshot(u *URL) map[string]interface{} {
+	if u == nil {
+		return nil
+	}
+	data, err := json.Marshal(u)
+	if err != nil {
+		return nil
+	}
+	snapshot := map[string]interface{}{}
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot
+}
+
+// GetURLByShortCode retrieves an active URL by its short code, scoped to
+// the default BaseURL host (custom_domain_id IS NULL). Use
+// GetURLByShortCodeForDomain for a URL created under a custom domain.
 func (db *DB) GetURLByShortCode(ctx context.Context, shortCode string) (*URL, error) {
 	url := &URL{}
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at
+		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at, custom_domain_id
 		FROM urls
 		WHERE short_code = $1
+			AND custom_domain_id IS NULL
 			AND is_active = true
 			AND (expires_at IS NULL OR expires_at > NOW())
 	`, shortCode).Scan(
 		&url.ID, &url.UserID, &url.ShortCode, &url.DestinationURL,
 		&url.Notes, &url.Metadata, &url.IsActive, &url.ExpiresAt,
-		&url.CreatedAt, &url.UpdatedAt,
+		&url.CreatedAt, &url.UpdatedAt, &url.CustomDomainID,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, fmt.Errorf("URL not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URL: %w", err)
+	}
+
+	return url, nil
+}
+
+// GetURLByShortCodeForDomain retrieves an active URL by its short code,
+// scoped to customDomainID, so e.g. "bit.acme.co/xyz" and
+// "go.foo.com/xyz" can resolve to different destinations even though
+// they share a short code - each is a distinct row, scoped by a distinct
+// custom_domain_id.
+func (db *DB) GetURLByShortCodeForDomain(ctx context.Context, shortCode string, customDomainID uuid.UUID) (*URL, error) {
+	url := &URL{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at, custom_domain_id
+		FROM urls
+		WHERE short_code = $1
+			AND custom_domain_id = $2
+			AND is_active = true
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`, shortCode, customDomainID).Scan(
+		&url.ID, &url.UserID, &url.ShortCode, &url.DestinationURL,
+		&url.Notes, &url.Metadata, &url.IsActive, &url.ExpiresAt,
+		&url.CreatedAt, &url.UpdatedAt, &url.CustomDomainID,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, fmt.Errorf("URL not found")
@@ -74,15 +227,26 @@ func (db *DB) GetURLByShortCode(ctx context.Context, shortCode string) (*URL, er
 
 // GetURLByID retrieves a URL by its ID
 func (db *DB) GetURLByID(ctx context.Context, id uuid.UUID) (*URL, error) {
+	return getURLByID(ctx, db.Pool, id)
+}
+
+// rowQuerier is the subset of pgx.Tx and *pgxpool.Pool that getURLByID
+// needs, so it can be reused both standalone and as the pre-image read
+// inside UpdateURL/DeactivateURL's audited transaction.
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+func getURLByID(ctx context.Context, q rowQuerier, id uuid.UUID) (*URL, error) {
 	url := &URL{}
-	err := db.Pool.QueryRow(ctx, `
-		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at
+	err := q.QueryRow(ctx, `
+		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at, custom_domain_id
 		FROM urls
 		WHERE id = $1
 	`, id).Scan(
 		&url.ID, &url.UserID, &url.ShortCode, &url.DestinationURL,
 		&url.Notes, &url.Metadata, &url.IsActive, &url.ExpiresAt,
-		&url.CreatedAt, &url.UpdatedAt,
+		&url.CreatedAt, &url.UpdatedAt, &url.CustomDomainID,
 	)
 	if err == pgx.ErrNoRows {
 		return nil, fmt.Errorf("URL not found")
@@ -96,16 +260,22 @@ func (db *DB) GetURLByID(ctx context.Context, id uuid.UUID) (*URL, error) {
 
 // URLFilters represents filter options for listing URLs
 type URLFilters struct {
-	IsActive     *bool
-	CreatedAfter *time.Time
+	IsActive      *bool
+	CreatedAfter  *time.Time
 	CreatedBefore *time.Time
-	SortOrder    string // "asc" or "desc"
+	SortOrder     string // "asc" or "desc"
+
+	// TagIDs, if non-empty, restricts results to URLs carrying at least
+	// one (TagMatch == "or", the default) or all (TagMatch == "and") of
+	// the listed tags.
+	TagIDs   []uuid.UUID
+	TagMatch string
 }
 
 // ListUserURLs retrieves all URLs for a user with pagination and filters
 func (db *DB) ListUserURLs(ctx context.Context, userID uuid.UUID, limit, offset int, filters *URLFilters) ([]*URL, error) {
 	query := `
-		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at
+		SELECT id, user_id, short_code, destination_url, notes, metadata, is_active, expires_at, created_at, updated_at, custom_domain_id
 		FROM urls
 		WHERE user_id = $1`
 	
@@ -129,6 +299,20 @@ func (db *DB) ListUserURLs(ctx context.Context, userID uuid.UUID, limit, offset
 			args = append(args, *filters.CreatedBefore)
 			argIndex++
 		}
+		if len(filters.TagIDs) > 0 {
+			if filters.TagMatch == "and" {
+				query += fmt.Sprintf(` AND id IN (
+					SELECT url_id FROM url_tags WHERE tag_id = ANY($%d)
+					GROUP BY url_id HAVING COUNT(DISTINCT tag_id) = $%d
+				)`, argIndex, argIndex+1)
+				args = append(args, filters.TagIDs, len(filters.TagIDs))
+				argIndex += 2
+			} else {
+				query += fmt.Sprintf(" AND id IN (SELECT url_id FROM url_tags WHERE tag_id = ANY($%d))", argIndex)
+				args = append(args, filters.TagIDs)
+				argIndex++
+			}
+		}
 	}
 
 	// Add ordering
@@ -154,7 +338,7 @@ func (db *DB) ListUserURLs(ctx context.Context, userID uuid.UUID, limit, offset
 		err := rows.Scan(
 			&url.ID, &url.UserID, &url.ShortCode, &url.DestinationURL,
 			&url.Notes, &url.Metadata, &url.IsActive, &url.ExpiresAt,
-			&url.CreatedAt, &url.UpdatedAt,
+			&url.CreatedAt, &url.UpdatedAt, &url.CustomDomainID,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan URL: %w", err)
@@ -165,15 +349,32 @@ func (db *DB) ListUserURLs(ctx context.Context, userID uuid.UUID, limit, offset
 	return urls, nil
 }
 
-// UpdateURL updates a URL's destination, notes, or expiry
-func (db *DB) UpdateURL(ctx context.Context, id uuid.UUID, destinationURL *string, notes *string, expiresIn *int, isActive *bool) error {
+// UpdateURL updates a URL's destination, notes, or expiry. The update and
+// its audit row (before and after images, if db.Auditor is set) run in
+// the same transaction.
+//
+// tagIDs, like the other parameters, is a partial update: nil leaves the
+// URL's tags untouched, while a non-nil (possibly empty) slice replaces
+// them with exactly that set.
+func (db *DB) UpdateURL(ctx context.Context, actor audit.Actor, id uuid.UUID, destinationURL *string, notes *string, expiresIn *int, isActive *bool, tagIDs *[]uuid.UUID) error {
 	var expiresAt *time.Time
 	if expiresIn != nil && *expiresIn > 0 {
 		expiry := time.Now().Add(time.Duration(*expiresIn) * time.Second)
 		expiresAt = &expiry
 	}
 
-	_, err := db.Pool.Exec(ctx, `
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin update URL transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := getURLByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
 		UPDATE urls
 		SET
 			destination_url = COALESCE($2, destination_url),
@@ -182,25 +383,87 @@ func (db *DB) UpdateURL(ctx context.Context, id uuid.UUID, destinationURL *strin
 			is_active = COALESCE($5, is_active),
 			updated_at = NOW()
 		WHERE id = $1
-	`, id, destinationURL, notes, expiresAt, isActive)
-	if err != nil {
+	`, id, destinationURL, notes, expiresAt, isActive); err != nil {
 		return fmt.Errorf("failed to update URL: %w", err)
 	}
 
+	if tagIDs != nil {
+		if err := setURLTags(ctx, tx, id, *tagIDs); err != nil {
+			return err
+		}
+	}
+
+	if db.Auditor != nil {
+		after, err := getURLByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := db.Auditor.Index(ctx, tx, audit.AuditEvent{
+			ActorUserID: actor.UserID,
+			URLID:       id,
+			Action:      "update",
+			RequestID:   actor.RequestID,
+			RemoteIP:    actor.RemoteIP,
+			Before:      urlSnapshot(before),
+			After:       urlSnapshot(after),
+			Reason:      actor.Reason,
+		}); err != nil {
+			return fmt.Errorf("failed to audit URL update: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit update URL transaction: %w", err)
+	}
+
 	return nil
 }
 
-// DeactivateURL soft deletes a URL by setting is_active to false
-func (db *DB) DeactivateURL(ctx context.Context, id uuid.UUID) error {
-	_, err := db.Pool.Exec(ctx, `
+// DeactivateURL soft deletes a URL by setting is_active to false. The
+// update and its audit row run in the same transaction.
+func (db *DB) DeactivateURL(ctx context.Context, actor audit.Actor, id uuid.UUID) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin deactivate URL transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	before, err := getURLByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
 		UPDATE urls
 		SET is_active = false, updated_at = NOW()
 		WHERE id = $1
-	`, id)
-	if err != nil {
+	`, id); err != nil {
 		return fmt.Errorf("failed to deactivate URL: %w", err)
 	}
 
+	if db.Auditor != nil {
+		after, err := getURLByID(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		if err := db.Auditor.Index(ctx, tx, audit.AuditEvent{
+			ActorUserID: actor.UserID,
+			URLID:       id,
+			Action:      "deactivate",
+			RequestID:   actor.RequestID,
+			RemoteIP:    actor.RemoteIP,
+			Before:      urlSnapshot(before),
+			After:       urlSnapshot(after),
+			Reason:      actor.Reason,
+		}); err != nil {
+			return fmt.Errorf("failed to audit URL deactivation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit deactivate URL transaction: %w", err)
+	}
+
 	return nil
 }
 
@@ -227,6 +490,20 @@ func (db *DB) CountUserURLs(ctx context.Context, userID uuid.UUID, filters *URLF
 			args = append(args, *filters.CreatedBefore)
 			argIndex++
 		}
+		if len(filters.TagIDs) > 0 {
+			if filters.TagMatch == "and" {
+				query += fmt.Sprintf(` AND id IN (
+					SELECT url_id FROM url_tags WHERE tag_id = ANY($%d)
+					GROUP BY url_id HAVING COUNT(DISTINCT tag_id) = $%d
+				)`, argIndex, argIndex+1)
+				args = append(args, filters.TagIDs, len(filters.TagIDs))
+				argIndex += 2
+			} else {
+				query += fmt.Sprintf(" AND id IN (SELECT url_id FROM url_tags WHERE tag_id = ANY($%d))", argIndex)
+				args = append(args, filters.TagIDs)
+				argIndex++
+			}
+		}
 	}
 
 	var count int
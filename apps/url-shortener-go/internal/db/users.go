@@ -13,6 +13,10 @@ type User struct {
 	Auth0Sub string    `json:"auth0_sub"`
 	Email    string    `json:"email"`
 	Name     string    `json:"name,omitempty"`
+	// SafetyMode is one of "off", "warn", "block" (see safety.ParseMode)
+	// and governs what CreateURL does when the destination-content
+	// classification gate flags a new URL. Empty parses as "warn".
+	SafetyMode string `json:"safety_mode,omitempty"`
 }
 
 // GetOrCreateUser gets an existing user by Auth0 sub or creates a new one
@@ -21,11 +25,11 @@ func (db *DB) GetOrCreateUser(ctx context.Context, auth0Sub, email, name string)
 	
 	// Try to get existing user
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, auth0_sub, email, name
+		SELECT id, auth0_sub, email, name, COALESCE(safety_mode, 'warn')
 		FROM users
 		WHERE auth0_sub = $1
-	`, auth0Sub).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name)
-	
+	`, auth0Sub).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name, &user.SafetyMode)
+
 	if err == nil {
 		return user, nil
 	}
@@ -38,8 +42,8 @@ func (db *DB) GetOrCreateUser(ctx context.Context, auth0Sub, email, name string)
 			email = EXCLUDED.email,
 			name = COALESCE(EXCLUDED.name, users.name),
 			updated_at = NOW()
-		RETURNING id, auth0_sub, email, name
-	`, auth0Sub, email, name).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name)
+		RETURNING id, auth0_sub, email, name, COALESCE(safety_mode, 'warn')
+	`, auth0Sub, email, name).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name, &user.SafetyMode)
 	
 	if err != nil {
 		return nil, fmt.Errorf("failed to get or create user: %w", err)
@@ -52,11 +56,11 @@ func (db *DB) GetOrCreateUser(ctx context.Context, auth0Sub, email, name string)
 func (db *DB) GetUserByAuth0Sub(ctx context.Context, auth0Sub string) (*User, error) {
 	user := &User{}
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, auth0_sub, email, name
+		SELECT id, auth0_sub, email, name, COALESCE(safety_mode, 'warn')
 		FROM users
 		WHERE auth0_sub = $1
-	`, auth0Sub).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name)
-	
+	`, auth0Sub).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name, &user.SafetyMode)
+
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
@@ -68,11 +72,11 @@ func (db *DB) GetUserByAuth0Sub(ctx context.Context, auth0Sub string) (*User, er
 func (db *DB) GetUserByID(ctx context.Context, id uuid.UUID) (*User, error) {
 	user := &User{}
 	err := db.Pool.QueryRow(ctx, `
-		SELECT id, auth0_sub, email, name
+		SELECT id, auth0_sub, email, name, COALESCE(safety_mode, 'warn')
 		FROM users
 		WHERE id = $1
-	`, id).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name)
-	
+	`, id).Scan(&user.ID, &user.Auth0Sub, &user.Email, &user.Name, &user.SafetyMode)
+
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
@@ -0,0 +1,290 @@
+// Package enrich fills in the click metadata the hot path can't afford
+// to compute itself: geo location from IP and structured device/browser/
+// OS/bot info from the User-Agent string.
+//
+// Geo lookups need the raw client IP, which the redirect handler hashes
+// for privacy (see api.hashIP) before a click ever reaches the Flusher -
+// so LookupGeo is called from the redirect hot path's background event
+// pool, while the resulting country/city/lat/lon ride along on the click
+// event through analytics:stream like everything else. ParseUA only needs
+// the User-Agent string, which does survive that trip, so it's called
+// from worker.Flusher.processEvent instead.
+package enrich
+
+import (
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	geoip2 "github.com/oschwald/geoip2-golang"
+	"github.com/ua-parser/uap-go/uaparser"
+)
+
+// GeoResult is what LookupGeo fills on a click. It's the zero value when
+// the mmdb database isn't loaded or the IP isn't found.
+type GeoResult struct {
+	Country   string
+	City      string
+	Latitude  float64
+	Longitude float64
+}
+
+// UAResult is what ParseUA fills on a click.
+type UAResult struct {
+	DeviceType string
+	Browser    string
+	OS         string
+	IsBot      bool
+}
+
+// Enricher resolves geo and User-Agent metadata for a click. It's safe
+// for concurrent use, and degrades gracefully to zero-value results when
+// its GeoLite2-City database isn't available, rather than failing the
+// click it was asked to enrich.
+type Enricher struct {
+	uaParser *uaparser.Parser
+
+	mmdbPath string
+	mu       sync.RWMutex
+	db       *geoip2.Reader
+	modTime  time.Time
+}
+
+// New creates an Enricher. mmdbPath may be empty, or point at a file that
+// doesn't exist yet - LookupGeo silently returns a zero GeoResult until a
+// valid database shows up. uaRegexesPath configures uap-go's regex file;
+// if it's empty or fails to load, ParseUA falls back to the same
+// substring-matching heuristics the Flusher used before this package
+// existed, so a missing regex file degrades UA parsing instead of
+// breaking it.
+func New(mmdbPath, uaRegexesPath string, reloadInterval time.Duration) *Enricher {
+	e := &Enricher{mmdbPath: mmdbPath}
+
+	if uaRegexesPath != "" {
+		parser, err := uaparser.New(uaRegexesPath)
+		if err != nil {
+			log.Printf("enrich: failed to load UA regexes from %s, falling back to heuristic UA parsing: %v", uaRegexesPath, err)
+		} else {
+			e.uaParser = parser
+		}
+	}
+
+	e.reload()
+	if mmdbPath != "" {
+		if reloadInterval <= 0 {
+			reloadInterval = time.Minute
+		}
+		go e.watchReload(reloadInterval)
+	}
+
+	return e
+}
+
+// watchReload re-opens mmdbPath whenever its mtime changes, so an
+// operator can hot-swap the GeoLite2 database without restarting the
+// process. It runs for the lifetime of the process.
+func (e *Enricher) watchReload(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		e.reload()
+	}
+}
+
+// reload opens mmdbPath if its mtime has changed since the last
+// successful load (or it hasn't been loaded yet), swapping in the new
+// reader and closing the old one. Any failure (missing file, corrupt
+// database) just leaves the previous reader - or no reader - in place.
+func (e *Enricher) reload() {
+	if e.mmdbPath == "" {
+		return
+	}
+
+	info, err := os.Stat(e.mmdbPath)
+	if err != nil {
+		return
+	}
+
+	e.mu.RLock()
+	unchanged := e.db != nil && info.ModTime().Equal(e.modTime)
+	e.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	db, err := geoip2.Open(e.mmdbPath)
+	if err != nil {
+		log.Printf("enrich: failed to open GeoLite2 database %s: %v", e.mmdbPath, err)
+		return
+	}
+
+	e.mu.Lock()
+	old := e.db
+	e.db = db
+	e.modTime = info.ModTime()
+	e.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	log.Printf("enrich: loaded GeoLite2 database %s (mtime %s)", e.mmdbPath, info.ModTime())
+}
+
+// LookupGeo resolves ip to a city-level location. It returns a zero
+// GeoResult, not an error, when no database is loaded or ip isn't found -
+// geo enrichment is best-effort and should never fail the click it's
+// attached to.
+func (e *Enricher) LookupGeo(ip string) GeoResult {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return GeoResult{}
+	}
+
+	e.mu.RLock()
+	db := e.db
+	e.mu.RUnlock()
+	if db == nil {
+		return GeoResult{}
+	}
+
+	record, err := db.City(parsed)
+	if err != nil {
+		return GeoResult{}
+	}
+
+	return GeoResult{
+		Country:   record.Country.Names["en"],
+		City:      record.City.Names["en"],
+		Latitude:  record.Location.Latitude,
+		Longitude: record.Location.Longitude,
+	}
+}
+
+// ParseUA resolves userAgent to a device type, browser, and OS, using
+// uap-go's maintained regex list (including bot/crawler detection) when
+// available, falling back to the heuristic substring matching this
+// package replaces otherwise.
+func (e *Enricher) ParseUA(userAgent string) UAResult {
+	if e.uaParser == nil {
+		return fallbackParseUA(userAgent)
+	}
+
+	client := e.uaParser.Parse(userAgent)
+
+	deviceType := "desktop"
+	isBot := false
+	switch client.Device.Family {
+	case "Spider":
+		deviceType = "bot"
+		isBot = true
+	case "":
+		// uap-go leaves Device.Family empty when it can't classify the
+		// device; fall back to the OS family for a coarse mobile/tablet
+		// guess instead of reporting "desktop" for every unknown device.
+		deviceType = deviceTypeFromOS(client.Os.Family)
+	default:
+		deviceType = deviceTypeFromDevice(client.Device.Family)
+	}
+
+	return UAResult{
+		DeviceType: deviceType,
+		Browser:    client.UserAgent.Family,
+		OS:         client.Os.Family,
+		IsBot:      isBot,
+	}
+}
+
+func deviceTypeFromDevice(family string) string {
+	switch family {
+	case "iPad", "Tablet", "Android Tablet":
+		return "tablet"
+	case "iPhone", "Android", "Android Mobile":
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
+
+func deviceTypeFromOS(osFamily string) string {
+	switch osFamily {
+	case "iOS", "Android":
+		return "mobile"
+	default:
+		return "desktop"
+	}
+}
+
+// fallbackParseUA is the pre-enrich-package heuristic: plain substring
+// matching against well-known tokens, used when no UA regex file is
+// configured or it failed to load.
+func fallbackParseUA(userAgent string) UAResult {
+	return UAResult{
+		DeviceType: fallbackDeviceType(userAgent),
+		Browser:    fallbackBrowser(userAgent),
+		OS:         fallbackOS(userAgent),
+		IsBot:      containsAny(userAgent, "bot", "Bot", "crawler"),
+	}
+}
+
+func fallbackDeviceType(ua string) string {
+	if ua == "" {
+		return "unknown"
+	}
+	if containsAny(ua, "Mobile", "Android", "iPhone") {
+		return "mobile"
+	}
+	if containsAny(ua, "Tablet", "iPad") {
+		return "tablet"
+	}
+	if containsAny(ua, "bot", "Bot", "crawler") {
+		return "bot"
+	}
+	return "desktop"
+}
+
+func fallbackBrowser(ua string) string {
+	switch {
+	case containsAny(ua, "Chrome") && !containsAny(ua, "Chromium"):
+		return "Chrome"
+	case containsAny(ua, "Firefox"):
+		return "Firefox"
+	case containsAny(ua, "Safari") && !containsAny(ua, "Chrome"):
+		return "Safari"
+	case containsAny(ua, "Edge"):
+		return "Edge"
+	default:
+		return "Other"
+	}
+}
+
+func fallbackOS(ua string) string {
+	switch {
+	case containsAny(ua, "Windows"):
+		return "Windows"
+	case containsAny(ua, "Mac OS"):
+		return "macOS"
+	case containsAny(ua, "Linux"):
+		return "Linux"
+	case containsAny(ua, "Android"):
+		return "Android"
+	case containsAny(ua, "iOS", "iPhone", "iPad"):
+		return "iOS"
+	default:
+		return "Other"
+	}
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, sub := range substrs {
+		if len(s) >= len(sub) {
+			for i := 0; i <= len(s)-len(sub); i++ {
+				if s[i:i+len(sub)] == sub {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
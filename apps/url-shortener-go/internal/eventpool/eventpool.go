@@ -0,0 +1,41 @@
+// Package eventpool provides a small bounded worker pool for best-effort
+// background work (cache write-through, click-event recording) that used
+// to run as an unbounded "go func() {...}" per request. Unbounded
+// goroutines risk a leak pile-up on a downstream outage; this pool caps
+// both the concurrency and the backlog, dropping new work once full
+// instead of queuing without limit.
+package eventpool
+
+// Pool runs submitted functions on a fixed number of background workers,
+// backed by a bounded job queue.
+type Pool struct {
+	jobs chan func()
+}
+
+// New creates a Pool with the given number of workers draining a queue of
+// capacity queueSize.
+func New(workers, queueSize int) *Pool {
+	p := &Pool{jobs: make(chan func(), queueSize)}
+	for i := 0; i < workers; i++ {
+		go p.run()
+	}
+	return p
+}
+
+func (p *Pool) run() {
+	for fn := range p.jobs {
+		fn()
+	}
+}
+
+// Submit enqueues fn for background execution. It never blocks: if the
+// queue is full it returns false immediately so the caller can count the
+// drop instead of stalling the hot path.
+func (p *Pool) Submit(fn func()) bool {
+	select {
+	case p.jobs <- fn:
+		return true
+	default:
+		return false
+	}
+}
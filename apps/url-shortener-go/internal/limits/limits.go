@@ -0,0 +1,190 @@
+// Package limits implements per-tenant overrides of the service's default
+// rate/quota limits, inspired by Cortex/Loki's per-tenant validation.Limits.
+// A YAML file lists overrides keyed by Auth0 sub (or org id); anything not
+// present in the file falls back to the env-configured defaults. The file
+// is watched for changes so operators can retune limits without a
+// redeploy.
+package limits
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
+	"github.com/fsnotify/fsnotify"
+	"gopkg.in/yaml.v3"
+)
+
+// Limits is the set of per-tenant knobs that can be overridden.
+type Limits struct {
+	MaxURLsPerUser      int      `yaml:"max_urls_per_user"`
+	MaxRedirectsPerSec  int      `yaml:"max_redirects_per_second"`
+	MaxTTLDays          int      `yaml:"max_ttl_days"`
+	AllowedHostPatterns []string `yaml:"allowed_host_patterns,omitempty"`
+	DeniedHostPatterns  []string `yaml:"denied_host_patterns,omitempty"`
+	MaxShortCodeLength  int      `yaml:"max_short_code_length"`
+}
+
+// file is the on-disk shape of the overrides YAML: a map of tenant ID
+// (Auth0 sub or org id) to that tenant's overrides.
+type file struct {
+	Overrides map[string]Limits `yaml:"overrides"`
+}
+
+// Overrides serves per-tenant Limits, falling back to defaults derived
+// from config.Config, and hot-reloads its backing YAML file on change.
+type Overrides struct {
+	defaults Limits
+	path     string
+
+	mu        sync.RWMutex
+	overrides map[string]Limits
+
+	watcher *fsnotify.Watcher
+}
+
+// defaultsFrom builds the fallback Limits from the service's env-configured
+// defaults.
+func defaultsFrom(cfg *config.Config) Limits {
+	return Limits{
+		MaxURLsPerUser:     0, // 0 = unlimited
+		MaxRedirectsPerSec: cfg.RateLimitRedirectPerSecond,
+		MaxTTLDays:         cfg.DefaultTTLDays,
+		MaxShortCodeLength: 32,
+	}
+}
+
+// NewOverrides loads the overrides YAML at path (if non-empty and it
+// exists) and starts watching it for changes. An empty path disables
+// per-tenant overrides entirely - every tenant gets the defaults.
+func NewOverrides(cfg *config.Config, path string) (*Overrides, error) {
+	o := &Overrides{
+		defaults:  defaultsFrom(cfg),
+		path:      path,
+		overrides: make(map[string]Limits),
+	}
+
+	if path == "" {
+		return o, nil
+	}
+
+	if err := o.reload(); err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(path); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	o.watcher = watcher
+
+	go o.watch()
+
+	return o, nil
+}
+
+// For returns the effective Limits for tenantID, falling back to defaults
+// when the tenant has no override (including an empty tenantID, e.g. an
+// unauthenticated redirect whose owner couldn't be resolved).
+func (o *Overrides) For(tenantID string) Limits {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	if l, ok := o.overrides[tenantID]; ok {
+		return l
+	}
+	return o.defaults
+}
+
+// Reload re-reads the overrides file on demand, e.g. from a SIGHUP
+// handler in cmd/server.
+func (o *Overrides) Reload() {
+	if o.path == "" {
+		return
+	}
+	if err := o.reload(); err != nil {
+		log.Printf("limits: failed to reload %s: %v", o.path, err)
+	}
+}
+
+func (o *Overrides) reload() error {
+	data, err := os.ReadFile(o.path)
+	if err != nil {
+		return err
+	}
+
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.overrides = f.Overrides
+	o.mu.Unlock()
+
+	log.Printf("limits: loaded %d tenant override(s) from %s", len(f.Overrides), o.path)
+	return nil
+}
+
+func (o *Overrides) watch() {
+	for {
+		select {
+		case event, ok := <-o.watcher.Events:
+			if !ok {
+				return
+			}
+			// Editors often replace the file (write+rename) rather than
+			// truncate it in place, so react to more than just Write.
+			if event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+				o.Reload()
+			}
+		case err, ok := <-o.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("limits: watcher error: %v", err)
+		}
+	}
+}
+
+// Close stops the file watcher, if any.
+func (o *Overrides) Close() error {
+	if o.watcher == nil {
+		return nil
+	}
+	return o.watcher.Close()
+}
+
+// AllowsHost reports whether destURL's host passes this tenant's
+// allow/deny host patterns. Deny patterns win over allow patterns. A
+// pattern matches if it equals the host or the host has it as a
+// suffix after a ".", so "example.com" also matches "sub.example.com".
+func (l Limits) AllowsHost(host string) bool {
+	for _, pattern := range l.DeniedHostPatterns {
+		if hostMatches(host, pattern) {
+			return false
+		}
+	}
+
+	if len(l.AllowedHostPatterns) == 0 {
+		return true
+	}
+	for _, pattern := range l.AllowedHostPatterns {
+		if hostMatches(host, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func hostMatches(host, pattern string) bool {
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
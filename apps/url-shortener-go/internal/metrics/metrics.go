@@ -0,0 +1,153 @@
+// Package metrics exposes Prometheus metrics for the URL shortener.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ActiveVisitors tracks the approximate number of distinct visitors
+// (by hashed IP) seen per short code over the trailing window, computed
+// from the visitor HyperLogLog buckets by the analytics worker.
+var ActiveVisitors = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "url_shortener_active_visitors",
+		Help: "Approximate distinct visitors per short code over the trailing window",
+	},
+	[]string{"short_code", "window"},
+)
+
+// ActiveShortCodes tracks the approximate number of distinct short codes
+// that received at least one redirect over the trailing window.
+var ActiveShortCodes = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "url_shortener_active_short_codes",
+		Help: "Approximate distinct short codes redirected over the trailing window",
+	},
+	[]string{"window"},
+)
+
+// HTTPRequestDuration tracks handler latency per route and method, so the
+// service can query its own P95 back from Prometheus (see
+// internal/adaptive) and so operators get a standard RED-style histogram.
+var HTTPRequestDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency in seconds",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"route", "method", "status"},
+)
+
+// LimitRejections counts requests rejected by a per-tenant override from
+// internal/limits, labeled by the limit that tripped and the tenant it
+// tripped for.
+var LimitRejections = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "urlshortener_limit_rejections_total",
+		Help: "Requests rejected by a per-tenant limit override",
+	},
+	[]string{"reason", "tenant"},
+)
+
+// DroppedEvents counts background work (click-event recording, cache
+// write-through) dropped because the bounded event pool's queue was full,
+// labeled by which stage dropped it.
+var DroppedEvents = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "dropped_events_total",
+		Help: "Background redirect-path events dropped because the event pool queue was full",
+	},
+	[]string{"stage"},
+)
+
+// clickIngestRows counts Click rows processed by internal/db's
+// ClickIngester, labeled by what happened to them. ClickIngestEnqueued,
+// ClickIngestDropped, ClickIngestFlushed and ClickIngestFailed below are
+// its four outcomes, pre-bound so callers don't repeat the label.
+var clickIngestRows = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "click_ingest_rows_total",
+		Help: "Click rows processed by the batched click ingester, by outcome",
+	},
+	[]string{"outcome"},
+)
+
+var (
+	ClickIngestEnqueued = clickIngestRows.WithLabelValues("enqueued")
+	ClickIngestDropped  = clickIngestRows.WithLabelValues("dropped")
+	ClickIngestFlushed  = clickIngestRows.WithLabelValues("flushed")
+	ClickIngestFailed   = clickIngestRows.WithLabelValues("failed")
+)
+
+// FlusherBatchSize tracks how many click events worker.Flusher processes
+// per handleBatch call.
+var FlusherBatchSize = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "flusher_batch_size",
+		Help:    "Number of click events processed per Flusher batch",
+		Buckets: prometheus.ExponentialBuckets(1, 2, 10),
+	},
+)
+
+// FlusherIngestLag tracks the delay between when a click happened
+// (ClickEvent.Timestamp) and when the Flusher enqueued it for insertion,
+// so a growing lag (backpressure, a slow Postgres) shows up directly.
+var FlusherIngestLag = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "flusher_ingest_lag_seconds",
+		Help:    "Seconds between a click event's timestamp and the Flusher enqueuing it",
+		Buckets: prometheus.DefBuckets,
+	},
+)
+
+// FlusherDBErrors counts errors returned by the click ingester while the
+// Flusher was processing a batch.
+var FlusherDBErrors = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "flusher_db_errors_total",
+		Help: "Errors enqueuing a click for insertion into TimescaleDB",
+	},
+)
+
+// FlusherStreamPending mirrors analytics:stream's current pending-entries
+// count (the same number served by GET /debug/flusher), sampled
+// periodically so it shows up in Grafana without scraping the debug
+// endpoint separately.
+var FlusherStreamPending = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "flusher_stream_pending",
+		Help: "Entries in analytics:stream not yet acknowledged by the flusher consumer group",
+	},
+)
+
+// cacheLookups counts redirect-path cache.GetURL lookups, labeled by
+// outcome ("hit" or "miss"). CacheHits and CacheMisses below are its two
+// outcomes, pre-bound so callers don't repeat the label.
+var cacheLookups = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "redirect_cache_lookups_total",
+		Help: "Redirect-path URL cache lookups, by outcome",
+	},
+	[]string{"outcome"},
+)
+
+var (
+	CacheHits   = cacheLookups.WithLabelValues("hit")
+	CacheMisses = cacheLookups.WithLabelValues("miss")
+)
+
+func init() {
+	prometheus.MustRegister(
+		ActiveVisitors, ActiveShortCodes, HTTPRequestDuration, LimitRejections, DroppedEvents, clickIngestRows,
+		FlusherBatchSize, FlusherIngestLag, FlusherDBErrors, FlusherStreamPending, cacheLookups,
+	)
+}
+
+// Handler returns an http.Handler serving the Prometheus exposition
+// format, for mounting at /metrics.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+)
+
+// ServeAdmin serves GET /metrics on addr until the process exits, using a
+// dedicated net/http server rather than the main Fiber app so a scraper
+// (or a heap/CPU profile pull) never competes with redirect traffic for a
+// request-handling goroutine. When pprofEnabled is true, it also attaches
+// net/http/pprof's standard handlers under /debug/pprof/ for capturing
+// production profiles on demand. It blocks; call it with `go`.
+func ServeAdmin(addr string, pprofEnabled bool) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	if pprofEnabled {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	log.Printf("Serving admin metrics endpoint on %s (pprof=%v)", addr, pprofEnabled)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		log.Printf("Admin metrics server exited: %v", err)
+	}
+}
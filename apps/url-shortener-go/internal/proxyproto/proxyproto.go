@@ -0,0 +1,243 @@
+// Package proxyproto implements just enough of the PROXY protocol
+// (https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt) to let
+// the redirect hot path see a client's real IP when it sits behind an L4
+// load balancer (ELB, HAProxy, envoy) that terminates TCP in front of it.
+// Without it, every click's IP, and therefore its GeoIP lookup, is the
+// load balancer's address instead of the visitor's.
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// v2Signature is the fixed 12-byte prefix of every PROXY protocol v2
+// header, chosen by the spec to be invalid as the start of any HTTP
+// request line.
+var v2Signature = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+
+// maxV1HeaderLen is the longest a v1 header can legally be (spec section
+// 2.1: "the receiver must be prepared to read up to 107 bytes").
+const maxV1HeaderLen = 107
+
+// Listener wraps an inner net.Listener, parsing a PROXY protocol header
+// off each new connection that originates from a trusted proxy and
+// rewriting that connection's RemoteAddr to the real client address it
+// carries. Connections from untrusted sources are passed through
+// unmodified - a PROXY header from the actual Internet-facing client
+// can't be trusted, since the client could write it to spoof its IP.
+type Listener struct {
+	net.Listener
+	trusted []*net.IPNet
+}
+
+// NewListener parses trustedCIDRs (e.g. "10.0.0.0/8,172.16.0.0/12") and
+// returns a Listener wrapping inner. A connection is only parsed for a
+// PROXY header if its remote address falls inside one of these ranges.
+func NewListener(inner net.Listener, trustedCIDRs string) (*Listener, error) {
+	var nets []*net.IPNet
+	for _, raw := range strings.Split(trustedCIDRs, ",") {
+		cidr := strings.TrimSpace(raw)
+		if cidr == "" {
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy CIDR %q: %w", cidr, err)
+		}
+		nets = append(nets, ipNet)
+	}
+	return &Listener{Listener: inner, trusted: nets}, nil
+}
+
+// Accept accepts the next connection, parsing and stripping a PROXY
+// protocol header if the connection comes from a trusted proxy. A
+// trusted connection that doesn't present a valid header is rejected
+// outright, rather than silently falling back to the proxy's own
+// address, since an enabled PROXY_PROTOCOL_ENABLED implies every
+// connection through the trusted proxy carries one.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if !l.isTrusted(c.RemoteAddr()) {
+			return c, nil
+		}
+
+		wrapped, err := parseHeader(c)
+		if err != nil {
+			c.Close()
+			continue
+		}
+		return wrapped, nil
+	}
+}
+
+func (l *Listener) isTrusted(addr net.Addr) bool {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, n := range l.trusted {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// conn wraps a net.Conn, reporting a different RemoteAddr than the
+// underlying transport connection's.
+type conn struct {
+	net.Conn
+	remoteAddr net.Addr
+}
+
+func (c *conn) RemoteAddr() net.Addr {
+	return c.remoteAddr
+}
+
+// parseHeader peeks c's first bytes to determine whether a v1 (text) or
+// v2 (binary) PROXY header follows, consumes exactly that header off the
+// stream, and returns c wrapped so RemoteAddr reports the real client
+// address the header carried.
+func parseHeader(c net.Conn) (net.Conn, error) {
+	r := bufio.NewReaderSize(c, maxV1HeaderLen)
+
+	sigPrefix, err := r.Peek(1)
+	if err != nil {
+		return nil, fmt.Errorf("peek proxy header: %w", err)
+	}
+
+	var remoteAddr net.Addr
+	if sigPrefix[0] == v2Signature[0] {
+		remoteAddr, err = parseV2(r)
+	} else {
+		remoteAddr, err = parseV1(r)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// r may have buffered bytes past the header (the client's first real
+	// request), so the connection returned to the caller must read
+	// through r, not c, from here on.
+	return &conn{Conn: &bufferedConn{Conn: c, r: r}, remoteAddr: remoteAddr}, nil
+}
+
+// bufferedConn routes Read through a bufio.Reader that may already hold
+// bytes read past the PROXY header, while leaving every other net.Conn
+// method (Write, Close, deadlines, ...) to the real connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (b *bufferedConn) Read(p []byte) (int, error) {
+	return b.r.Read(p)
+}
+
+// parseV1 reads a text "PROXY TCP4|TCP6|UNKNOWN src dst sport dport\r\n"
+// header and returns the source address it carries.
+func parseV1(r *bufio.Reader) (net.Addr, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read v1 proxy header: %w", err)
+	}
+	line = strings.TrimRight(line, "\r\n")
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "PROXY" {
+		return nil, errors.New("malformed v1 proxy header")
+	}
+
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, errors.New("proxy protocol UNKNOWN connection")
+	case "TCP4", "TCP6":
+		if len(fields) != 6 {
+			return nil, errors.New("malformed v1 proxy header: wrong field count")
+		}
+		srcIP := net.ParseIP(fields[2])
+		if srcIP == nil {
+			return nil, fmt.Errorf("malformed v1 proxy header: invalid source IP %q", fields[2])
+		}
+		srcPort, err := strconv.Atoi(fields[4])
+		if err != nil {
+			return nil, fmt.Errorf("malformed v1 proxy header: invalid source port %q", fields[4])
+		}
+		return &net.TCPAddr{IP: srcIP, Port: srcPort}, nil
+	default:
+		return nil, fmt.Errorf("malformed v1 proxy header: unknown protocol %q", fields[1])
+	}
+}
+
+// parseV2 reads a binary PROXY protocol v2 header (12-byte signature,
+// version/command byte, family/protocol byte, 2-byte big-endian address
+// block length, then the address block) and returns the source address
+// it carries.
+func parseV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, 16)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("read v2 proxy header: %w", err)
+	}
+
+	if !bytes.Equal(header[:12], v2Signature) {
+		return nil, errors.New("malformed v2 proxy header: bad signature")
+	}
+
+	version := header[12] >> 4
+	if version != 2 {
+		return nil, fmt.Errorf("unsupported proxy protocol version %d", version)
+	}
+	command := header[12] & 0x0F
+
+	family := header[13] >> 4
+	addrLen := binary.BigEndian.Uint16(header[14:16])
+
+	addr := make([]byte, addrLen)
+	if _, err := io.ReadFull(r, addr); err != nil {
+		return nil, fmt.Errorf("read v2 proxy address block: %w", err)
+	}
+
+	// LOCAL connections (health checks from the proxy itself) carry no
+	// meaningful address; the caller treats this as "no header" by
+	// erroring so that path falls back to rejecting rather than
+	// fabricating an address.
+	if command == 0x00 {
+		return nil, errors.New("proxy protocol v2 LOCAL connection")
+	}
+
+	switch family {
+	case 0x01: // AF_INET
+		if len(addr) < 12 {
+			return nil, errors.New("malformed v2 proxy header: short IPv4 address block")
+		}
+		srcIP := net.IP(addr[0:4])
+		srcPort := binary.BigEndian.Uint16(addr[8:10])
+		return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, nil
+	case 0x02: // AF_INET6
+		if len(addr) < 36 {
+			return nil, errors.New("malformed v2 proxy header: short IPv6 address block")
+		}
+		srcIP := net.IP(addr[0:16])
+		srcPort := binary.BigEndian.Uint16(addr[32:34])
+		return &net.TCPAddr{IP: srcIP, Port: int(srcPort)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported proxy protocol address family %d", family)
+	}
+}
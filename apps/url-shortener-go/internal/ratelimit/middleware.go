@@ -0,0 +1,69 @@
+// Package ratelimit provides a Fiber middleware that enforces
+// RedisCache's sliding-window rate limit and reports standard
+// X-RateLimit-* headers.
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config configures a rate-limit middleware instance.
+type Config struct {
+	// Limit is the maximum number of requests allowed per Window. Ignored
+	// if LimitFunc is set.
+	Limit int
+	// LimitFunc, if set, is consulted on every request instead of Limit,
+	// letting the effective limit be adjusted at runtime (e.g. by a
+	// self-adaptive limiter) without rebuilding the middleware.
+	LimitFunc func() int
+	// Window is the sliding window duration.
+	Window time.Duration
+	// KeyFunc derives the rate-limit key for a request.
+	KeyFunc func(c *fiber.Ctx) string
+}
+
+// Middleware enforces cfg.Limit (or the live value of cfg.LimitFunc)
+// requests per cfg.Window per key, backed by RedisCache.CheckRateLimit.
+// It sets X-RateLimit-Limit/Remaining/Reset on every response and
+// responds 429 with Retry-After once the limit is hit. A Redis error
+// fails open rather than blocking traffic on a cache outage.
+func Middleware(redisCache *cache.RedisCache, cfg Config) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := cfg.Limit
+		if cfg.LimitFunc != nil {
+			limit = cfg.LimitFunc()
+		}
+
+		key := cfg.KeyFunc(c)
+
+		allowed, remaining, retryAfter, err := redisCache.CheckRateLimit(c.Context(), key, limit, cfg.Window)
+		if err != nil {
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(retryAfter).Unix(), 10))
+
+		if !allowed {
+			c.Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "rate limit exceeded",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// ByIP builds a KeyFunc that rate-limits per client IP, namespaced by
+// prefix so different endpoints don't share a budget.
+func ByIP(prefix string) func(c *fiber.Ctx) string {
+	return func(c *fiber.Ctx) string {
+		return prefix + ":" + c.IP()
+	}
+}
@@ -0,0 +1,229 @@
+// Package realtime fans out live analytics events (currently just clicks,
+// see worker.Flusher) to SSE subscribers across processes.
+//
+// It plays the same role as db.clickBroker does for GET
+// /v1/urls/:id/clicks/stream, but for a different endpoint and over a
+// different transport: clickBroker fans out from a single shared Postgres
+// LISTEN connection and backs Last-Event-ID resume with a durable query
+// against the clicks hypertable, while Hub fans out over Redis Pub/Sub
+// (since the flusher worker and the API server are separate processes)
+// and backs resume with a small bounded in-memory ring buffer, not a
+// durable store - a reconnecting client can miss events if nobody was
+// subscribed for a while, which clickBroker's DB-backed replay avoids.
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/google/uuid"
+)
+
+// Channel is the single Redis Pub/Sub channel every Hub instance
+// publishes to and listens on; events carry their own URL ID so one
+// subscription can fan out to every URL's local subscribers instead of
+// opening one Redis subscription per URL.
+const Channel = "realtime:analytics"
+
+// ringSize bounds how many recent events Hub buffers per URL ID for a
+// reconnecting subscriber's Last-Event-ID replay.
+const ringSize = 50
+
+// subscriberBufferSize bounds how many unconsumed events a single
+// Subscribe caller may have buffered before Hub starts dropping the
+// oldest queued event to make room for the newest one.
+const subscriberBufferSize = 32
+
+// Event is one message fanned out by Hub.
+type Event struct {
+	ID   string
+	Time time.Time
+	Data json.RawMessage
+}
+
+// wireEvent is the envelope actually published on Channel.
+type wireEvent struct {
+	URLID uuid.UUID       `json:"url_id"`
+	Time  time.Time       `json:"time"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// subscriber is one Subscribe caller's mailbox.
+type subscriber struct {
+	ch chan Event
+}
+
+// ring is a small append-and-trim buffer of the most recent events
+// published for one URL ID.
+type ring struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *ring) push(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	if len(r.events) > ringSize {
+		r.events = r.events[len(r.events)-ringSize:]
+	}
+}
+
+func (r *ring) since(t time.Time) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Event, 0, len(r.events))
+	for _, e := range r.events {
+		if e.Time.After(t) {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Hub fans live analytics events out to subscribers by URL ID, via a
+// single shared Redis Pub/Sub subscription started lazily on first
+// Subscribe call, mirroring db.clickBroker's lazy-start shared LISTEN
+// connection.
+type Hub struct {
+	cache *cache.RedisCache
+
+	startOnce sync.Once
+
+	mu    sync.Mutex
+	subs  map[uuid.UUID]map[*subscriber]struct{}
+	rings map[uuid.UUID]*ring
+}
+
+// NewHub creates a Hub backed by redisCache's connection.
+func NewHub(redisCache *cache.RedisCache) *Hub {
+	return &Hub{
+		cache: redisCache,
+		subs:  map[uuid.UUID]map[*subscriber]struct{}{},
+		rings: map[uuid.UUID]*ring{},
+	}
+}
+
+// Publish fans data out to every Subscribe(urlID) caller, in this process
+// or any other reachable over the same Redis instance.
+func (h *Hub) Publish(ctx context.Context, urlID uuid.UUID, data json.RawMessage) error {
+	payload, err := json.Marshal(wireEvent{URLID: urlID, Time: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("realtime: marshal event: %w", err)
+	}
+	if err := h.cache.Publish(ctx, Channel, payload); err != nil {
+		return fmt.Errorf("realtime: publish: %w", err)
+	}
+	return nil
+}
+
+// Subscribe registers a new subscriber for urlID and returns its receive
+// channel, starting the shared Redis subscription on first call. The
+// returned channel is closed once ctx is done; a subscriber on a slow
+// consumer may miss events rather than backpressure the shared listen
+// loop, see subscriberBufferSize.
+func (h *Hub) Subscribe(ctx context.Context, urlID uuid.UUID) <-chan Event {
+	h.startOnce.Do(func() { go h.listenLoop() })
+
+	sub := &subscriber{ch: make(chan Event, subscriberBufferSize)}
+
+	h.mu.Lock()
+	if h.subs[urlID] == nil {
+		h.subs[urlID] = map[*subscriber]struct{}{}
+	}
+	h.subs[urlID][sub] = struct{}{}
+	h.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		h.mu.Lock()
+		delete(h.subs[urlID], sub)
+		if len(h.subs[urlID]) == 0 {
+			delete(h.subs, urlID)
+			// No one is left to Replay() for urlID - drop its ring too,
+			// rather than keep buffering events for it forever. A
+			// reconnect that lands in the gap between this delete and
+			// dispatch re-creating the ring just gets a shorter replay
+			// window, same as a brand new subscriber would.
+			delete(h.rings, urlID)
+		}
+		h.mu.Unlock()
+		close(sub.ch)
+	}()
+
+	return sub.ch
+}
+
+// Replay returns every event buffered for urlID since t, oldest first, for
+// a reconnecting SSE client to catch up on before Subscribe's channel
+// picks up with live events. It's served from the in-memory ring buffer,
+// not a durable store, so it only covers a short gap - see the package doc.
+func (h *Hub) Replay(urlID uuid.UUID, t time.Time) []Event {
+	h.mu.Lock()
+	r, ok := h.rings[urlID]
+	h.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return r.since(t)
+}
+
+// listenLoop holds the single shared Redis Pub/Sub subscription against
+// Channel and dispatches every message it receives. It runs for the
+// lifetime of the process, not tied to any one subscriber's context,
+// since other subscribers must keep receiving events after the first one
+// disconnects.
+func (h *Hub) listenLoop() {
+	ctx := context.Background()
+	sub := h.cache.Subscribe(ctx, Channel)
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		var we wireEvent
+		if err := json.Unmarshal([]byte(msg.Payload), &we); err != nil {
+			log.Printf("realtime: failed to unmarshal event payload: %v", err)
+			continue
+		}
+		h.dispatch(we)
+	}
+}
+
+// dispatch records we in its URL ID's ring buffer and fans it out to every
+// locally-registered subscriber for that URL ID, dropping the oldest
+// buffered event for a subscriber whose buffer is full rather than
+// blocking the shared listen loop on a slow consumer.
+func (h *Hub) dispatch(we wireEvent) {
+	event := Event{ID: we.Time.Format(time.RFC3339Nano), Time: we.Time, Data: we.Data}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	r, ok := h.rings[we.URLID]
+	if !ok {
+		r = &ring{}
+		h.rings[we.URLID] = r
+	}
+	r.push(event)
+
+	for sub := range h.subs[we.URLID] {
+		select {
+		case sub.ch <- event:
+		default:
+			select {
+			case <-sub.ch:
+			default:
+			}
+			select {
+			case sub.ch <- event:
+			default:
+			}
+			metrics.DroppedEvents.WithLabelValues("live_analytics").Inc()
+		}
+	}
+}
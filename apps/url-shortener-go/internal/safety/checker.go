@@ -0,0 +1,270 @@
+// Package safety classifies a short URL's destination content at
+// creation time, so operators can flag or block links that point at
+// unsafe pages before they're ever shared.
+package safety
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/ssrf"
+)
+
+// Mode controls what CreateURL does with a Flagged Result.
+type Mode int
+
+const (
+	// ModeOff skips the content-classification gate entirely.
+	ModeOff Mode = iota
+	// ModeWarn stores the URL with is_active=false and a metadata.safety
+	// block instead of rejecting the request outright.
+	ModeWarn
+	// ModeBlock rejects URL creation with ErrDestinationFlagged.
+	ModeBlock
+)
+
+// ParseMode maps a stored/configured safety mode string to a Mode,
+// defaulting to ModeWarn for an empty or unrecognized value so a missing
+// per-user preference fails toward caution rather than wide open.
+func ParseMode(s string) Mode {
+	switch strings.ToLower(s) {
+	case "off":
+		return ModeOff
+	case "block":
+		return ModeBlock
+	default:
+		return ModeWarn
+	}
+}
+
+func (m Mode) String() string {
+	switch m {
+	case ModeOff:
+		return "off"
+	case ModeBlock:
+		return "block"
+	default:
+		return "warn"
+	}
+}
+
+// Config configures a Checker's destination fetch.
+type Config struct {
+	// Enabled gates whether Checker.Check runs at all; when false, Check
+	// returns a zero Result immediately without making any network call.
+	Enabled bool
+	// FetchTimeout bounds the robots.txt, HEAD, and GET requests combined.
+	FetchTimeout time.Duration
+	// MaxBodyBytes caps how much of the destination's response body is
+	// read before classification, so a huge or slow-loris response can't
+	// stall URL creation or blow up memory.
+	MaxBodyBytes int64
+}
+
+// Result is the outcome of classifying a destination URL's content.
+type Result struct {
+	Flagged    bool
+	Score      float64
+	Categories []string
+}
+
+// Checker fetches a destination URL's page content and classifies it,
+// gating URL creation on the result.
+type Checker struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewChecker builds a Checker from cfg. guard re-resolves the
+// destination's host on every dial this Checker makes (robots.txt, HEAD,
+// GET) and refuses to connect to an unsafe IP - the destination was
+// already validated at shorten time by ValidatorService, but a DNS
+// record can be rebound between then and this fetch, so the same guard
+// runs again here, at actual connection time.
+func NewChecker(cfg Config, guard *ssrf.Guard) *Checker {
+	return &Checker{
+		cfg: cfg,
+		client: &http.Client{
+			Timeout:   cfg.FetchTimeout,
+			Transport: &http.Transport{DialContext: guard.DialContext},
+		},
+	}
+}
+
+// titleRe/descriptionRe/tagRe are deliberately simple: they're run once
+// per URL creation against a bounded prefix of the page, not used to
+// render anything, so a lightweight regex extraction is enough and
+// avoids pulling in a full HTML parser for this one call site.
+var (
+	titleRe       = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	descriptionRe = regexp.MustCompile(`(?is)<meta[^>]+name=["']description["'][^>]+content=["']([^"']*)["']`)
+	tagRe         = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Check fetches destinationURL's page (respecting robots.txt, bounded by
+// cfg.FetchTimeout/MaxBodyBytes), extracts its title, meta description,
+// and visible text, and classifies that plus the URL's own path/query.
+// It returns a zero Result, nil when the checker is disabled or the
+// fetch can't be completed - a dead or unreachable destination isn't
+// itself unsafe content, so Check fails open rather than blocking
+// creation on a network hiccup.
+func (c *Checker) Check(ctx context.Context, destinationURL string) (*Result, error) {
+	if !c.cfg.Enabled {
+		return &Result{}, nil
+	}
+
+	parsed, err := url.Parse(destinationURL)
+	if err != nil {
+		return &Result{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.FetchTimeout)
+	defer cancel()
+
+	if !c.robotsAllow(ctx, parsed) {
+		return &Result{}, nil
+	}
+
+	if !c.htmlResponse(ctx, destinationURL) {
+		return &Result{}, nil
+	}
+
+	body, err := c.fetchBody(ctx, destinationURL)
+	if err != nil {
+		return &Result{}, nil
+	}
+
+	text := extractText(body) + " " + parsed.Path + " " + parsed.RawQuery
+	flagged, score, categories := classifyContent(text)
+
+	return &Result{Flagged: flagged, Score: score, Categories: categories}, nil
+}
+
+// robotsAllow fetches dest's robots.txt and reports whether its
+// User-agent: * rules allow fetching dest's path. A missing or
+// unreadable robots.txt is treated as allow-all, matching standard
+// crawler behavior.
+func (c *Checker) robotsAllow(ctx context.Context, dest *url.URL) bool {
+	robotsURL := fmt.Sprintf("%s://%s/robots.txt", dest.Scheme, dest.Host)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil)
+	if err != nil {
+		return true
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return true
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return true
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.cfg.MaxBodyBytes))
+	if err != nil {
+		return true
+	}
+
+	return robotsTxtAllows(string(body), dest.Path)
+}
+
+// robotsTxtAllows implements just enough of robots.txt to respect a
+// destination's wishes for this one-shot classification fetch: it
+// applies the first "User-agent: *" block's Disallow prefixes to path.
+func robotsTxtAllows(robotsTxt, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	inWildcardBlock := false
+	for _, line := range strings.Split(robotsTxt, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field = strings.ToLower(strings.TrimSpace(field))
+		value = strings.TrimSpace(value)
+
+		switch field {
+		case "user-agent":
+			inWildcardBlock = value == "*"
+		case "disallow":
+			if inWildcardBlock && value != "" && strings.HasPrefix(path, value) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// htmlResponse issues a HEAD request and reports whether the destination
+// looks like an HTML page worth classifying, so Check doesn't waste a GET
+// downloading a PDF, image, or binary it can't usefully extract text from.
+func (c *Checker) htmlResponse(ctx context.Context, destinationURL string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, destinationURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// Some servers don't implement HEAD; fall back to attempting the GET.
+		return true
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	return contentType == "" || strings.Contains(contentType, "text/html")
+}
+
+// fetchBody GETs destinationURL and returns up to cfg.MaxBodyBytes of its
+// response body.
+func (c *Checker) fetchBody(ctx context.Context, destinationURL string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, destinationURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("destination returned status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.cfg.MaxBodyBytes))
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// extractText pulls the title, meta description, and a stripped-tags
+// rendering of the rest of an HTML document, which is all classifyContent
+// needs to look at.
+func extractText(html string) string {
+	var parts []string
+
+	if m := titleRe.FindStringSubmatch(html); m != nil {
+		parts = append(parts, m[1])
+	}
+	if m := descriptionRe.FindStringSubmatch(html); m != nil {
+		parts = append(parts, m[1])
+	}
+	parts = append(parts, tagRe.ReplaceAllString(html, " "))
+
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package safety
+
+import "strings"
+
+// The model-content-class-go app's inference.ClassifyContent does exactly
+// this keyword classification, but it lives under that app's internal/
+// package tree, which Go's internal-import rule keeps off-limits to
+// url-shortener-go's own module, and that app ships no HTTP server to
+// call instead. classifyContent is a small, deliberate duplicate of that
+// logic kept local to this package rather than promoted to a shared
+// module, since splitting it out isn't worth a cross-app dependency for
+// a few keyword lists.
+var (
+	violenceKeywords = []string{"violence", "fight", "weapon", "gun", "blood", "injury", "assault"}
+	adultKeywords    = []string{"adult", "explicit", "nsfw", "nude", "sexual"}
+	spamKeywords     = []string{"buy now", "click here", "free", "winner", "prize", "urgent", "act now"}
+	drugKeywords     = []string{"drug", "cocaine", "heroin", "meth", "opioid"}
+)
+
+// classifyContent performs keyword-based content classification, scoring
+// flagged text 0.5-1.0 in proportion to how many category keyword sets it hits.
+func classifyContent(text string) (flagged bool, score float64, categories []string) {
+	lowerText := strings.ToLower(text)
+
+	for _, k := range violenceKeywords {
+		if strings.Contains(lowerText, k) {
+			categories = append(categories, "violence")
+			break
+		}
+	}
+	for _, k := range adultKeywords {
+		if strings.Contains(lowerText, k) {
+			categories = append(categories, "adult")
+			break
+		}
+	}
+	for _, k := range spamKeywords {
+		if strings.Contains(lowerText, k) {
+			categories = append(categories, "spam")
+			break
+		}
+	}
+	for _, k := range drugKeywords {
+		if strings.Contains(lowerText, k) {
+			categories = append(categories, "drugs")
+			break
+		}
+	}
+
+	if len(categories) == 0 {
+		return false, 0.0, nil
+	}
+
+	score = float64(len(categories)) * 0.25
+	if score > 1.0 {
+		score = 1.0
+	}
+	if score < 0.5 {
+		score = 0.5
+	}
+
+	return true, score, categories
+}
@@ -2,8 +2,15 @@ package services
 
 import (
 	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
 	"image/png"
+	"strings"
 
+	"github.com/jung-kurt/gofpdf"
 	"github.com/skip2/go-qrcode"
 )
 
@@ -15,47 +22,384 @@ func NewQRService() *QRService {
 	return &QRService{}
 }
 
-// GeneratePNG generates a QR code as PNG bytes
-func (s *QRService) GeneratePNG(content string, size int) ([]byte, error) {
-	// Validate size
-	if size < 100 {
-		size = 100
+// QROptions customizes a generated QR code beyond its payload and pixel
+// size. The zero value renders the same Medium-correction, black-on-white
+// code with the library's default quiet zone that QRService always
+// produced before these options existed.
+type QROptions struct {
+	// Level is the error-correction level: "L", "M", "Q", or "H".
+	// Defaults to "M" when empty or unrecognized.
+	Level string
+
+	// Foreground and Background are "#rrggbb" (or "rrggbb") hex colors.
+	// Default to black-on-white when empty.
+	Foreground string
+	Background string
+
+	// QuietZone is the number of extra background-colored modules drawn
+	// around the code. PNG and PDF output draw them outside the quiet zone
+	// go-qrcode's renderer already adds, which is kept; SVG and EPS output
+	// draw them around qr.Bitmap()'s module grid. Negative or zero adds
+	// nothing to PNG and PDF output, while SVG and EPS output then use 4.
+	QuietZone int
+
+	// Logo, if non-empty, is decoded as a PNG and alpha-composited over
+	// the center of the code, sized to at most ~20% of the code's area.
+	// Presence of a logo forces Level to "H" regardless of the caller's
+	// setting, since a center logo covers enough modules that anything
+	// less risks being unscannable.
+	Logo []byte
+}
+
+// Fingerprint returns a short, stable hash of o suitable for namespacing a
+// cache key - so two requests for the same URL/size but different
+// QROptions don't collide on (and overwrite) each other's cached render.
+// The zero value (no customization) hashes to "", matching the pre-options
+// cache key format.
+func (o QROptions) Fingerprint() string {
+	if o.Level == "" && o.Foreground == "" && o.Background == "" && o.QuietZone <= 0 && len(o.Logo) == 0 {
+		return ""
 	}
-	if size > 1000 {
-		size = 1000
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%s|%s|%s|%d|", o.Level, o.Foreground, o.Background, o.QuietZone)
+	h.Write(o.Logo)
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// recoveryLevel maps Level to go-qrcode's RecoveryLevel, defaulting to
+// Medium for an empty or unrecognized value.
+func (o QROptions) recoveryLevel() qrcode.RecoveryLevel {
+	if len(o.Logo) > 0 {
+		return qrcode.Highest
+	}
+	switch strings.ToUpper(o.Level) {
+	case "L":
+		return qrcode.Low
+	case "Q":
+		return qrcode.High
+	case "H":
+		return qrcode.Highest
+	default:
+		return qrcode.Medium
 	}
+}
 
-	// Generate QR code
-	qr, err := qrcode.New(content, qrcode.Medium)
+// parseHexColor parses a "#rrggbb" or "rrggbb" string, returning fallback
+// if hex is empty or malformed.
+func parseHexColor(hex string, fallback color.Color) (color.Color, error) {
+	if hex == "" {
+		return fallback, nil
+	}
+	hex = strings.TrimPrefix(hex, "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid hex color %q", hex)
+	}
+	var r, g, b uint8
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &r, &g, &b); err != nil {
+		return nil, fmt.Errorf("invalid hex color %q: %w", hex, err)
+	}
+	return color.RGBA{R: r, G: g, B: b, A: 0xff}, nil
+}
+
+// buildQR constructs a *qrcode.QRCode with opts' level and colors applied.
+func buildQR(content string, opts QROptions) (*qrcode.QRCode, error) {
+	qr, err := qrcode.New(content, opts.recoveryLevel())
 	if err != nil {
 		return nil, err
 	}
 
-	// Create buffer and encode to PNG
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, qr.Image(size)); err != nil {
+	fg, err := parseHexColor(opts.Foreground, color.Black)
+	if err != nil {
+		return nil, err
+	}
+	bg, err := parseHexColor(opts.Background, color.White)
+	if err != nil {
+		return nil, err
+	}
+	qr.ForegroundColor = fg
+	qr.BackgroundColor = bg
+
+	return qr, nil
+}
+
+// renderImage rasterizes qr at size, applying opts' quiet zone and logo.
+func renderImage(qr *qrcode.QRCode, size int, opts QROptions) (image.Image, error) {
+	img := qr.Image(size)
+
+	if opts.QuietZone > 0 {
+		img = applyQuietZone(img, qr.BackgroundColor, size, opts.QuietZone, len(qr.Bitmap()))
+	}
+
+	if len(opts.Logo) > 0 {
+		composited, err := compositeLogo(img, opts.Logo)
+		if err != nil {
+			return nil, fmt.Errorf("composite logo: %w", err)
+		}
+		img = composited
+	}
+
+	return img, nil
+}
+
+// applyQuietZone redraws img onto a larger canvas with quietZoneModules
+// worth of extra background-colored border on each side, outside
+// whatever quiet zone go-qrcode's renderer already added (which is kept,
+// since img is drawn onto the canvas whole). moduleCount is
+// the QR's module-grid width (from qr.Bitmap()), used to convert
+// quietZoneModules into pixels at size's scale.
+func applyQuietZone(img image.Image, bg color.Color, size, quietZoneModules, moduleCount int) image.Image {
+	if moduleCount == 0 {
+		return img
+	}
+	pixelsPerModule := size / moduleCount
+	if pixelsPerModule == 0 {
+		pixelsPerModule = 1
+	}
+	border := quietZoneModules * pixelsPerModule
+
+	out := image.NewRGBA(image.Rect(0, 0, size+2*border, size+2*border))
+	draw.Draw(out, out.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+	draw.Draw(out, image.Rect(border, border, border+size, border+size), img, img.Bounds().Min, draw.Src)
+	return out
+}
+
+// compositeLogo decodes logoPNG, scales it to at most ~20% of img's area,
+// and alpha-composites it centered over img.
+func compositeLogo(img image.Image, logoPNG []byte) (image.Image, error) {
+	logo, err := png.Decode(bytes.NewReader(logoPNG))
+	if err != nil {
+		return nil, fmt.Errorf("decode logo: %w", err)
+	}
+
+	bounds := img.Bounds()
+	qrSide := bounds.Dx()
+	// Cap the logo's side at sqrt(0.20) of the QR's side so its area
+	// stays at or under ~20% of the code - past that, error correction
+	// can no longer reliably recover the obscured modules.
+	maxLogoSide := int(float64(qrSide) * 0.447)
+	logo = scaleToFit(logo, maxLogoSide)
+
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+
+	logoBounds := logo.Bounds()
+	offsetX := bounds.Min.X + (qrSide-logoBounds.Dx())/2
+	offsetY := bounds.Min.Y + (bounds.Dy()-logoBounds.Dy())/2
+	dstRect := image.Rect(offsetX, offsetY, offsetX+logoBounds.Dx(), offsetY+logoBounds.Dy())
+	draw.Draw(out, dstRect, logo, logoBounds.Min, draw.Over)
+
+	return out, nil
+}
+
+// scaleToFit nearest-neighbor scales img down so its longer side is at
+// most maxSide. img is returned unchanged if it's already small enough.
+func scaleToFit(img image.Image, maxSide int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	longer := w
+	if h > longer {
+		longer = h
+	}
+	if longer <= maxSide || maxSide <= 0 {
+		return img
+	}
+
+	scale := float64(maxSide) / float64(longer)
+	newW := int(float64(w) * scale)
+	newH := int(float64(h) * scale)
+	if newW < 1 {
+		newW = 1
+	}
+	if newH < 1 {
+		newH = 1
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, newW, newH))
+	for y := 0; y < newH; y++ {
+		for x := 0; x < newW; x++ {
+			srcX := b.Min.X + x*w/newW
+			srcY := b.Min.Y + y*h/newH
+			out.Set(x, y, img.At(srcX, srcY))
+		}
+	}
+	return out
+}
+
+// GeneratePNG generates a QR code as PNG bytes
+func (s *QRService) GeneratePNG(content string, size int, opts QROptions) ([]byte, error) {
+	size = clampSize(size)
+
+	qr, err := buildQR(content, opts)
+	if err != nil {
+		return nil, err
+	}
+	img, err := renderImage(qr, size, opts)
+	if err != nil {
 		return nil, err
 	}
 
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
 	return buf.Bytes(), nil
 }
 
 // GenerateSVG generates a QR code as SVG string
-func (s *QRService) GenerateSVG(content string, size int) (string, error) {
-	// Validate size
-	if size < 100 {
-		size = 100
+func (s *QRService) GenerateSVG(content string, size int, opts QROptions) (string, error) {
+	size = clampSize(size)
+
+	qr, err := buildQR(content, opts)
+	if err != nil {
+		return "", err
 	}
-	if size > 1000 {
-		size = 1000
+
+	if opts.Foreground == "" && opts.Background == "" && opts.QuietZone <= 0 && len(opts.Logo) == 0 {
+		// No customization requested - keep using the library's own
+		// renderer, which is a bit more compact than bitmapToSVG below.
+		return qr.ToSmallString(false), nil
 	}
 
-	// Generate QR code
-	qr, err := qrcode.New(content, qrcode.Medium)
+	return bitmapToSVG(qr, size, opts), nil
+}
+
+// bitmapToSVG renders qr's module grid as an SVG <rect> per dark module,
+// so custom foreground/background/quiet-zone options (which go-qrcode's
+// own ToString doesn't support) carry through to vector output too.
+func bitmapToSVG(qr *qrcode.QRCode, size int, opts QROptions) string {
+	bitmap := qr.Bitmap()
+	moduleCount := len(bitmap)
+	quietZone := 4
+	if opts.QuietZone > 0 {
+		quietZone = opts.QuietZone
+	}
+	total := moduleCount + 2*quietZone
+
+	fg := hexOrDefault(opts.Foreground, "#000000")
+	bg := hexOrDefault(opts.Background, "#ffffff")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d">`, total, total, size, size)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="%s"/>`, total, total, bg)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="1" height="1" fill="%s"/>`, x+quietZone, y+quietZone, fg)
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+func hexOrDefault(hex, fallback string) string {
+	if hex == "" {
+		return fallback
+	}
+	if !strings.HasPrefix(hex, "#") {
+		hex = "#" + hex
+	}
+	return hex
+}
+
+// GeneratePDF generates a single-page PDF sized to the QR code, for
+// printable assets (stickers, flyers, packaging inserts).
+func (s *QRService) GeneratePDF(content string, size int, opts QROptions) ([]byte, error) {
+	pngData, err := s.GeneratePNG(content, size, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// gofpdf works in points; render the code at its pixel size 1:1 so
+	// the PDF page is exactly as large as the code plus a small margin.
+	marginMM := 5.0
+	sizeMM := float64(size) * 25.4 / 96.0
+
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		SizeStr:        "",
+		Size:           gofpdf.SizeType{Wd: sizeMM + 2*marginMM, Ht: sizeMM + 2*marginMM},
+	})
+	pdf.SetMargins(0, 0, 0)
+	pdf.AddPage()
+
+	imageOpts := gofpdf.ImageOptions{ImageType: "PNG", ReadDpi: true}
+	pdf.RegisterImageOptionsReader("qr", imageOpts, bytes.NewReader(pngData))
+	pdf.ImageOptions("qr", marginMM, marginMM, sizeMM, sizeMM, false, imageOpts, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// GenerateEPS generates an Encapsulated PostScript rendering of the QR
+// code for vector print workflows. Unlike GeneratePNG/GeneratePDF, this
+// draws each dark module as a filled PostScript rectangle straight from
+// the bitmap rather than rasterizing, so it doesn't support a center
+// logo - alpha compositing has no vector equivalent here.
+func (s *QRService) GenerateEPS(content string, size int, opts QROptions) (string, error) {
+	qr, err := buildQR(content, opts)
+	if err != nil {
+		return "", err
+	}
+
+	bitmap := qr.Bitmap()
+	moduleCount := len(bitmap)
+	quietZone := 4
+	if opts.QuietZone > 0 {
+		quietZone = opts.QuietZone
+	}
+	total := moduleCount + 2*quietZone
+
+	fg, err := parseHexColor(opts.Foreground, color.Black)
 	if err != nil {
 		return "", err
 	}
+	bg, err := parseHexColor(opts.Background, color.White)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%%!PS-Adobe-3.0 EPSF-3.0\n")
+	fmt.Fprintf(&b, "%%%%BoundingBox: 0 0 %d %d\n", total, total)
+	fmt.Fprintf(&b, "%%%%Title: QR Code\n")
+	fmt.Fprintf(&b, "%%%%EndComments\n")
+	fmt.Fprintf(&b, "%s setrgbcolor\n", epsColor(bg))
+	fmt.Fprintf(&b, "0 0 %d %d rectfill\n", total, total)
+	fmt.Fprintf(&b, "%s setrgbcolor\n", epsColor(fg))
+	for y, row := range bitmap {
+		// PostScript's origin is bottom-left; flip the row index so the
+		// code isn't rendered upside down.
+		psY := total - quietZone - y - 1
+		for x, dark := range row {
+			if !dark {
+				continue
+			}
+			fmt.Fprintf(&b, "%d %d 1 1 rectfill\n", x+quietZone, psY)
+		}
+	}
+	b.WriteString("%%EOF\n")
+	return b.String(), nil
+}
+
+// epsColor formats c as PostScript "r g b" components in [0, 1].
+func epsColor(c color.Color) string {
+	r, g, bl, _ := c.RGBA()
+	return fmt.Sprintf("%.3f %.3f %.3f", float64(r)/0xffff, float64(g)/0xffff, float64(bl)/0xffff)
+}
 
-	// Generate SVG using the library's built-in method
-	return qr.ToSmallString(false), nil
+func clampSize(size int) int {
+	if size < 100 {
+		return 100
+	}
+	if size > 1000 {
+		return 1000
+	}
+	return size
 }
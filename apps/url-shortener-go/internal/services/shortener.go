@@ -11,6 +11,7 @@ import (
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/google/uuid"
 )
 
 const (
@@ -34,34 +35,37 @@ func NewShortenerService(cache *cache.RedisCache, database *db.DB, cfg *config.C
 	}
 }
 
-// GenerateCode generates a new random alphanumeric short code
-func (s *ShortenerService) GenerateCode(ctx context.Context) (string, error) {
+// GenerateCode generates a new random alphanumeric short code, unique
+// within customDomainID's namespace (nil for the default BaseURL host).
+func (s *ShortenerService) GenerateCode(ctx context.Context, customDomainID *uuid.UUID) (string, error) {
 	maxRetries := 5
-	
+
 	for i := 0; i < maxRetries; i++ {
 		// Generate random code
 		code := generateRandomCode(s.cfg.ShortCodeMinLength)
-		
+
 		// Check for collision
-		exists, err := s.codeExists(ctx, code)
+		exists, err := s.codeExists(ctx, code, customDomainID)
 		if err != nil {
 			return "", fmt.Errorf("failed to check code existence: %w", err)
 		}
-		
+
 		if !exists {
 			return code, nil
 		}
-		
+
 		// Collision detected, retry with a longer code
 	}
-	
+
 	// If all retries failed, generate a longer code
 	code := generateRandomCode(s.cfg.ShortCodeMinLength + 1)
 	return code, nil
 }
 
-// ValidateCustomCode validates a user-provided custom short code
-func (s *ShortenerService) ValidateCustomCode(ctx context.Context, code string) error {
+// ValidateCustomCode validates a user-provided custom short code for
+// uniqueness within customDomainID's namespace (nil for the default
+// BaseURL host).
+func (s *ShortenerService) ValidateCustomCode(ctx context.Context, code string, customDomainID *uuid.UUID) error {
 	// Check length
 	if len(code) < 4 || len(code) > 12 {
 		return errors.New("custom code must be 4-12 characters")
@@ -86,7 +90,7 @@ func (s *ShortenerService) ValidateCustomCode(ctx context.Context, code string)
 	}
 
 	// Check if already taken
-	exists, err := s.codeExists(ctx, code)
+	exists, err := s.codeExists(ctx, code, customDomainID)
 	if err != nil {
 		return fmt.Errorf("failed to check code availability: %w", err)
 	}
@@ -98,18 +102,30 @@ func (s *ShortenerService) ValidateCustomCode(ctx context.Context, code string)
 }
 
 // codeExists checks if a short code already exists in the database
-func (s *ShortenerService) codeExists(ctx context.Context, code string) (bool, error) {
-	var exists bool
-	err := s.db.Pool.QueryRow(ctx, `
-		SELECT EXISTS(
-			SELECT 1 FROM urls
-			WHERE short_code = $1 AND is_active = true
-		)
-	`, code).Scan(&exists)
-	if err != nil {
-		return false, err
+// within customDomainID's namespace (nil for the default BaseURL host).
+// The result is cached briefly behind a distributed lock: under a burst
+// of collisions on a hot code, only one caller hits the database while
+// the rest share its answer instead of all re-running the same query.
+func (s *ShortenerService) codeExists(ctx context.Context, code string, customDomainID *uuid.UUID) (bool, error) {
+	domainKey := "default"
+	if customDomainID != nil {
+		domainKey = customDomainID.String()
 	}
-	return exists, nil
+	key := "codeexists:" + domainKey + ":" + code
+	return cache.GetOrCompute(ctx, s.cache, key, s.cfg.CodeExistsCacheTTL, func() (bool, error) {
+		var exists bool
+		err := s.db.Pool.QueryRow(ctx, `
+			SELECT EXISTS(
+				SELECT 1 FROM urls
+				WHERE short_code = $1 AND is_active = true
+					AND custom_domain_id IS NOT DISTINCT FROM $2
+			)
+		`, code, customDomainID).Scan(&exists)
+		if err != nil {
+			return false, err
+		}
+		return exists, nil
+	})
 }
 
 // encodeBase62 encodes an integer to Base62 string
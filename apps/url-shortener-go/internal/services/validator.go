@@ -1,22 +1,29 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"net"
 	"net/url"
 	"strings"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/ssrf"
 )
 
 // ValidatorService handles URL validation and sanitization
-type ValidatorService struct{}
+type ValidatorService struct {
+	guard *ssrf.Guard
+}
 
-// NewValidatorService creates a new validator service
-func NewValidatorService() *ValidatorService {
-	return &ValidatorService{}
+// NewValidatorService creates a new validator service. guard resolves
+// each destination hostname and rejects it if any of its IPs fall into a
+// disallowed range - see ssrf.Guard for the exact ranges and the
+// ALLOW_PRIVATE_TARGETS escape hatch.
+func NewValidatorService(guard *ssrf.Guard) *ValidatorService {
+	return &ValidatorService{guard: guard}
 }
 
 // ValidateAndSanitizeURL validates and sanitizes a destination URL
-func (v *ValidatorService) ValidateAndSanitizeURL(rawURL string) (string, error) {
+func (v *ValidatorService) ValidateAndSanitizeURL(ctx context.Context, rawURL string) (string, error) {
 	// Parse URL
 	parsed, err := url.Parse(rawURL)
 	if err != nil {
@@ -34,7 +41,7 @@ func (v *ValidatorService) ValidateAndSanitizeURL(rawURL string) (string, error)
 	}
 
 	// Block local and private IPs (SSRF prevention)
-	if err := v.checkHostSafety(parsed.Hostname()); err != nil {
+	if err := v.checkHostSafety(ctx, parsed.Hostname()); err != nil {
 		return "", err
 	}
 
@@ -51,36 +58,21 @@ func (v *ValidatorService) ValidateAndSanitizeURL(rawURL string) (string, error)
 	return parsed.String(), nil
 }
 
-// checkHostSafety checks if a hostname is safe (not local/private)
-func (v *ValidatorService) checkHostSafety(hostname string) error {
-	// Try to parse as IP
-	ip := net.ParseIP(hostname)
-	if ip != nil {
-		// Check if IP is loopback or private
-		if ip.IsLoopback() {
-			return errors.New("loopback addresses not allowed")
-		}
-		if ip.IsPrivate() {
-			return errors.New("private IP addresses not allowed")
-		}
-		if ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
-			return errors.New("link-local addresses not allowed")
-		}
-	}
-
+// checkHostSafety checks if a hostname is safe (not local/private),
+// resolving it via guard so a domain that only resolves to an internal
+// IP (rather than naming one directly) is caught too.
+func (v *ValidatorService) checkHostSafety(ctx context.Context, hostname string) error {
 	// Check for localhost variants
 	hostname = strings.ToLower(hostname)
 	if hostname == "localhost" || strings.HasSuffix(hostname, ".local") {
 		return errors.New("localhost addresses not allowed")
 	}
 
-	// Block common internal hostnames
+	// Block common internal hostnames that wouldn't necessarily resolve
+	// in this environment (e.g. the cloud metadata hostname) but are
+	// never a legitimate shortening target.
 	blocked := []string{
 		"metadata.google.internal",
-		"169.254.169.254", // AWS/GCP metadata service
-		"127.0.0.1",
-		"0.0.0.0",
-		"::1",
 	}
 	for _, b := range blocked {
 		if hostname == b {
@@ -88,5 +80,9 @@ func (v *ValidatorService) checkHostSafety(hostname string) error {
 		}
 	}
 
+	if err := v.guard.CheckHost(ctx, hostname); err != nil {
+		return errors.New("destination host is not allowed")
+	}
+
 	return nil
 }
@@ -0,0 +1,72 @@
+// Package storage stores analytics export artifacts in an S3-compatible
+// object store, via minio-go so the same code works against AWS S3,
+// MinIO, or any other S3-API-compatible endpoint.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Client uploads export artifacts to, and generates presigned download
+// URLs for, a single configured bucket.
+type Client struct {
+	mc     *minio.Client
+	bucket string
+}
+
+// Config is the S3-compatible endpoint Client talks to.
+type Config struct {
+	Endpoint  string
+	AccessKey string
+	SecretKey string
+	Bucket    string
+	UseSSL    bool
+}
+
+// New creates a Client against cfg's endpoint. It doesn't verify
+// connectivity or that the bucket exists - Put creates the bucket lazily
+// on first upload.
+func New(cfg Config) (*Client, error) {
+	mc, err := minio.New(cfg.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure: cfg.UseSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create S3 client: %w", err)
+	}
+	return &Client{mc: mc, bucket: cfg.Bucket}, nil
+}
+
+// Put uploads r (size bytes, content type contentType) to key, creating
+// the bucket first if it doesn't already exist.
+func (c *Client) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	exists, err := c.mc.BucketExists(ctx, c.bucket)
+	if err != nil {
+		return fmt.Errorf("check bucket %s: %w", c.bucket, err)
+	}
+	if !exists {
+		if err := c.mc.MakeBucket(ctx, c.bucket, minio.MakeBucketOptions{}); err != nil {
+			return fmt.Errorf("create bucket %s: %w", c.bucket, err)
+		}
+	}
+
+	if _, err := c.mc.PutObject(ctx, c.bucket, key, r, size, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		return fmt.Errorf("put object %s: %w", key, err)
+	}
+	return nil
+}
+
+// PresignedURL returns a time-limited download URL for key.
+func (c *Client) PresignedURL(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	u, err := c.mc.PresignedGetObject(ctx, c.bucket, key, expiry, nil)
+	if err != nil {
+		return "", fmt.Errorf("presign %s: %w", key, err)
+	}
+	return u.String(), nil
+}
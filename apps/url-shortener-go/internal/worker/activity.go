@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+)
+
+// activityWindowLabel is the Prometheus label value for the trailing
+// window the tracker reports over.
+const activityWindowLabel = "1h"
+
+// activityTrailingBuckets covers a 1h trailing window at the cache
+// package's 5-minute HLL bucket span.
+const activityTrailingBuckets = 12
+
+// activityInterval is how often the tracker recomputes the gauges. It
+// doesn't need to be tighter than this since the underlying buckets only
+// change resolution every 5 minutes.
+const activityInterval = 60 * time.Second
+
+// ActivityTracker periodically recomputes the active-visitors and
+// active-short-codes gauges from the Redis HyperLogLog buckets
+// RedirectHandler writes to on every redirect.
+type ActivityTracker struct {
+	cache *cache.RedisCache
+}
+
+// NewActivityTracker creates a new activity tracker.
+func NewActivityTracker(redisCache *cache.RedisCache) *ActivityTracker {
+	return &ActivityTracker{cache: redisCache}
+}
+
+// Start runs the tracker loop until ctx is cancelled.
+func (t *ActivityTracker) Start(ctx context.Context) {
+	ticker := time.NewTicker(activityInterval)
+	defer ticker.Stop()
+
+	log.Printf("Activity tracker started - interval=%s window=%s", activityInterval, activityWindowLabel)
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Activity tracker shutting down...")
+			return
+		case <-ticker.C:
+			t.tick(ctx)
+		}
+	}
+}
+
+func (t *ActivityTracker) tick(ctx context.Context) {
+	tickCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	codeCount, err := t.cache.ActiveShortCodes(tickCtx, activityTrailingBuckets)
+	if err != nil {
+		log.Printf("Activity tracker: failed to count active short codes: %v", err)
+	} else {
+		metrics.ActiveShortCodes.WithLabelValues(activityWindowLabel).Set(float64(codeCount))
+	}
+
+	codes, err := t.cache.ActiveShortCodeNames(tickCtx, activityTrailingBuckets)
+	if err != nil {
+		log.Printf("Activity tracker: failed to list active short codes: %v", err)
+		return
+	}
+
+	for _, code := range codes {
+		visitors, err := t.cache.ActiveVisitors(tickCtx, code, activityTrailingBuckets)
+		if err != nil {
+			log.Printf("Activity tracker: failed to count active visitors for %s: %v", code, err)
+			continue
+		}
+		metrics.ActiveVisitors.WithLabelValues(code, activityWindowLabel).Set(float64(visitors))
+	}
+}
@@ -3,220 +3,242 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"os"
 	"time"
 
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/cache"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/config"
 	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/db"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/enrich"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/metrics"
+	"github.com/Jainish-S/playground/apps/url-shortener-go/internal/realtime"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 )
 
-// ClickEvent represents a click event from Redis Stream
+const (
+	streamName    = "analytics:stream"
+	consumerGroup = "analytics-flusher"
+)
+
+// ClickEvent represents a click event from Redis Stream. Country/City/
+// Latitude/Longitude are filled by enrich.Enricher.LookupGeo on the
+// redirect hot path, before the IP that produced them is hashed away -
+// see the internal/enrich package doc for why that can't happen here.
 type ClickEvent struct {
-	ShortCode string `json:"short_code"`
-	IPHash    string `json:"ip_hash"`
-	UserAgent string `json:"user_agent"`
-	Referrer  string `json:"referrer"`
-	Timestamp int64  `json:"timestamp"`
+	RequestID string  `json:"request_id"`
+	ShortCode string  `json:"short_code"`
+	IPHash    string  `json:"ip_hash"`
+	UserAgent string  `json:"user_agent"`
+	Referrer  string  `json:"referrer"`
+	Timestamp int64   `json:"timestamp"`
+	Country   string  `json:"country"`
+	City      string  `json:"city"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
 }
 
 // Flusher processes click events from Redis Stream and writes to TimescaleDB
 type Flusher struct {
-	cache    *cache.RedisCache
-	db       *db.DB
-	cfg      *config.Config
+	cache     *cache.RedisCache
+	db        *db.DB
+	cfg       *config.Config
+	ingester  *db.ClickIngester
+	hub       *realtime.Hub
+	enricher  *enrich.Enricher
+	consumer  string
 	batchSize int
-	flushInterval time.Duration
 }
 
-// NewFlusher creates a new analytics flusher
-func NewFlusher(cache *cache.RedisCache, database *db.DB, cfg *config.Config) *Flusher {
+// NewFlusher creates a new analytics flusher. Each instance gets a unique
+// consumer name within the shared consumer group, so horizontally scaling
+// the worker just means starting more instances. Clicks are handed off to
+// ingester, which batches them into TimescaleDB via CopyFrom instead of
+// one INSERT per click; call ingester.Shutdown separately during
+// graceful shutdown. hub may be nil, in which case processed clicks are
+// never published for GET /v1/urls/:id/analytics/live (see
+// cfg.LiveAnalyticsEnabled).
+func NewFlusher(cache *cache.RedisCache, database *db.DB, cfg *config.Config, ingester *db.ClickIngester, hub *realtime.Hub, enricher *enrich.Enricher) *Flusher {
 	return &Flusher{
-		cache:         cache,
-		db:            database,
-		cfg:           cfg,
-		batchSize:     100,
-		flushInterval: 5 * time.Second,
+		cache:     cache,
+		db:        database,
+		cfg:       cfg,
+		ingester:  ingester,
+		hub:       hub,
+		enricher:  enricher,
+		consumer:  fmt.Sprintf("%s-%s", hostname(), uuid.NewString()[:8]),
+		batchSize: 100,
 	}
 }
 
-// Start begins the flusher worker
+// Start begins the flusher worker. It blocks until ctx is cancelled,
+// consuming analytics:stream as part of the "analytics-flusher" consumer
+// group so a crashed instance's in-flight events are recovered and
+// reprocessed by whichever instance picks them up next. The
+// XREADGROUP/XCLAIM/DLQ consumer loop itself lives in
+// cache.RedisCache.ConsumeStream; Start only wires Flusher's batch
+// handling on top of it.
 func (f *Flusher) Start(ctx context.Context) {
-	log.Println("Analytics Flusher started - consuming from analytics:stream")
+	log.Printf("Analytics Flusher started - consumer=%s group=%s stream=%s", f.consumer, consumerGroup, streamName)
 
-	ticker := time.NewTicker(f.flushInterval)
-	defer ticker.Stop()
+	go f.reportStreamPending(ctx)
+
+	cfg := cache.StreamConsumerConfig{BatchSize: f.batchSize}
+	if err := f.cache.ConsumeStream(ctx, streamName, consumerGroup, f.consumer, cfg, f.handleBatch); err != nil {
+		log.Printf("Flusher stream consumer exited with error: %v", err)
+	}
+
+	log.Println("Flusher shutting down...")
+}
 
+// reportStreamPending periodically samples analytics:stream's pending-entries
+// count into metrics.FlusherStreamPending, until ctx is cancelled.
+func (f *Flusher) reportStreamPending(ctx context.Context) {
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Flusher shutting down...")
-			// Final flush before exit
-			f.flush(context.Background())
 			return
 		case <-ticker.C:
-			f.flush(ctx)
+			stats, err := f.DebugStats(ctx)
+			if err != nil {
+				continue
+			}
+			metrics.FlusherStreamPending.Set(float64(stats.Pending))
 		}
 	}
 }
 
-// flush processes a batch of events from the Redis Stream
-func (f *Flusher) flush(ctx context.Context) {
-	// Read events from stream
-	events, err := f.readEvents(ctx)
-	if err != nil {
-		log.Printf("Error reading events: %v", err)
-		return
-	}
-
-	if len(events) == 0 {
-		return
-	}
-
+// handleBatch parses and writes a batch of click events. It returns the
+// first error encountered so the caller leaves the whole batch pending for
+// retry (and, eventually, the dead-letter stream) rather than silently
+// dropping events on a transient DB outage.
+func (f *Flusher) handleBatch(events []cache.Event) error {
 	log.Printf("Processing %d click events...", len(events))
+	metrics.FlusherBatchSize.Observe(float64(len(events)))
 
-	// Process each event
-	for _, event := range events {
-		if err := f.processEvent(ctx, event); err != nil {
-			log.Printf("Error processing event: %v", err)
-			// Continue processing other events
-		}
-	}
-
-	log.Printf("Processed %d click events", len(events))
-}
-
-// readEvents reads events from the Redis Stream
-func (f *Flusher) readEvents(ctx context.Context) ([]ClickEvent, error) {
-	// Use XREAD to get events
-	result, err := f.cache.ReadStream(ctx, "analytics:stream", f.batchSize)
-	if err != nil {
-		if err == redis.Nil {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	events := make([]ClickEvent, 0, len(result))
-	for _, msg := range result {
-		data, ok := msg["data"].(string)
+	var firstErr error
+	processed := 0
+	for _, e := range events {
+		event, ok := parseClickEvent(e.Values)
 		if !ok {
+			log.Printf("Skipping malformed event %s", e.ID)
 			continue
 		}
 
-		var event ClickEvent
-		if err := json.Unmarshal([]byte(data), &event); err != nil {
-			log.Printf("Error parsing event: %v", err)
+		if err := f.processEvent(context.Background(), event); err != nil {
+			log.Printf("Error processing event %s request_id=%s: %v", e.ID, event.RequestID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
 			continue
 		}
-		events = append(events, event)
+		processed++
+	}
+
+	log.Printf("Processed %d/%d click events", processed, len(events))
+	return firstErr
+}
+
+// parseClickEvent decodes the JSON-encoded "data" field written by
+// RecordClickEvent into a ClickEvent.
+func parseClickEvent(values map[string]interface{}) (ClickEvent, bool) {
+	data, ok := values["data"].(string)
+	if !ok {
+		return ClickEvent{}, false
 	}
 
-	return events, nil
+	var event ClickEvent
+	if err := json.Unmarshal([]byte(data), &event); err != nil {
+		log.Printf("Error parsing event: %v", err)
+		return ClickEvent{}, false
+	}
+	return event, true
 }
 
-// processEvent processes a single click event and writes to TimescaleDB
+// processEvent processes a single click event and hands it off to the
+// batched click ingester for TimescaleDB insertion, then publishes it to
+// the realtime hub for any live dashboard subscribed to this URL.
 func (f *Flusher) processEvent(ctx context.Context, event ClickEvent) error {
-	// Get URL ID from short code
+	// Get URL ID from short code. ClickEvent doesn't carry which custom
+	// domain (if any) the click came in on, so this only resolves codes
+	// on the default BaseURL host - a click on a custom-domain short
+	// code is dropped here rather than misattributed to an unrelated
+	// default-host URL that happens to share its code. Threading the
+	// resolved domain through RecordClickEvent is left as a follow-up.
 	url, err := f.db.GetURLByShortCode(ctx, event.ShortCode)
 	if err != nil {
 		return err
 	}
 
-	// Parse device info from user agent (simplified)
-	deviceType := parseDeviceType(event.UserAgent)
-	browser := parseBrowser(event.UserAgent)
-	os := parseOS(event.UserAgent)
+	ua := f.enricher.ParseUA(event.UserAgent)
 
 	// Create click time from timestamp
 	clickTime := time.Unix(event.Timestamp, 0)
+	metrics.FlusherIngestLag.Observe(time.Since(clickTime).Seconds())
 
-	// Insert into clicks table
-	return f.db.InsertClick(ctx, db.Click{
+	click := db.Click{
 		Time:       clickTime,
 		URLID:      url.ID,
 		IPHash:     event.IPHash,
 		UserAgent:  event.UserAgent,
 		Referrer:   event.Referrer,
-		DeviceType: deviceType,
-		Browser:    browser,
-		OS:         os,
-	})
-}
-
-// parseDeviceType extracts device type from user agent
-func parseDeviceType(userAgent string) string {
-	ua := userAgent
-	if len(ua) == 0 {
-		return "unknown"
+		Country:    event.Country,
+		City:       event.City,
+		Latitude:   event.Latitude,
+		Longitude:  event.Longitude,
+		DeviceType: ua.DeviceType,
+		Browser:    ua.Browser,
+		OS:         ua.OS,
 	}
 
-	// Simple detection
-	if contains(ua, "Mobile") || contains(ua, "Android") || contains(ua, "iPhone") {
-		return "mobile"
-	}
-	if contains(ua, "Tablet") || contains(ua, "iPad") {
-		return "tablet"
-	}
-	if contains(ua, "bot") || contains(ua, "Bot") || contains(ua, "crawler") {
-		return "bot"
+	// Enqueue for the next CopyFrom batch rather than inserting one row at
+	// a time. A full ingester buffer is a transient failure like any
+	// other - returning it here leaves the stream entry pending for retry.
+	if err := f.ingester.Enqueue(ctx, click); err != nil {
+		metrics.FlusherDBErrors.Inc()
+		return err
 	}
-	return "desktop"
-}
 
-// parseBrowser extracts browser from user agent
-func parseBrowser(userAgent string) string {
-	ua := userAgent
-	if contains(ua, "Chrome") && !contains(ua, "Chromium") {
-		return "Chrome"
-	}
-	if contains(ua, "Firefox") {
-		return "Firefox"
-	}
-	if contains(ua, "Safari") && !contains(ua, "Chrome") {
-		return "Safari"
-	}
-	if contains(ua, "Edge") {
-		return "Edge"
-	}
-	return "Other"
+	f.publishLive(ctx, click)
+	return nil
 }
 
-// parseOS extracts OS from user agent
-func parseOS(userAgent string) string {
-	ua := userAgent
-	if contains(ua, "Windows") {
-		return "Windows"
-	}
-	if contains(ua, "Mac OS") {
-		return "macOS"
-	}
-	if contains(ua, "Linux") {
-		return "Linux"
+// publishLive fans click out to the realtime hub for GET
+// /v1/urls/:id/analytics/live. It's best-effort: the click has already
+// been durably enqueued above, so a publish failure only means a live
+// dashboard misses one update, not that the click is lost.
+func (f *Flusher) publishLive(ctx context.Context, click db.Click) {
+	if f.hub == nil || !f.cfg.LiveAnalyticsEnabled {
+		return
 	}
-	if contains(ua, "Android") {
-		return "Android"
+
+	data, err := json.Marshal(click)
+	if err != nil {
+		log.Printf("realtime: failed to marshal click for live publish: %v", err)
+		return
 	}
-	if contains(ua, "iOS") || contains(ua, "iPhone") || contains(ua, "iPad") {
-		return "iOS"
+	if err := f.hub.Publish(ctx, click.URLID, data); err != nil {
+		log.Printf("realtime: failed to publish live click: %v", err)
 	}
-	return "Other"
 }
 
-// contains checks if string contains substring
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsImpl(s, substr))
+// DebugStats reports analytics:stream's current pending-entries count and
+// analytics:stream:dlq's length, for GET /debug/flusher.
+func (f *Flusher) DebugStats(ctx context.Context) (cache.StreamStats, error) {
+	return f.cache.StreamDebugStats(ctx, streamName, consumerGroup)
 }
 
-func containsImpl(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+// hostname returns the local hostname, falling back to "flusher" if it
+// can't be determined, for use in consumer names.
+func hostname() string {
+	name, err := os.Hostname()
+	if err != nil || name == "" {
+		return "flusher"
 	}
-	return false
+	return name
 }
 
 // InsertClick adds to db package the ability to insert clicks
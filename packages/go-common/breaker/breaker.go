@@ -0,0 +1,274 @@
+// Package breaker provides a generic, metrics-backed circuit breaker for
+// wrapping calls to downstream model services, independent of any one
+// service's own retry/orchestration logic.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrBreakerOpen is returned by Call when the breaker for a model is
+// OPEN, or its single HALF_OPEN probe slot is already taken, so callers
+// can fail fast instead of waiting on a downstream socket that's already
+// known to be unhealthy.
+var ErrBreakerOpen = errors.New("breaker: circuit open")
+
+// State mirrors the values gocommon.Metrics' CircuitBreakerState gauge
+// expects: CLOSED=0, OPEN=1, HALF_OPEN=2.
+type State int
+
+const (
+	StateClosed   State = 0
+	StateOpen     State = 1
+	StateHalfOpen State = 2
+)
+
+// Config configures a Breaker. Zero values fall back to sane defaults in
+// New.
+type Config struct {
+	// WindowSize is how many of the most recent call outcomes are
+	// considered when computing the failure ratio.
+	WindowSize int
+	// FailureRatio is the fraction of failures in the window (once the
+	// window has seen WindowSize calls) that trips the breaker open.
+	FailureRatio float64
+	// CooldownTimeout is how long the breaker stays OPEN before allowing
+	// a single HALF_OPEN probe request through.
+	CooldownTimeout time.Duration
+	// MaxAttempts is the max number of attempts Call makes per
+	// invocation; 1 disables retries.
+	MaxAttempts int
+	// BaseDelay is the base for the jittered exponential backoff between
+	// retries: retry N waits BaseDelay*2^(N-2) plus up to that much
+	// again in jitter.
+	BaseDelay time.Duration
+}
+
+func (cfg Config) withDefaults() Config {
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = 20
+	}
+	if cfg.FailureRatio <= 0 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.CooldownTimeout <= 0 {
+		cfg.CooldownTimeout = 30 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+	if cfg.BaseDelay <= 0 {
+		cfg.BaseDelay = 100 * time.Millisecond
+	}
+	return cfg
+}
+
+// modelState is the sliding-window failure count and current breaker
+// state for a single model.
+type modelState struct {
+	mu       sync.Mutex
+	outcomes []bool
+	next     int
+	filled   bool
+
+	state           State
+	openedAt        time.Time
+	halfOpenProbing bool
+}
+
+func (ms *modelState) record(success bool) {
+	ms.outcomes[ms.next] = success
+	ms.next = (ms.next + 1) % len(ms.outcomes)
+	if ms.next == 0 {
+		ms.filled = true
+	}
+}
+
+func (ms *modelState) failureRatio() (ratio float64, count int) {
+	n := len(ms.outcomes)
+	if !ms.filled {
+		n = ms.next
+	}
+	if n == 0 {
+		return 0, 0
+	}
+
+	failures := 0
+	for i := 0; i < n; i++ {
+		if !ms.outcomes[i] {
+			failures++
+		}
+	}
+	return float64(failures) / float64(n), n
+}
+
+func (ms *modelState) reset() {
+	ms.next = 0
+	ms.filled = false
+}
+
+// Breaker wraps downstream model calls with a per-model circuit breaker
+// and jittered-backoff retries, reporting state transitions and retries
+// to the given Prometheus vectors (either may be nil).
+type Breaker struct {
+	cfg         Config
+	stateGauge  *prometheus.GaugeVec
+	retries     *prometheus.CounterVec
+
+	mu     sync.Mutex
+	models map[string]*modelState
+}
+
+// New creates a Breaker. stateGauge is expected to be
+// gocommon.Metrics.CircuitBreakerState and retries
+// gocommon.Metrics.ModelCallRetries, but either may be nil (metrics are
+// simply skipped).
+func New(cfg Config, stateGauge *prometheus.GaugeVec, retries *prometheus.CounterVec) *Breaker {
+	return &Breaker{
+		cfg:        cfg.withDefaults(),
+		stateGauge: stateGauge,
+		retries:    retries,
+		models:     make(map[string]*modelState),
+	}
+}
+
+func (b *Breaker) modelFor(modelName string) *modelState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ms, ok := b.models[modelName]
+	if ok {
+		return ms
+	}
+
+	ms = &modelState{outcomes: make([]bool, b.cfg.WindowSize)}
+	b.models[modelName] = ms
+	if b.stateGauge != nil {
+		b.stateGauge.WithLabelValues(modelName).Set(float64(StateClosed))
+	}
+	return ms
+}
+
+// Call invokes fn up to cfg.MaxAttempts times in total, with jittered
+// exponential backoff between attempts. It short-circuits with
+// ErrBreakerOpen without calling fn at all if the breaker for modelName
+// is OPEN, or already has a HALF_OPEN probe in flight.
+func (b *Breaker) Call(ctx context.Context, modelName string, fn func() error) error {
+	ms := b.modelFor(modelName)
+
+	if !b.allow(modelName, ms) {
+		return ErrBreakerOpen
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= b.cfg.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			if b.retries != nil {
+				b.retries.WithLabelValues(modelName, strconv.Itoa(attempt)).Inc()
+			}
+			if err := sleepWithJitter(ctx, b.cfg.BaseDelay, attempt); err != nil {
+				lastErr = err
+				break
+			}
+		}
+
+		lastErr = fn()
+		if lastErr == nil {
+			b.recordSuccess(modelName, ms)
+			return nil
+		}
+	}
+
+	b.recordFailure(modelName, ms)
+	return lastErr
+}
+
+// allow reports whether a call should proceed, claiming the sole
+// HALF_OPEN probe slot if it's the one granted.
+func (b *Breaker) allow(modelName string, ms *modelState) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	switch ms.state {
+	case StateClosed:
+		return true
+	case StateOpen:
+		if time.Since(ms.openedAt) < b.cfg.CooldownTimeout {
+			return false
+		}
+		b.transitionTo(modelName, ms, StateHalfOpen)
+		ms.halfOpenProbing = true
+		return true
+	case StateHalfOpen:
+		if ms.halfOpenProbing {
+			return false
+		}
+		ms.halfOpenProbing = true
+		return true
+	default:
+		return false
+	}
+}
+
+func (b *Breaker) recordSuccess(modelName string, ms *modelState) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.record(true)
+
+	if ms.state == StateHalfOpen {
+		ms.halfOpenProbing = false
+		b.transitionTo(modelName, ms, StateClosed)
+	}
+}
+
+func (b *Breaker) recordFailure(modelName string, ms *modelState) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	ms.record(false)
+
+	switch ms.state {
+	case StateHalfOpen:
+		ms.halfOpenProbing = false
+		ms.openedAt = time.Now()
+		b.transitionTo(modelName, ms, StateOpen)
+	case StateClosed:
+		if rate, n := ms.failureRatio(); n >= b.cfg.WindowSize && rate > b.cfg.FailureRatio {
+			ms.openedAt = time.Now()
+			b.transitionTo(modelName, ms, StateOpen)
+		}
+	}
+}
+
+// transitionTo moves ms to newState. Must be called with ms.mu held.
+func (b *Breaker) transitionTo(modelName string, ms *modelState, newState State) {
+	ms.state = newState
+	if newState == StateClosed {
+		ms.reset()
+	}
+	if b.stateGauge != nil {
+		b.stateGauge.WithLabelValues(modelName).Set(float64(newState))
+	}
+}
+
+// sleepWithJitter waits BaseDelay*2^(attempt-2) plus up to that much
+// again in jitter, or returns ctx.Err() if ctx is cancelled first.
+func sleepWithJitter(ctx context.Context, base time.Duration, attempt int) error {
+	backoff := base * time.Duration(int64(1)<<uint(attempt-2))
+	wait := backoff + time.Duration(rand.Int63n(int64(backoff)+1))
+
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,88 @@
+// Package lifecycle coordinates graceful shutdown across a service's HTTP
+// server and its background resources (client pools, breakers, caches),
+// so readiness checks and resource teardown agree on a single source of
+// truth instead of each service hand-rolling its own shuttingDown flag.
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultPreStopDelay is how long a caller waits, after MarkDraining
+// flips readiness to "draining", before it shuts the HTTP server down;
+// Coordinator itself does not wait or read this value. It gives an upstream load balancer time to stop
+// routing new requests here based on the now-failing readiness check.
+const DefaultPreStopDelay = 2 * time.Second
+
+// closer is a named, timeout-bound cleanup registered with RegisterCloser.
+type closer struct {
+	name    string
+	timeout time.Duration
+	fn      func(context.Context) error
+}
+
+// Coordinator tracks whether a service is draining and runs registered
+// cleanup functions on shutdown. The zero value is not usable; construct
+// one with NewCoordinator.
+type Coordinator struct {
+	draining atomic.Bool
+
+	mu      sync.Mutex
+	closers []closer
+}
+
+// NewCoordinator creates a Coordinator in the not-draining state.
+func NewCoordinator() *Coordinator {
+	return &Coordinator{}
+}
+
+// MarkDraining flips the coordinator into the draining state. Readiness
+// handlers should check IsDraining and start failing immediately so
+// upstream load balancers stop sending new requests here.
+func (c *Coordinator) MarkDraining() {
+	c.draining.Store(true)
+}
+
+// IsDraining reports whether MarkDraining has been called.
+func (c *Coordinator) IsDraining() bool {
+	return c.draining.Load()
+}
+
+// RegisterCloser registers fn to run during Wait, under the given
+// per-closer timeout. Closers run in reverse registration order, mirroring
+// the usual defer convention: resources acquired last (e.g. a cache sitting
+// on top of a client pool) are torn down first.
+func (c *Coordinator) RegisterCloser(name string, timeout time.Duration, fn func(context.Context) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closers = append(c.closers, closer{name: name, timeout: timeout, fn: fn})
+}
+
+// Wait runs every registered closer in reverse registration order, each
+// bounded by its own timeout (further bounded by ctx if ctx is also
+// carrying a deadline). A closer that errors or times out is logged by the
+// caller via the returned error but does not stop the remaining closers
+// from running, since a slow cache flush shouldn't prevent the client pool
+// beneath it from also closing its idle connections.
+func (c *Coordinator) Wait(ctx context.Context) []error {
+	c.mu.Lock()
+	closers := make([]closer, len(c.closers))
+	copy(closers, c.closers)
+	c.mu.Unlock()
+
+	var errs []error
+	for i := len(closers) - 1; i >= 0; i-- {
+		cl := closers[i]
+		closeCtx, cancel := context.WithTimeout(ctx, cl.timeout)
+		err := cl.fn(closeCtx)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", cl.name, err))
+		}
+	}
+	return errs
+}
@@ -55,6 +55,26 @@ type Metrics struct {
 	// CircuitBreakerState tracks circuit breaker states
 	CircuitBreakerState *prometheus.GaugeVec
 
+	// CircuitBreakerTransitions tracks every circuit breaker state
+	// transition as a discrete event, complementing the point-in-time
+	// CircuitBreakerState gauge with a count of how often (and between
+	// which states) each breaker has flipped.
+	CircuitBreakerTransitions *prometheus.CounterVec
+
+	// AdaptiveConcurrencyLimit tracks the current per-model concurrency
+	// limit maintained by the orchestrator's adaptive limiter.
+	AdaptiveConcurrencyLimit *prometheus.GaugeVec
+
+	// HedgeWinRate tracks the fraction of fired hedged requests whose
+	// delayed (second) attempt won the race against the primary.
+	HedgeWinRate *prometheus.GaugeVec
+
+	// CacheHits tracks result cache hits per model.
+	CacheHits *prometheus.CounterVec
+
+	// CacheMisses tracks result cache misses per model.
+	CacheMisses *prometheus.CounterVec
+
 	// ServiceName is the name of this service
 	ServiceName string
 }
@@ -77,14 +97,14 @@ func NewModelMetrics(serviceName string) *Metrics {
 				Help:    "Model inference latency in seconds (ML execution only)",
 				Buckets: InferenceLatencyBuckets,
 			},
-			[]string{"model_name"},
+			[]string{"model_name", "backend", "model_version"},
 		),
 		InferenceTotal: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Name: "model_inference_total",
 				Help: "Total model inferences",
 			},
-			[]string{"model_name", "status"},
+			[]string{"model_name", "backend", "model_version", "status"},
 		),
 		InFlightRequests: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -106,10 +126,9 @@ func NewModelMetrics(serviceName string) *Metrics {
 	// Get hostname (pod name)
 	hostname, _ := os.Hostname()
 
-	// Pre-initialize labels
-	m.InferenceLatency.WithLabelValues(serviceName)
-	m.InferenceTotal.WithLabelValues(serviceName, "success")
-	m.InferenceTotal.WithLabelValues(serviceName, "error")
+	// Note: InferenceLatency/InferenceTotal are no longer pre-initialized here
+	// since their "backend"/"model_version" labels depend on which classifier
+	// backend a given model service loads at startup.
 	// Initialize to 0 so it's exposed immediately (crucial for HPA)
 	m.InFlightRequests.WithLabelValues(serviceName, hostname).Set(0)
 
@@ -165,6 +184,41 @@ func NewGuardrailMetrics(serviceName string) *Metrics {
 			},
 			[]string{"model_name"},
 		),
+		CircuitBreakerTransitions: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guardrail_circuit_breaker_transitions_total",
+				Help: "Total circuit breaker state transitions",
+			},
+			[]string{"model_name", "from_state", "to_state"},
+		),
+		AdaptiveConcurrencyLimit: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "guardrail_adaptive_concurrency_limit",
+				Help: "Current adaptive concurrency limit for downstream model calls",
+			},
+			[]string{"model_name"},
+		),
+		HedgeWinRate: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "guardrail_hedge_win_rate",
+				Help: "Fraction of fired hedged requests whose delayed attempt won the race",
+			},
+			[]string{"model_name"},
+		),
+		CacheHits: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guardrail_result_cache_hits_total",
+				Help: "Total result cache hits per model",
+			},
+			[]string{"model_name"},
+		),
+		CacheMisses: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "guardrail_result_cache_misses_total",
+				Help: "Total result cache misses per model",
+			},
+			[]string{"model_name"},
+		),
 	}
 
 	// Register metrics
@@ -175,6 +229,11 @@ func NewGuardrailMetrics(serviceName string) *Metrics {
 		m.ModelCallLatency,
 		m.ModelCallRetries,
 		m.CircuitBreakerState,
+		m.CircuitBreakerTransitions,
+		m.AdaptiveConcurrencyLimit,
+		m.HedgeWinRate,
+		m.CacheHits,
+		m.CacheMisses,
 	)
 
 	// Get hostname (pod name)
@@ -187,6 +246,10 @@ func NewGuardrailMetrics(serviceName string) *Metrics {
 	for _, name := range modelNames {
 		m.ModelCallLatency.WithLabelValues(name)
 		m.CircuitBreakerState.WithLabelValues(name).Set(0) // CLOSED
+		m.AdaptiveConcurrencyLimit.WithLabelValues(name)
+		m.HedgeWinRate.WithLabelValues(name)
+		m.CacheHits.WithLabelValues(name)
+		m.CacheMisses.WithLabelValues(name)
 	}
 
 	return m
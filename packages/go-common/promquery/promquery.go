@@ -0,0 +1,75 @@
+// Package promquery wraps the Prometheus HTTP API client so a service can
+// query its own exported metrics at runtime - for example to drive an
+// adaptive rate limiter off a live P95 latency, or to surface firing
+// alerts on an admin endpoint without leaving the app.
+package promquery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/api"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+)
+
+// Client queries a Prometheus server's HTTP API.
+type Client struct {
+	api v1.API
+}
+
+// New creates a Client against the Prometheus server at addr, e.g.
+// "http://localhost:9090".
+func New(addr string) (*Client, error) {
+	c, err := api.NewClient(api.Config{Address: addr})
+	if err != nil {
+		return nil, fmt.Errorf("promquery: create client: %w", err)
+	}
+	return &Client{api: v1.NewAPI(c)}, nil
+}
+
+// Instant runs an instant query and returns its result value.
+func (c *Client) Instant(ctx context.Context, query string) (model.Value, error) {
+	value, _, err := c.api.Query(ctx, query, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("promquery: instant query %q: %w", query, err)
+	}
+	return value, nil
+}
+
+// Range runs a range query over r and returns its result value.
+func (c *Client) Range(ctx context.Context, query string, r v1.Range) (model.Value, error) {
+	value, _, err := c.api.QueryRange(ctx, query, r)
+	if err != nil {
+		return nil, fmt.Errorf("promquery: range query %q: %w", query, err)
+	}
+	return value, nil
+}
+
+// Alerts returns every alert currently known to the Prometheus server,
+// firing or pending.
+func (c *Client) Alerts(ctx context.Context) ([]v1.Alert, error) {
+	result, err := c.api.Alerts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("promquery: alerts: %w", err)
+	}
+	return result.Alerts, nil
+}
+
+// Scalar extracts a single float64 out of an instant query result, e.g.
+// the output of a rate(...) or histogram_quantile(...) expression. It
+// returns false if value isn't a scalar-shaped vector/scalar result.
+func Scalar(value model.Value) (float64, bool) {
+	switch v := value.(type) {
+	case model.Vector:
+		if len(v) == 0 {
+			return 0, false
+		}
+		return float64(v[0].Value), true
+	case *model.Scalar:
+		return float64(v.Value), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,42 @@
+package gocommon
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader is the header request IDs are read from and echoed back on.
+const RequestIDHeader = "X-Request-ID"
+
+// contextKey is unexported so other packages can't collide with it by
+// stuffing their own "requestID" string key into the same context.
+type contextKey int
+
+const requestIDKey contextKey = 0
+
+// RequestIDMiddleware reads X-Request-ID from the incoming request,
+// generating a UUIDv4 when absent, injects it into the request's context,
+// and echoes it back on the response so clients and downstream services
+// can correlate logs and metrics for the same request.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequestIDFromContext extracts the request ID injected by
+// RequestIDMiddleware, returning "" if none is present (e.g. in a context
+// that never passed through the middleware).
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
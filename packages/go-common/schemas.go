@@ -13,16 +13,32 @@ type ModelPredictRequest struct {
 // ModelPredictResponse is the response schema from model prediction endpoints.
 // Matches the Python ModelPredictResponse in py-common.
 type ModelPredictResponse struct {
+	// Request ID, round-tripped from ModelPredictRequest
+	RequestID string `json:"request_id,omitempty"`
 	// Whether the text was flagged
 	Flagged bool `json:"flagged"`
 	// Confidence score (0.0 to 1.0)
 	Score float64 `json:"score"`
 	// Explanation details
 	Details []string `json:"details"`
+	// Structured entities found in the text (e.g. PII spans), for
+	// detectors that support them. Empty for detectors that only
+	// produce flat Details.
+	Entities []Entity `json:"entities,omitempty"`
 	// Inference latency in milliseconds
 	LatencyMs int `json:"latency_ms"`
 }
 
+// Entity is a structured span of a detected item within the input text
+// (e.g. a PII match), giving its type, matched text, and character
+// offsets so callers can redact or highlight it precisely.
+type Entity struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
 // ValidateRequest is the request schema for the main validation endpoint.
 // Matches the Python ValidateRequest in py-common.
 type ValidateRequest struct {
@@ -36,6 +52,10 @@ type ValidateRequest struct {
 	Type string `json:"type"`
 	// Optional metadata
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	// Optional aggregation strategy name (e.g. "any_flag",
+	// "weighted_threshold", "log_odds", "veto"). Defaults to the
+	// orchestrator's configured default strategy when omitted.
+	Strategy string `json:"strategy,omitempty"`
 }
 
 // ModelResultResponse is the result from a single model.
@@ -44,6 +64,7 @@ type ModelResultResponse struct {
 	Flagged   bool     `json:"flagged"`
 	Score     float64  `json:"score"`
 	Details   []string `json:"details"`
+	Entities  []Entity `json:"entities,omitempty"`
 	LatencyMs int      `json:"latency_ms"`
 }
 
@@ -54,6 +75,9 @@ type ValidateResponse struct {
 	RequestID string `json:"request_id"`
 	// Overall flag status
 	Flagged bool `json:"flagged"`
+	// Aggregated numeric score produced by the selected aggregation
+	// strategy, so callers can tune their own thresholds downstream.
+	Score float64 `json:"score"`
 	// Reasons for flagging
 	FlagReasons []string `json:"flag_reasons"`
 	// Per-model results
@@ -54,10 +54,17 @@ type Client struct {
 	tenants    []Tenant
 	tenantIdx  atomic.Uint64
 	reqCounter atomic.Uint64
+
+	// scenarios, if non-nil, is weight-sampled for request text/type on
+	// every call instead of the hardcoded textSamples fallback, so a run
+	// can measure detection quality against a known corpus rather than
+	// just latency.
+	scenarios *ScenarioSet
 }
 
-// NewClient creates a new guardrail API client
-func NewClient(baseURL string, numTenants int) *Client {
+// NewClient creates a new guardrail API client. scenarios may be nil, in
+// which case requests fall back to generateRandomText.
+func NewClient(baseURL string, numTenants int, scenarios *ScenarioSet) *Client {
 	// Create HTTP client with connection pooling
 	transport := &http.Transport{
 		MaxIdleConns:        200,
@@ -71,8 +78,9 @@ func NewClient(baseURL string, numTenants int) *Client {
 			Transport: transport,
 			Timeout:   5 * time.Second,
 		},
-		baseURL: baseURL,
-		tenants: make([]Tenant, numTenants),
+		baseURL:   baseURL,
+		tenants:   make([]Tenant, numTenants),
+		scenarios: scenarios,
 	}
 
 	// Generate simulated tenants
@@ -87,15 +95,34 @@ func NewClient(baseURL string, numTenants int) *Client {
 	return client
 }
 
-// RequestResult holds the result of a single request
+// RequestResult holds the result of a single request. IntendedStart,
+// ActualStart and End are stamped by Runner.worker rather than SendRequest,
+// since only the scheduler knows when a request was meant to go out; they
+// are the zero Time when a caller records a result outside open-loop
+// scheduling.
 type RequestResult struct {
-	TenantID  string
-	Latency   time.Duration
-	Success   bool
-	Timeout   bool
-	Error     error
-	Flagged   bool
+	TenantID   string
+	Latency    time.Duration
+	Success    bool
+	Timeout    bool
+	Error      error
+	Flagged    bool
 	StatusCode int
+
+	// IntendedStart is when the target-RPS schedule called for this
+	// request to begin. ActualStart is when a worker actually picked it
+	// up, which lags IntendedStart once workers fall behind schedule.
+	IntendedStart time.Time
+	ActualStart   time.Time
+	End           time.Time
+
+	// Scenario, HasExpected and ExpectedFlagged are set when the request
+	// was sampled from a ScenarioSet rather than generateRandomText, so
+	// Metrics can score the response against the corpus's known answer in
+	// addition to tracking per-scenario latency.
+	Scenario        string
+	HasExpected     bool
+	ExpectedFlagged bool
 }
 
 // SendRequest sends a validation request to the guardrail API
@@ -107,31 +134,52 @@ func (c *Client) SendRequest(ctx context.Context) RequestResult {
 	// Generate request ID
 	reqID := fmt.Sprintf("load-%d-%d", time.Now().UnixNano(), c.reqCounter.Add(1))
 
+	// Pick request text: weight-sampled from the scenario corpus if one
+	// was configured, otherwise the hardcoded fallback samples.
+	text := generateRandomText()
+	reqType := "input"
+	var scenarioName string
+	var expectedFlagged bool
+	var hasExpected bool
+	var metadata map[string]string
+
+	if c.scenarios != nil {
+		sc, rec := c.scenarios.Pick()
+		text = rec.Text
+		reqType = sc.Type
+		scenarioName = sc.Name
+		expectedFlagged = rec.ExpectedFlagged
+		hasExpected = true
+		metadata = sc.Metadata
+	}
+
+	result := RequestResult{
+		TenantID:        tenant.ID,
+		Scenario:        scenarioName,
+		HasExpected:     hasExpected,
+		ExpectedFlagged: expectedFlagged,
+	}
+
 	// Create request body
 	reqBody := ValidateRequest{
 		RequestID: reqID,
 		ProjectID: tenant.ProjectID,
-		Text:      generateRandomText(),
-		Type:      "input",
+		Text:      text,
+		Type:      reqType,
+		Metadata:  metadata,
 	}
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return RequestResult{
-			TenantID: tenant.ID,
-			Success:  false,
-			Error:    err,
-		}
+		result.Error = err
+		return result
 	}
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/validate", bytes.NewReader(body))
 	if err != nil {
-		return RequestResult{
-			TenantID: tenant.ID,
-			Success:  false,
-			Error:    err,
-		}
+		result.Error = err
+		return result
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -140,12 +188,7 @@ func (c *Client) SendRequest(ctx context.Context) RequestResult {
 	// Send request and measure latency
 	start := time.Now()
 	resp, err := c.httpClient.Do(req)
-	latency := time.Since(start)
-
-	result := RequestResult{
-		TenantID: tenant.ID,
-		Latency:  latency,
-	}
+	result.Latency = time.Since(start)
 
 	if err != nil {
 		// Check if it's a timeout
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	gocommon "github.com/playground/packages/go-common"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ServeMetrics starts a net/http server on addr exposing /metrics in
+// Prometheus text format (reusing gocommon.MetricsHandler, the same
+// promhttp wiring every other service in this repo uses), so an operator
+// can point Grafana at the running harness and correlate client-observed
+// latency against the guardrail-server-go's own /metrics during a run
+// instead of waiting for the final JSON. The server is closed when ctx is
+// done.
+func (m *Metrics) ServeMetrics(ctx context.Context, addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", gocommon.MetricsHandler())
+	server := &http.Server{Addr: addr, Handler: mux}
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("⚠️  metrics server error: %v\n", err)
+		}
+	}()
+}
+
+// latencyCollector publishes a loadtest_latency_seconds histogram rebuilt
+// from m's global service-time HDR histogram on every scrape, so a
+// concurrent scrape never observes a torn snapshot.
+type latencyCollector struct {
+	m    *Metrics
+	desc *prometheus.Desc
+}
+
+func newLatencyCollector(m *Metrics) *latencyCollector {
+	return &latencyCollector{
+		m: m,
+		desc: prometheus.NewDesc(
+			"loadtest_latency_seconds",
+			"Live service-time distribution of load test requests, snapshotted from the run's HDR histogram",
+			nil, nil,
+		),
+	}
+}
+
+func (c *latencyCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *latencyCollector) Collect(ch chan<- prometheus.Metric) {
+	c.m.mu.Lock()
+	bars := c.m.histogram.Distribution()
+	count := uint64(c.m.histogram.TotalCount())
+	mean := c.m.histogram.Mean()
+	c.m.mu.Unlock()
+
+	buckets := make(map[float64]uint64, len(bars))
+	var cumulative uint64
+	for _, bar := range bars {
+		if bar.Count <= 0 {
+			continue
+		}
+		cumulative += uint64(bar.Count)
+		buckets[float64(bar.To)/1_000_000.0] = cumulative
+	}
+
+	// mean is in microseconds; hdrhistogram.Histogram doesn't expose a
+	// running sum directly, so reconstruct it from the mean and count.
+	sumSeconds := (mean * float64(count)) / 1_000_000.0
+
+	metric, err := prometheus.NewConstHistogram(c.desc, count, sumSeconds, buckets)
+	if err != nil {
+		return
+	}
+	ch <- metric
+}
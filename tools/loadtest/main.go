@@ -22,6 +22,29 @@ type Config struct {
 	Workers  int           // Number of concurrent workers
 	Tenants  int           // Number of simulated tenants
 	Output   string        // Output format (json/text)
+
+	// SLOFile, if set, is a YAML or JSON file of SLO thresholds loaded
+	// into SLO; a failing run then exits non-zero so the tool can gate CI.
+	SLOFile string
+	SLO     *SLO
+
+	// ReportJSONPath and ReportJUnitPath, if set, write the full Results
+	// (respectively a JUnit testsuite derived from Results.Verdict)
+	// alongside the normal human-readable output.
+	ReportJSONPath  string
+	ReportJUnitPath string
+
+	// MetricsAddr, if set, serves a live /metrics Prometheus endpoint for
+	// the duration of the run so an operator can scrape it without
+	// waiting for the final results.
+	MetricsAddr string
+
+	// ScenarioFile, if set, is a YAML or JSON file of weighted Scenario
+	// entries; the Client weight-samples one per request instead of
+	// generateRandomText, so the run measures detection quality against a
+	// known corpus alongside latency.
+	ScenarioFile string
+	Scenarios    *ScenarioSet
 }
 
 func main() {
@@ -51,8 +74,26 @@ func main() {
 		cancel()
 	}()
 
+	if cfg.SLOFile != "" {
+		slo, err := LoadSLO(cfg.SLOFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading SLO file: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.SLO = slo
+	}
+
+	if cfg.ScenarioFile != "" {
+		scenarios, err := LoadScenarios(cfg.ScenarioFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading scenario file: %v\n", err)
+			os.Exit(1)
+		}
+		cfg.Scenarios = scenarios
+	}
+
 	// Create client and runner
-	client := NewClient(cfg.Target, cfg.Tenants)
+	client := NewClient(cfg.Target, cfg.Tenants, cfg.Scenarios)
 	runner := NewRunner(cfg, client)
 
 	// Run the load test
@@ -64,6 +105,25 @@ func main() {
 	} else {
 		printTextResults(results)
 	}
+
+	if cfg.ReportJSONPath != "" {
+		if err := WriteJSONReport(cfg.ReportJSONPath, results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if cfg.ReportJUnitPath != "" && results.Verdict != nil {
+		if err := WriteJUnitReport(cfg.ReportJUnitPath, results.Verdict); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JUnit report: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Exit non-zero on SLO failure so the tool is usable directly as a CI
+	// gate.
+	if results.Verdict != nil && !results.Verdict.Passed {
+		os.Exit(1)
+	}
 }
 
 func parseFlags() Config {
@@ -75,6 +135,11 @@ func parseFlags() Config {
 	flag.IntVar(&cfg.Workers, "workers", 10, "Number of concurrent workers")
 	flag.IntVar(&cfg.Tenants, "tenants", 5, "Number of simulated tenants")
 	flag.StringVar(&cfg.Output, "output", "text", "Output format (json/text)")
+	flag.StringVar(&cfg.SLOFile, "slo-file", "", "YAML/JSON file of SLO thresholds; a failing run exits non-zero")
+	flag.StringVar(&cfg.ReportJSONPath, "report-json", "", "Write the full Results as JSON to this path")
+	flag.StringVar(&cfg.ReportJUnitPath, "report-junit", "", "Write the SLO verdict as a JUnit XML testsuite to this path")
+	flag.StringVar(&cfg.MetricsAddr, "metrics-addr", "", "Address to serve a live /metrics Prometheus endpoint on (e.g. :9090); empty disables it")
+	flag.StringVar(&cfg.ScenarioFile, "scenario-file", "", "YAML/JSON file of weighted scenarios to sample request text from; empty uses the built-in random samples")
 
 	flag.Parse()
 
@@ -107,8 +172,15 @@ func printTextResults(results *Results) {
 	fmt.Printf("║    P90           : %-42.1f ║\n", results.LatencyP90)
 	fmt.Printf("║    P95           : %-42.1f ║\n", results.LatencyP95)
 	fmt.Printf("║    P99           : %-42.1f ║\n", results.LatencyP99)
+	fmt.Printf("║    P99.9         : %-42.1f ║\n", results.LatencyP999)
+	fmt.Printf("║    P99.99        : %-42.1f ║\n", results.LatencyP9999)
 	fmt.Printf("║    Max           : %-42.1f ║\n", results.LatencyMax)
 	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
+	fmt.Println("║  COORDINATED-OMISSION CORRECTION (ms)                         ║")
+	fmt.Printf("║    P99 Uncorrected : %-40.1f ║\n", results.LatencyP99Uncorrected)
+	fmt.Printf("║    P99 Corrected   : %-40.1f ║\n", results.LatencyP99Corrected)
+	fmt.Printf("║    Schedule Lag P99: %-40.1f ║\n", results.ScheduleLagMs)
+	fmt.Println("╠═══════════════════════════════════════════════════════════════╣")
 	fmt.Println("║  SUCCESS/ERROR                                                ║")
 	fmt.Printf("║    Success       : %-7s (%.1f%%)                             ║\n",
 		formatNumber(results.SuccessCount),
@@ -138,6 +210,47 @@ func printTextResults(results *Results) {
 		}
 		fmt.Println("└────────────┴───────────┴──────────┴──────────┴──────────┘")
 	}
+
+	if results.Confusion != nil {
+		c := results.Confusion
+		fmt.Println()
+		fmt.Println("Detection Quality (vs. corpus expected_flagged):")
+		fmt.Printf("  TP: %-6d FP: %-6d TN: %-6d FN: %-6d  Precision: %.3f  Recall: %.3f\n",
+			c.TruePositive, c.FalsePositive, c.TrueNegative, c.FalseNegative, c.Precision, c.Recall)
+	}
+
+	if len(results.ScenarioResults) > 0 {
+		fmt.Println()
+		fmt.Println("Per-Scenario Breakdown:")
+		fmt.Println("┌────────────────────┬───────────┬──────────┬──────────┬───────────┬────────┐")
+		fmt.Println("│ Scenario           │ Requests  │ P50 (ms) │ P99 (ms) │ Precision │ Recall │")
+		fmt.Println("├────────────────────┼───────────┼──────────┼──────────┼───────────┼────────┤")
+		for _, sr := range results.ScenarioResults {
+			precision, recall := 0.0, 0.0
+			if sr.Confusion != nil {
+				precision, recall = sr.Confusion.Precision, sr.Confusion.Recall
+			}
+			fmt.Printf("│ %-18s │ %9d │ %8.1f │ %8.1f │ %9.3f │ %6.3f │\n",
+				sr.Name, sr.Requests, sr.LatencyP50, sr.LatencyP99, precision, recall)
+		}
+		fmt.Println("└────────────────────┴───────────┴──────────┴──────────┴───────────┴────────┘")
+	}
+
+	if results.Verdict != nil {
+		fmt.Println()
+		if results.Verdict.Passed {
+			fmt.Println("✅ SLO: PASSED")
+		} else {
+			fmt.Println("❌ SLO: FAILED")
+			for _, v := range results.Verdict.Violations {
+				if v.TenantID != "" {
+					fmt.Printf("   - %s[%s]: observed %.3f exceeds threshold %.3f\n", v.Metric, v.TenantID, v.Observed, v.Threshold)
+				} else {
+					fmt.Printf("   - %s: observed %.3f exceeds threshold %.3f\n", v.Metric, v.Observed, v.Threshold)
+				}
+			}
+		}
+	}
 }
 
 func printJSONResults(results *Results) {
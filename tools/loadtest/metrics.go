@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/HdrHistogram/hdrhistogram-go"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Metrics collects and aggregates load test metrics
@@ -14,13 +15,49 @@ type Metrics struct {
 	startTime time.Time
 	endTime   time.Time
 
-	// Global histogram (microseconds for precision)
+	// Global histogram of service time: end - actualStart (microseconds
+	// for precision).
 	histogram *hdrhistogram.Histogram
 
+	// responseHistogram is the uncorrected response time seen by the
+	// schedule: end - intendedStart. Under saturation this runs well
+	// ahead of histogram, since it also counts time a request spent
+	// queued waiting for a free worker.
+	responseHistogram *hdrhistogram.Histogram
+
+	// correctedHistogram backfills the service-time histogram for
+	// requests the open-loop schedule would have issued while a worker
+	// was still busy with a late one, via
+	// hdrhistogram.RecordCorrectedValue. Without this, percentiles look
+	// optimistic under load because the requests the harness fell behind
+	// on are under-represented.
+	correctedHistogram *hdrhistogram.Histogram
+
+	// scheduleLagHistogram tracks actualStart - intendedStart: how far
+	// behind the target RPS schedule the harness had fallen by the time a
+	// worker picked up each request.
+	scheduleLagHistogram *hdrhistogram.Histogram
+
+	// expectedIntervalUs is 1e6/targetRPS, the inter-arrival time
+	// RecordCorrectedValue uses to backfill missed samples.
+	expectedIntervalUs int64
+
 	// Per-tenant histograms
-	tenantHistograms map[string]*hdrhistogram.Histogram
-	tenantSuccess    map[string]int64
-	tenantTotal      map[string]int64
+	tenantHistograms          map[string]*hdrhistogram.Histogram
+	tenantResponseHistograms  map[string]*hdrhistogram.Histogram
+	tenantCorrectedHistograms map[string]*hdrhistogram.Histogram
+	tenantSuccess             map[string]int64
+	tenantTotal               map[string]int64
+
+	// Per-scenario latency and detection-quality tracking, populated only
+	// for requests the Client sampled from a ScenarioSet.
+	scenarioHistograms map[string]*hdrhistogram.Histogram
+	scenarioRequests   map[string]int64
+	scenarioConfusion  map[string]*confusionCounts
+
+	// confusion is the overall confusion matrix across every scenario
+	// request with a known expected_flagged answer.
+	confusion confusionCounts
 
 	// Counters
 	totalRequests int64
@@ -29,17 +66,64 @@ type Metrics struct {
 	errorCount    int64
 	flaggedCount  int64
 	rateLimited   int64
+
+	// Live Prometheus metrics, registered once by NewMetrics so an
+	// operator can point Grafana at ServeMetrics while the run is still
+	// in progress instead of waiting for GetResults at the end.
+	promRequestsTotal    *prometheus.CounterVec
+	promFlaggedTotal     prometheus.Counter
+	promRateLimitedTotal prometheus.Counter
 }
 
-// NewMetrics creates a new metrics collector
-func NewMetrics() *Metrics {
-	return &Metrics{
+// NewMetrics creates a new metrics collector. targetRPS is the schedule
+// Record's coordinated-omission correction backfills against. Its live
+// Prometheus metrics (and the loadtest_latency_seconds collector ServeMetrics
+// exposes) are registered against the default registry immediately, so a
+// caller must not construct more than one Metrics per process.
+func NewMetrics(targetRPS int) *Metrics {
+	m := &Metrics{
 		// HDR Histogram: 1us to 60s range, 3 significant figures
-		histogram:        hdrhistogram.New(1, 60_000_000, 3),
-		tenantHistograms: make(map[string]*hdrhistogram.Histogram),
-		tenantSuccess:    make(map[string]int64),
-		tenantTotal:      make(map[string]int64),
+		histogram:            hdrhistogram.New(1, 60_000_000, 3),
+		responseHistogram:    hdrhistogram.New(1, 60_000_000, 3),
+		correctedHistogram:   hdrhistogram.New(1, 60_000_000, 3),
+		scheduleLagHistogram: hdrhistogram.New(1, 60_000_000, 3),
+		expectedIntervalUs:   int64(1_000_000 / targetRPS),
+
+		tenantHistograms:          make(map[string]*hdrhistogram.Histogram),
+		tenantResponseHistograms:  make(map[string]*hdrhistogram.Histogram),
+		tenantCorrectedHistograms: make(map[string]*hdrhistogram.Histogram),
+		tenantSuccess:             make(map[string]int64),
+		tenantTotal:               make(map[string]int64),
+
+		scenarioHistograms: make(map[string]*hdrhistogram.Histogram),
+		scenarioRequests:   make(map[string]int64),
+		scenarioConfusion:  make(map[string]*confusionCounts),
+
+		promRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "loadtest_requests_total",
+				Help: "Total load test requests by tenant and outcome",
+			},
+			[]string{"tenant", "outcome"},
+		),
+		promFlaggedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "loadtest_flagged_total",
+				Help: "Total requests the guardrail API reported as flagged",
+			},
+		),
+		promRateLimitedTotal: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "loadtest_rate_limited_total",
+				Help: "Total requests rejected with 429",
+			},
+		),
 	}
+
+	prometheus.MustRegister(m.promRequestsTotal, m.promFlaggedTotal, m.promRateLimitedTotal)
+	prometheus.MustRegister(newLatencyCollector(m))
+
+	return m
 }
 
 // Start marks the beginning of the test
@@ -56,6 +140,53 @@ func (m *Metrics) Stop() {
 	m.mu.Unlock()
 }
 
+// confusionCounts tallies a detector's outcomes against known answers:
+// TruePositive/FalsePositive/TrueNegative/FalseNegative counts of
+// result.Flagged vs. a corpus record's expected_flagged.
+type confusionCounts struct {
+	truePositive  int64
+	falsePositive int64
+	trueNegative  int64
+	falseNegative int64
+}
+
+// record tallies one scored outcome into c.
+func (c *confusionCounts) record(expectedFlagged, flagged bool) {
+	switch {
+	case expectedFlagged && flagged:
+		c.truePositive++
+	case !expectedFlagged && flagged:
+		c.falsePositive++
+	case !expectedFlagged && !flagged:
+		c.trueNegative++
+	default:
+		c.falseNegative++
+	}
+}
+
+// precisionRecall computes precision and recall from c, returning 0 for
+// either when its denominator is 0.
+func (c *confusionCounts) precisionRecall() (precision, recall float64) {
+	if tp := c.truePositive + c.falsePositive; tp > 0 {
+		precision = float64(c.truePositive) / float64(tp)
+	}
+	if tp := c.truePositive + c.falseNegative; tp > 0 {
+		recall = float64(c.truePositive) / float64(tp)
+	}
+	return precision, recall
+}
+
+// TotalRequests returns the number of requests completed (not merely
+// scheduled) so far, for Runner's saturation check - the schedule's send
+// rate stays pinned to target RPS even when the SUT can't keep up, since
+// requestCh is sized to absorb the whole run, so only completions reveal
+// whether workers are actually draining it fast enough.
+func (m *Metrics) TotalRequests() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.totalRequests
+}
+
 // Record adds a request result to the metrics
 func (m *Metrics) Record(result RequestResult) {
 	m.mu.Lock()
@@ -63,33 +194,91 @@ func (m *Metrics) Record(result RequestResult) {
 
 	m.totalRequests++
 
-	// Record latency in microseconds
+	// Record service time (end - actualStart) in microseconds.
 	latencyUs := result.Latency.Microseconds()
 	if latencyUs > 0 {
 		m.histogram.RecordValue(latencyUs)
+		m.correctedHistogram.RecordCorrectedValue(latencyUs, m.expectedIntervalUs)
 
-		// Per-tenant histogram
+		// Per-tenant histograms
 		if _, ok := m.tenantHistograms[result.TenantID]; !ok {
 			m.tenantHistograms[result.TenantID] = hdrhistogram.New(1, 60_000_000, 3)
+			m.tenantCorrectedHistograms[result.TenantID] = hdrhistogram.New(1, 60_000_000, 3)
 		}
 		m.tenantHistograms[result.TenantID].RecordValue(latencyUs)
+		m.tenantCorrectedHistograms[result.TenantID].RecordCorrectedValue(latencyUs, m.expectedIntervalUs)
+
+		if result.Scenario != "" {
+			if _, ok := m.scenarioHistograms[result.Scenario]; !ok {
+				m.scenarioHistograms[result.Scenario] = hdrhistogram.New(1, 60_000_000, 3)
+			}
+			m.scenarioHistograms[result.Scenario].RecordValue(latencyUs)
+		}
+	}
+
+	if result.Scenario != "" {
+		m.scenarioRequests[result.Scenario]++
+	}
+
+	// Score the response against the corpus's known answer. Only scored
+	// on success, since Flagged is parsed from the response body and is
+	// meaningless on a request that errored or timed out.
+	if result.HasExpected && result.Success {
+		m.confusion.record(result.ExpectedFlagged, result.Flagged)
+
+		if result.Scenario != "" {
+			if _, ok := m.scenarioConfusion[result.Scenario]; !ok {
+				m.scenarioConfusion[result.Scenario] = &confusionCounts{}
+			}
+			m.scenarioConfusion[result.Scenario].record(result.ExpectedFlagged, result.Flagged)
+		}
+	}
+
+	// Record uncorrected response time (end - intendedStart) and how far
+	// behind schedule this request had fallen, when it was scheduled
+	// open-loop at all.
+	if !result.IntendedStart.IsZero() {
+		responseTimeUs := result.End.Sub(result.IntendedStart).Microseconds()
+		if responseTimeUs < 1 {
+			responseTimeUs = 1
+		}
+		m.responseHistogram.RecordValue(responseTimeUs)
+
+		if _, ok := m.tenantResponseHistograms[result.TenantID]; !ok {
+			m.tenantResponseHistograms[result.TenantID] = hdrhistogram.New(1, 60_000_000, 3)
+		}
+		m.tenantResponseHistograms[result.TenantID].RecordValue(responseTimeUs)
+
+		scheduleLagUs := result.ActualStart.Sub(result.IntendedStart).Microseconds()
+		if scheduleLagUs < 1 {
+			scheduleLagUs = 1
+		}
+		m.scheduleLagHistogram.RecordValue(scheduleLagUs)
 	}
 
 	m.tenantTotal[result.TenantID]++
 
+	outcome := "error"
 	if result.Success {
+		outcome = "success"
 		m.successCount++
 		m.tenantSuccess[result.TenantID]++
 		if result.Flagged {
 			m.flaggedCount++
+			m.promFlaggedTotal.Inc()
 		}
 	} else if result.Timeout {
+		outcome = "timeout"
 		m.timeoutCount++
 	} else if result.Error != nil && result.Error.Error() == "rate limited" {
+		outcome = "rate_limited"
 		m.rateLimited++
+		m.promRateLimitedTotal.Inc()
 	} else {
 		m.errorCount++
 	}
+
+	m.promRequestsTotal.WithLabelValues(result.TenantID, outcome).Inc()
 }
 
 // Results represents the final test results
@@ -104,10 +293,32 @@ type Results struct {
 	LatencyP90 float64 `json:"latency_p90_ms"`
 	LatencyP95 float64 `json:"latency_p95_ms"`
 	LatencyP99 float64 `json:"latency_p99_ms"`
+
+	// LatencyP999 and LatencyP9999 are the far-tail percentiles HDR
+	// histogram buckets make cheap to track continuously, unlike a
+	// sort-based percentile that would need every sample retained.
+	LatencyP999  float64 `json:"latency_p999_ms"`
+	LatencyP9999 float64 `json:"latency_p9999_ms"`
+
 	LatencyMax float64 `json:"latency_max_ms"`
 	LatencyMin float64 `json:"latency_min_ms"`
 	LatencyAvg float64 `json:"latency_avg_ms"`
 
+	// LatencyP99Uncorrected is P99 of end - intendedStart: the response
+	// time a client actually waiting on the open-loop schedule would have
+	// seen, including time queued behind a saturated worker pool.
+	LatencyP99Uncorrected float64 `json:"latency_p99_uncorrected_ms"`
+
+	// LatencyP99Corrected is P99 of the service-time histogram after
+	// hdrhistogram.RecordCorrectedValue has backfilled it for requests
+	// the schedule would have issued while a worker was still busy.
+	LatencyP99Corrected float64 `json:"latency_p99_corrected_ms"`
+
+	// ScheduleLagMs is P99 of actualStart - intendedStart: how far behind
+	// the target RPS schedule the harness had fallen by the time a
+	// worker picked up each request.
+	ScheduleLagMs float64 `json:"schedule_lag_p99_ms"`
+
 	// Counts
 	SuccessCount int64 `json:"success_count"`
 	TimeoutCount int64 `json:"timeout_count"`
@@ -117,19 +328,72 @@ type Results struct {
 
 	// Per-tenant results
 	TenantResults []TenantResult `json:"tenant_results,omitempty"`
+
+	// Per-scenario results, set only for requests the Client sampled from
+	// a ScenarioSet.
+	ScenarioResults []ScenarioResult `json:"scenario_results,omitempty"`
+
+	// Confusion is the overall detection-quality confusion matrix across
+	// every scenario request with a known expected_flagged answer.
+	Confusion *ConfusionMatrix `json:"confusion_matrix,omitempty"`
+
+	// Verdict is the SLO pass/fail outcome, set only when GetResults is
+	// called with a non-nil SLO.
+	Verdict *Verdict `json:"verdict,omitempty"`
+}
+
+// ScenarioResult holds per-scenario latency and detection-quality
+// metrics: a scenario mixes latency measurement (this run's RPS
+// contribution) with quality measurement (how well the ensemble's
+// Flagged verdict matched the corpus's expected_flagged label).
+type ScenarioResult struct {
+	Name       string  `json:"name"`
+	Requests   int64   `json:"requests"`
+	LatencyP50 float64 `json:"latency_p50_ms"`
+	LatencyP99 float64 `json:"latency_p99_ms"`
+
+	Confusion *ConfusionMatrix `json:"confusion_matrix,omitempty"`
+}
+
+// ConfusionMatrix reports detector outcomes against known answers, plus
+// precision/recall derived from them.
+type ConfusionMatrix struct {
+	TruePositive  int64   `json:"true_positive"`
+	FalsePositive int64   `json:"false_positive"`
+	TrueNegative  int64   `json:"true_negative"`
+	FalseNegative int64   `json:"false_negative"`
+	Precision     float64 `json:"precision"`
+	Recall        float64 `json:"recall"`
+}
+
+// toConfusionMatrix converts a confusionCounts accumulator to its wire
+// representation, computing precision/recall.
+func toConfusionMatrix(c *confusionCounts) *ConfusionMatrix {
+	precision, recall := c.precisionRecall()
+	return &ConfusionMatrix{
+		TruePositive:  c.truePositive,
+		FalsePositive: c.falsePositive,
+		TrueNegative:  c.trueNegative,
+		FalseNegative: c.falseNegative,
+		Precision:     precision,
+		Recall:        recall,
+	}
 }
 
 // TenantResult holds per-tenant metrics
 type TenantResult struct {
-	TenantID    string  `json:"tenant_id"`
-	Requests    int64   `json:"requests"`
-	SuccessRate float64 `json:"success_rate"`
-	LatencyP50  float64 `json:"latency_p50_ms"`
-	LatencyP99  float64 `json:"latency_p99_ms"`
+	TenantID              string  `json:"tenant_id"`
+	Requests              int64   `json:"requests"`
+	SuccessRate           float64 `json:"success_rate"`
+	LatencyP50            float64 `json:"latency_p50_ms"`
+	LatencyP99            float64 `json:"latency_p99_ms"`
+	LatencyP99Uncorrected float64 `json:"latency_p99_uncorrected_ms"`
+	LatencyP99Corrected   float64 `json:"latency_p99_corrected_ms"`
 }
 
-// GetResults computes the final results
-func (m *Metrics) GetResults(targetRPS int) *Results {
+// GetResults computes the final results and, if slo is non-nil, evaluates
+// it against those results to populate Results.Verdict.
+func (m *Metrics) GetResults(targetRPS int, slo *SLO) *Results {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -149,10 +413,18 @@ func (m *Metrics) GetResults(targetRPS int) *Results {
 		LatencyP90: float64(m.histogram.ValueAtPercentile(90)) / 1000.0,
 		LatencyP95: float64(m.histogram.ValueAtPercentile(95)) / 1000.0,
 		LatencyP99: float64(m.histogram.ValueAtPercentile(99)) / 1000.0,
+
+		LatencyP999:  float64(m.histogram.ValueAtPercentile(99.9)) / 1000.0,
+		LatencyP9999: float64(m.histogram.ValueAtPercentile(99.99)) / 1000.0,
+
 		LatencyMax: float64(m.histogram.Max()) / 1000.0,
 		LatencyMin: float64(m.histogram.Min()) / 1000.0,
 		LatencyAvg: m.histogram.Mean() / 1000.0,
 
+		LatencyP99Uncorrected: float64(m.responseHistogram.ValueAtPercentile(99)) / 1000.0,
+		LatencyP99Corrected:   float64(m.correctedHistogram.ValueAtPercentile(99)) / 1000.0,
+		ScheduleLagMs:         float64(m.scheduleLagHistogram.ValueAtPercentile(99)) / 1000.0,
+
 		SuccessCount: m.successCount,
 		TimeoutCount: m.timeoutCount,
 		ErrorCount:   m.errorCount,
@@ -169,13 +441,43 @@ func (m *Metrics) GetResults(targetRPS int) *Results {
 			successRate = float64(success) / float64(total)
 		}
 
-		results.TenantResults = append(results.TenantResults, TenantResult{
+		result := TenantResult{
 			TenantID:    tenantID,
 			Requests:    total,
 			SuccessRate: successRate,
 			LatencyP50:  float64(hist.ValueAtPercentile(50)) / 1000.0,
 			LatencyP99:  float64(hist.ValueAtPercentile(99)) / 1000.0,
-		})
+		}
+		if respHist, ok := m.tenantResponseHistograms[tenantID]; ok {
+			result.LatencyP99Uncorrected = float64(respHist.ValueAtPercentile(99)) / 1000.0
+		}
+		if corrHist, ok := m.tenantCorrectedHistograms[tenantID]; ok {
+			result.LatencyP99Corrected = float64(corrHist.ValueAtPercentile(99)) / 1000.0
+		}
+
+		results.TenantResults = append(results.TenantResults, result)
+	}
+
+	// Per-scenario results
+	for name, hist := range m.scenarioHistograms {
+		result := ScenarioResult{
+			Name:       name,
+			Requests:   m.scenarioRequests[name],
+			LatencyP50: float64(hist.ValueAtPercentile(50)) / 1000.0,
+			LatencyP99: float64(hist.ValueAtPercentile(99)) / 1000.0,
+		}
+		if cc, ok := m.scenarioConfusion[name]; ok {
+			result.Confusion = toConfusionMatrix(cc)
+		}
+		results.ScenarioResults = append(results.ScenarioResults, result)
+	}
+
+	if m.confusion != (confusionCounts{}) {
+		results.Confusion = toConfusionMatrix(&m.confusion)
+	}
+
+	if slo != nil {
+		results.Verdict = EvaluateSLO(slo, results)
 	}
 
 	return results
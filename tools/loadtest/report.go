@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// WriteJSONReport writes results as JSON to path, for pipelines that want
+// the full metrics rather than just the pass/fail verdict.
+func WriteJSONReport(path string, results *Results) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON report: %w", err)
+	}
+	return nil
+}
+
+// junitTestSuites and junitTestCase mirror the minimal subset of the JUnit
+// XML schema CI dashboards (GitLab, Jenkins, GitHub Actions annotations)
+// know how to render: one testsuite, holding one testcase per SLO
+// violation, or a single passing "slo" testcase when there are none.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name    string        `xml:"name,attr"`
+	Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnitReport writes verdict as a JUnit XML report to path: one
+// passing testcase named "slo" if there were no violations, otherwise one
+// failing testcase per violation.
+func WriteJUnitReport(path string, verdict *Verdict) error {
+	suite := junitTestSuite{Name: "loadtest-slo"}
+
+	if len(verdict.Violations) == 0 {
+		suite.Tests = 1
+		suite.TestCases = []junitTestCase{{Name: "slo"}}
+	} else {
+		suite.Tests = len(verdict.Violations)
+		suite.Failures = len(verdict.Violations)
+		for _, v := range verdict.Violations {
+			name := v.Metric
+			if v.TenantID != "" {
+				name = fmt.Sprintf("%s[%s]", v.Metric, v.TenantID)
+			}
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Name: name,
+				Failure: &junitFailure{
+					Message: fmt.Sprintf("observed %.3f exceeds threshold %.3f", v.Observed, v.Threshold),
+				},
+			})
+		}
+	}
+
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JUnit report: %w", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write JUnit report: %w", err)
+	}
+	return nil
+}
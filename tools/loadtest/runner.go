@@ -19,21 +19,53 @@ func NewRunner(cfg Config, client *Client) *Runner {
 	return &Runner{
 		cfg:     cfg,
 		client:  client,
-		metrics: NewMetrics(),
+		metrics: NewMetrics(cfg.RPS),
 	}
 }
 
-// Run executes the load test
+// scheduledRequest is one slot on the open-loop schedule: a request that
+// was meant to start at intendedStart regardless of when a worker actually
+// gets to it.
+type scheduledRequest struct {
+	intendedStart time.Time
+}
+
+// saturationWindow is how often Run samples the achieved completion rate to
+// check it against target RPS; it piggybacks on the progress ticker so
+// watching for saturation costs nothing extra.
+const saturationWindow = 5 * time.Second
+
+// saturationWarnAfter is how long the achieved completion rate must stay below
+// saturationThreshold of target RPS before Run warns that the SUT looks
+// saturated, rather than reacting to a single noisy window.
+const saturationWarnAfter = 10 * time.Second
+
+// saturationThreshold is the fraction of target RPS below which a window
+// counts as "behind schedule" for saturation detection.
+const saturationThreshold = 0.95
+
+// Run executes the load test. Requests are scheduled open-loop: each tick
+// computes the request's intended start time from the target RPS rather
+// than from when the ticker happened to fire, and requestCh is sized to
+// hold the whole run's worth of requests so a burst of slow workers queues
+// up instead of silently dropping requests - dropping here would be
+// coordinated omission, hiding exactly the saturation behavior this tool
+// exists to measure.
 func (r *Runner) Run(ctx context.Context) *Results {
-	// Channel for work distribution
-	requestCh := make(chan struct{}, r.cfg.Workers*2)
-	
+	scheduledRequests := r.cfg.RPS * (int(r.cfg.Duration.Seconds()) + 5)
+	requestCh := make(chan scheduledRequest, scheduledRequests)
+
 	// Wait group for workers
 	var wg sync.WaitGroup
 
 	// Start metrics collection
 	r.metrics.Start()
 
+	if r.cfg.MetricsAddr != "" {
+		r.metrics.ServeMetrics(ctx, r.cfg.MetricsAddr)
+		fmt.Printf("📊 Live metrics: http://%s/metrics\n", r.cfg.MetricsAddr)
+	}
+
 	// Start workers
 	for i := 0; i < r.cfg.Workers; i++ {
 		wg.Add(1)
@@ -44,7 +76,8 @@ func (r *Runner) Run(ctx context.Context) *Results {
 	}
 
 	// Rate limiter: send requests at target RPS
-	ticker := time.NewTicker(time.Second / time.Duration(r.cfg.RPS))
+	interval := time.Second / time.Duration(r.cfg.RPS)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	// Progress reporting
@@ -54,6 +87,16 @@ func (r *Runner) Run(ctx context.Context) *Results {
 	startTime := time.Now()
 	requestsSent := 0
 
+	// lastWindowCompleted and belowTargetFor track the achieved completion
+	// rate over each saturationWindow so Run can warn when it has stayed
+	// below target for longer than saturationWarnAfter. This has to be
+	// measured from completed requests (r.metrics), not from requestsSent:
+	// requestCh is sized to hold the whole run's schedule, so the
+	// scheduler keeps sending at target RPS even while the SUT is fully
+	// saturated and workers are falling further behind every tick.
+	var lastWindowCompleted int64
+	belowTargetFor := time.Duration(0)
+
 	fmt.Println("🚀 Load test started...")
 	fmt.Println()
 
@@ -73,12 +116,33 @@ loop:
 				requestsSent,
 				currentRPS)
 
+			windowCompleted := r.metrics.TotalRequests()
+			windowRPS := float64(windowCompleted-lastWindowCompleted) / saturationWindow.Seconds()
+			lastWindowCompleted = windowCompleted
+			if windowRPS < float64(r.cfg.RPS)*saturationThreshold {
+				belowTargetFor += saturationWindow
+				if belowTargetFor >= saturationWarnAfter {
+					fmt.Printf("⚠️  SUT looks saturated: achieved %.1f req/s over the last %s, below target %d req/s\n",
+						windowRPS, saturationWindow, r.cfg.RPS)
+				}
+			} else {
+				belowTargetFor = 0
+			}
+
 		case <-ticker.C:
+			// intendedStart is the nominal schedule position, not
+			// time.Now(), so the schedule itself never drifts even if
+			// the ticker fires a little late under load.
+			intendedStart := startTime.Add(time.Duration(requestsSent) * interval)
 			select {
-			case requestCh <- struct{}{}:
+			case requestCh <- scheduledRequest{intendedStart: intendedStart}:
 				requestsSent++
 			default:
-				// Channel full, workers are falling behind
+				// requestCh is sized to hold the entire run's schedule,
+				// so this only fires if workers fell behind by more than
+				// the whole test duration - log it rather than silently
+				// losing the sample.
+				fmt.Println("⚠️  request schedule buffer exhausted, dropping a request")
 			}
 		}
 	}
@@ -93,12 +157,12 @@ loop:
 	fmt.Println()
 	fmt.Println("✅ Load test completed!")
 
-	return r.metrics.GetResults(r.cfg.RPS)
+	return r.metrics.GetResults(r.cfg.RPS, r.cfg.SLO)
 }
 
 // worker processes requests from the request channel
-func (r *Runner) worker(ctx context.Context, id int, requestCh <-chan struct{}) {
-	for range requestCh {
+func (r *Runner) worker(ctx context.Context, id int, requestCh <-chan scheduledRequest) {
+	for req := range requestCh {
 		// Check if context is cancelled
 		select {
 		case <-ctx.Done():
@@ -106,11 +170,17 @@ func (r *Runner) worker(ctx context.Context, id int, requestCh <-chan struct{})
 		default:
 		}
 
+		actualStart := time.Now()
+
 		// Create request context with timeout
 		reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 		result := r.client.SendRequest(reqCtx)
 		cancel()
 
+		result.IntendedStart = req.intendedStart
+		result.ActualStart = actualStart
+		result.End = time.Now()
+
 		// Record the result
 		r.metrics.Record(result)
 	}
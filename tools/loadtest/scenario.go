@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CorpusRecord is one labeled example in a scenario's corpus file. It
+// drives both the request text sent to the guardrail API and the
+// confusion-matrix scoring of the response against a known answer.
+type CorpusRecord struct {
+	Text            string   `json:"text"`
+	ExpectedFlagged bool     `json:"expected_flagged"`
+	ExpectedReasons []string `json:"expected_reasons,omitempty"`
+}
+
+// Scenario describes one named component of a weighted request mix: how
+// often to sample it, which ValidateRequest.Type it should be sent as,
+// and the corpus its request text and expected labels are drawn from.
+type Scenario struct {
+	Name       string            `yaml:"name" json:"name"`
+	Weight     float64           `yaml:"weight" json:"weight"`
+	Type       string            `yaml:"type" json:"type"`
+	CorpusPath string            `yaml:"corpus_path" json:"corpus_path"`
+	Metadata   map[string]string `yaml:"metadata,omitempty" json:"metadata,omitempty"`
+
+	corpus []CorpusRecord
+}
+
+// scenarioFile is the on-disk shape of a -scenario-file.
+type scenarioFile struct {
+	Scenarios []Scenario `yaml:"scenarios"`
+}
+
+// ScenarioSet is a loaded, weight-sampleable pool of scenarios with their
+// corpora resolved into memory.
+type ScenarioSet struct {
+	scenarios   []Scenario
+	totalWeight float64
+}
+
+// LoadScenarios reads a YAML/JSON scenario file at path, plus every
+// scenario's corpus_path (resolved relative to path's directory if not
+// absolute), and returns a ScenarioSet ready for Pick.
+func LoadScenarios(path string) (*ScenarioSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+
+	var file scenarioFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file: %w", err)
+	}
+	if len(file.Scenarios) == 0 {
+		return nil, fmt.Errorf("scenario file %s defines no scenarios", path)
+	}
+
+	dir := filepath.Dir(path)
+	set := &ScenarioSet{}
+	for _, sc := range file.Scenarios {
+		corpusPath := sc.CorpusPath
+		if !filepath.IsAbs(corpusPath) {
+			corpusPath = filepath.Join(dir, corpusPath)
+		}
+
+		corpus, err := loadCorpus(corpusPath)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %q: %w", sc.Name, err)
+		}
+		if len(corpus) == 0 {
+			return nil, fmt.Errorf("scenario %q: corpus %s has no records", sc.Name, corpusPath)
+		}
+		if sc.Weight <= 0 {
+			sc.Weight = 1
+		}
+
+		sc.corpus = corpus
+		set.scenarios = append(set.scenarios, sc)
+		set.totalWeight += sc.Weight
+	}
+
+	return set, nil
+}
+
+// loadCorpus reads a JSONL file of CorpusRecord values, one per line.
+func loadCorpus(path string) ([]CorpusRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+	defer f.Close()
+
+	var records []CorpusRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		var rec CorpusRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			return nil, fmt.Errorf("failed to parse corpus record: %w", err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	return records, nil
+}
+
+// Pick weight-samples a scenario and then a random record from its
+// corpus.
+func (s *ScenarioSet) Pick() (*Scenario, *CorpusRecord) {
+	r := rand.Float64() * s.totalWeight
+
+	var cumulative float64
+	for i := range s.scenarios {
+		cumulative += s.scenarios[i].Weight
+		if r < cumulative {
+			sc := &s.scenarios[i]
+			return sc, &sc.corpus[rand.Intn(len(sc.corpus))]
+		}
+	}
+
+	// Floating point rounding can leave r just past the last cumulative
+	// boundary; fall back to the last scenario rather than picking none.
+	sc := &s.scenarios[len(s.scenarios)-1]
+	return sc, &sc.corpus[rand.Intn(len(sc.corpus))]
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SLO is a set of pass/fail thresholds a load test run is checked against,
+// in the spirit of the latency-threshold verdicts Kubernetes' e2e
+// service-latency tests produce. A zero value for any threshold means that
+// check is skipped, matching this repo's "0 = unlimited" convention
+// elsewhere (e.g. limits.Limits.MaxURLsPerUser).
+type SLO struct {
+	MinRPS                float64 `yaml:"min_rps" json:"min_rps"`
+	MaxLatencyP50Ms       float64 `yaml:"max_latency_p50_ms" json:"max_latency_p50_ms"`
+	MaxLatencyP95Ms       float64 `yaml:"max_latency_p95_ms" json:"max_latency_p95_ms"`
+	MaxLatencyP99Ms       float64 `yaml:"max_latency_p99_ms" json:"max_latency_p99_ms"`
+	MaxErrorRate          float64 `yaml:"max_error_rate" json:"max_error_rate"`
+	MaxRateLimitedRate    float64 `yaml:"max_rate_limited_rate" json:"max_rate_limited_rate"`
+	MaxTenantLatencyP99Ms float64 `yaml:"max_tenant_latency_p99_ms,omitempty" json:"max_tenant_latency_p99_ms,omitempty"`
+}
+
+// SLOViolation names one threshold a run failed, so a CI consumer can
+// report exactly what regressed without re-deriving it from the raw
+// percentiles.
+type SLOViolation struct {
+	Metric    string  `json:"metric"`
+	Observed  float64 `json:"observed"`
+	Threshold float64 `json:"threshold"`
+	TenantID  string  `json:"tenant_id,omitempty"`
+}
+
+// Verdict is the pass/fail outcome of evaluating an SLO against Results.
+type Verdict struct {
+	Passed     bool           `json:"passed"`
+	Violations []SLOViolation `json:"violations,omitempty"`
+}
+
+// LoadSLO reads an SLO from a YAML or JSON file at path. YAML is a
+// superset of JSON, so a single yaml.Unmarshal handles both without
+// needing to branch on file extension.
+func LoadSLO(path string) (*SLO, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SLO file: %w", err)
+	}
+
+	var slo SLO
+	if err := yaml.Unmarshal(data, &slo); err != nil {
+		return nil, fmt.Errorf("failed to parse SLO file: %w", err)
+	}
+	return &slo, nil
+}
+
+// EvaluateSLO checks results against slo's thresholds, in the order a human
+// reading the report would expect: throughput, global latency, error
+// budget, then per-tenant latency.
+func EvaluateSLO(slo *SLO, results *Results) *Verdict {
+	var violations []SLOViolation
+
+	if slo.MinRPS > 0 && results.AchievedRPS < slo.MinRPS {
+		violations = append(violations, SLOViolation{
+			Metric: "achieved_rps", Observed: results.AchievedRPS, Threshold: slo.MinRPS,
+		})
+	}
+	if slo.MaxLatencyP50Ms > 0 && results.LatencyP50 > slo.MaxLatencyP50Ms {
+		violations = append(violations, SLOViolation{
+			Metric: "latency_p50_ms", Observed: results.LatencyP50, Threshold: slo.MaxLatencyP50Ms,
+		})
+	}
+	if slo.MaxLatencyP95Ms > 0 && results.LatencyP95 > slo.MaxLatencyP95Ms {
+		violations = append(violations, SLOViolation{
+			Metric: "latency_p95_ms", Observed: results.LatencyP95, Threshold: slo.MaxLatencyP95Ms,
+		})
+	}
+	if slo.MaxLatencyP99Ms > 0 && results.LatencyP99 > slo.MaxLatencyP99Ms {
+		violations = append(violations, SLOViolation{
+			Metric: "latency_p99_ms", Observed: results.LatencyP99, Threshold: slo.MaxLatencyP99Ms,
+		})
+	}
+
+	if results.TotalRequests > 0 {
+		errorRate := float64(results.ErrorCount) / float64(results.TotalRequests)
+		if slo.MaxErrorRate > 0 && errorRate > slo.MaxErrorRate {
+			violations = append(violations, SLOViolation{
+				Metric: "error_rate", Observed: errorRate, Threshold: slo.MaxErrorRate,
+			})
+		}
+
+		rateLimitedRate := float64(results.RateLimited) / float64(results.TotalRequests)
+		if slo.MaxRateLimitedRate > 0 && rateLimitedRate > slo.MaxRateLimitedRate {
+			violations = append(violations, SLOViolation{
+				Metric: "rate_limited_rate", Observed: rateLimitedRate, Threshold: slo.MaxRateLimitedRate,
+			})
+		}
+	}
+
+	if slo.MaxTenantLatencyP99Ms > 0 {
+		for _, tr := range results.TenantResults {
+			if tr.LatencyP99 > slo.MaxTenantLatencyP99Ms {
+				violations = append(violations, SLOViolation{
+					Metric: "tenant_latency_p99_ms", Observed: tr.LatencyP99,
+					Threshold: slo.MaxTenantLatencyP99Ms, TenantID: tr.TenantID,
+				})
+			}
+		}
+	}
+
+	return &Verdict{Passed: len(violations) == 0, Violations: violations}
+}